@@ -1,9 +1,29 @@
 package id3
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gbkr-com/id3/internal/wire"
 )
 
 // From https://iq.opengenus.org/id3-algorithm/
@@ -113,7 +133,7 @@ func TestAverageEntropy(t *testing.T) {
 
 func TestLearningOutput(t *testing.T) {
 	view, _ := Read(strings.NewReader(example))
-	decision := Learn(view, "play")
+	decision, _ := Learn(view, "play")
 	//
 	//
 	//
@@ -195,8 +215,2657 @@ func TestDecide(t *testing.T) {
 	//
 	// Test.
 	//
-	answer := rule.Decide(data)
+	answer, _ := rule.Decide(data)
 	if answer[0] != data[1][4] {
 		t.Error()
 	}
 }
+
+func TestMutualInformation(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	//
+	// The mutual information with the class is the information gain.
+	//
+	mi := MutualInformation(view, "outlook", "play")
+	if fmt.Sprintf("%.3f", mi) != "0.247" {
+		t.Error()
+	}
+	m := MIMatrix(view, []string{"outlook", "humidity", "play"})
+	if fmt.Sprintf("%.3f", m[0][2]) != "0.247" || m[0][2] != m[2][0] {
+		t.Error()
+	}
+	if m[2][2] != TotalEntropy(view, "play") {
+		t.Error()
+	}
+}
+
+func TestToDOT(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToDOT(true)
+	if err != nil {
+		t.Error()
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, "digraph id3 {") {
+		t.Error()
+	}
+	if !strings.Contains(s, `n0 [label="outlook"];`) {
+		t.Error()
+	}
+	if !strings.Contains(s, `[label="yes\nyes=4", shape=ellipse];`) {
+		t.Error()
+	}
+}
+
+func TestString(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	s := decision.String()
+	if !strings.Contains(s, "outlook = overcast: yes (4)\n") {
+		t.Error()
+	}
+	if !strings.Contains(s, "|   humidity = high: no (3)\n") {
+		t.Error()
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToHTML()
+	if err != nil {
+		t.Error()
+	}
+	s := string(b)
+	if !strings.Contains(s, "<summary>outlook = sunny</summary>") {
+		t.Error()
+	}
+	if !strings.Contains(s, `<span class="counts">yes=4</span>`) {
+		t.Error()
+	}
+}
+
+func TestToSVG(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToSVG()
+	if err != nil {
+		t.Error()
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, "<svg ") || !strings.HasSuffix(s, "</svg>\n") {
+		t.Error()
+	}
+	if strings.Count(s, "<ellipse ") != 5 || strings.Count(s, "<rect ") != 3 {
+		t.Error()
+	}
+}
+
+func TestFromPMML(t *testing.T) {
+	const pmml = `<?xml version="1.0"?>
+<PMML xmlns="http://www.dmg.org/PMML-4_4" version="4.4">
+  <TreeModel functionName="classification">
+    <Node score="yes">
+      <True/>
+      <Node>
+        <SimplePredicate field="outlook" operator="equal" value="sunny"/>
+        <Node score="no">
+          <SimplePredicate field="humidity" operator="equal" value="high"/>
+          <ScoreDistribution value="no" recordCount="3"/>
+        </Node>
+        <Node score="yes">
+          <SimplePredicate field="humidity" operator="equal" value="normal"/>
+        </Node>
+      </Node>
+      <Node score="yes">
+        <SimpleSetPredicate field="outlook" booleanOperator="isIn">
+          <Array n="2" type="string">overcast "rain"</Array>
+        </SimpleSetPredicate>
+      </Node>
+    </Node>
+  </TreeModel>
+</PMML>`
+	d, err := FromPMML([]byte(pmml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Column != "outlook" || len(d.Cases) != 3 {
+		t.Error()
+	}
+	if d.Cases[0].Decide.Cases[0].Counts["no"] != 3 {
+		t.Error()
+	}
+	if d.Cases[2].Value != "rain" || d.Cases[2].Class != "yes" {
+		t.Error()
+	}
+	//
+	// Numeric splits other than on a threshold are not supported.
+	//
+	_, err = FromPMML([]byte(strings.Replace(pmml, `operator="equal" value="sunny"`, `operator="lessThan" value="3"`, 1)))
+	if err == nil {
+		t.Error()
+	}
+	//
+	// Each value of a set has its own copy of the decision below it.
+	//
+	set := strings.Replace(pmml, `</SimpleSetPredicate>`, `</SimpleSetPredicate>
+        <Node score="no"><SimplePredicate field="wind" operator="equal" value="strong"/></Node>
+        <Node score="yes"><SimplePredicate field="wind" operator="equal" value="weak"/></Node>`, 1)
+	d, err = FromPMML([]byte(set))
+	if err != nil || d.Validate(nil) != nil {
+		t.Fatal(d, err)
+	}
+	d.Cases[1].Decide.Cases[0].Class = "maybe"
+	if d.Cases[2].Decide.Column != "wind" || d.Cases[2].Decide.Cases[0].Class != "no" {
+		t.Error(d)
+	}
+}
+
+func TestToPMML(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	decision.caseFor("sunny").Decide.Cases = append(decision.caseFor("sunny").Decide.Cases, &Case{Value: "", Class: "no"})
+	decision.Default = "yes"
+	b, err := decision.ToPMML("play")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<PMML xmlns="http://www.dmg.org/PMML-4_4" version="4.4">`,
+		`<MiningField name="play" usageType="target"></MiningField>`,
+		`<SimplePredicate field="outlook" operator="equal" value="overcast"></SimplePredicate>`,
+		`<SimplePredicate field="humidity" operator="isMissing"></SimplePredicate>`,
+		`<ScoreDistribution value="yes" recordCount="4"></ScoreDistribution>`,
+	} {
+		if !strings.Contains(string(b), s) {
+			t.Error(s)
+		}
+	}
+	d, err := FromPMML(b)
+	if err != nil || !d.Equivalent(decision, true) || d.Default != "yes" {
+		t.Error(d, err)
+	}
+	//
+	// Thresholds are continuous fields, but "contains" cannot be expressed.
+	//
+	threshold := &Decision{Column: "temperature", Test: &Test{Op: opLessOrEqual, Operand: "75"}, Cases: []*Case{{Value: "true", Class: "yes"}, {Value: "false", Class: "no"}}}
+	b, err = threshold.ToPMML("play")
+	if err != nil || !strings.Contains(string(b), `operator="greaterThan" value="75"`) || !strings.Contains(string(b), `optype="continuous" dataType="double"`) {
+		t.Error(string(b), err)
+	}
+	if d, err := FromPMML(b); err != nil || !reflect.DeepEqual(d, threshold) {
+		t.Error(d, err)
+	}
+	if _, err := FromPMML([]byte(strings.Replace(string(b), `value="75"`, `value="80"`, 1))); err == nil {
+		t.Error()
+	}
+	threshold.Test = &Test{Op: opContains, Operand: "x", Separator: ";"}
+	if _, err := threshold.ToPMML("play"); err == nil {
+		t.Error()
+	}
+}
+
+func TestToONNX(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToONNX([]string{"outlook", "temperature", "humidity", "wind"})
+	if err != nil {
+		t.Error()
+	}
+	if !strings.Contains(string(b), "TreeEnsembleClassifier") {
+		t.Error()
+	}
+	if !strings.Contains(string(b), `"outlook":["overcast","rain","sunny"]`) {
+		t.Error()
+	}
+	//
+	// Every column in the tree must be an input.
+	//
+	_, err = decision.ToONNX([]string{"outlook"})
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestYAML(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToYAML()
+	if err != nil {
+		t.Error()
+	}
+	d, err := FromYAML(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.String() != decision.String() {
+		t.Error()
+	}
+	//
+	// Hand edited YAML, with comments, quoting and a sequence at the same
+	// indentation as its key.
+	//
+	const edited = `# Reviewed rules.
+column: outlook
+cases:
+- value: 'sunny'   # sunny days
+  class: "no"
+- value: rain
+  decide:
+    column: wind
+    cases:
+      - value: weak
+        class: yes
+        counts:
+          yes: 3
+`
+	d, err = FromYAML([]byte(edited))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Cases[0].Value != "sunny" || d.Cases[0].Class != "no" {
+		t.Error()
+	}
+	if d.Cases[1].Decide.Cases[0].Counts["yes"] != 3 {
+		t.Error()
+	}
+	_, err = FromYAML([]byte("column: outlook\ncases: [a, b]\n"))
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestBinary(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.MarshalBinary()
+	if err != nil {
+		t.Error()
+	}
+	d := new(Decision)
+	if err := d.UnmarshalBinary(b); err != nil {
+		t.Error()
+	}
+	if d.String() != decision.String() {
+		t.Error()
+	}
+	//
+	// Through gob, as an interface value.
+	//
+	var buf bytes.Buffer
+	var model interface{} = decision
+	if err := gob.NewEncoder(&buf).Encode(&model); err != nil {
+		t.Error()
+	}
+	var decoded interface{}
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Error()
+	}
+	if decoded.(*Decision).String() != decision.String() {
+		t.Error()
+	}
+}
+
+func TestProto(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToProto()
+	if err != nil {
+		t.Error()
+	}
+	d, err := FromProto(b)
+	if err != nil {
+		t.Error()
+	}
+	if d.String() != decision.String() {
+		t.Error()
+	}
+	_, err = FromProto(b[:len(b)-1])
+	if err == nil {
+		t.Error()
+	}
+	//
+	// A committee keeps its trees and weights, which may also be packed.
+	//
+	forest, _ := LearnForest(view, "play", 3)
+	forest.Weights[1] = 0.5
+	b, err = forest.ToProto()
+	if err != nil {
+		t.Error(err)
+	}
+	restored, err := ForestFromProto(b)
+	if err != nil || !reflect.DeepEqual(restored, forest) {
+		t.Error(restored, err)
+	}
+	w := new(wire.Writer)
+	w.Message(1, decision.toProto())
+	w.Bytes(2, []byte{0, 0, 0, 0, 0, 0, 0xe0, 0x3f})
+	if c, err := CommitteeFromProto(w.Encoded()); err != nil || c.Weights[0] != 0.5 {
+		t.Error(c, err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToCompact()
+	if err != nil {
+		t.Error()
+	}
+	j, _ := decision.ToJSON(false)
+	if len(b) >= len(j)/2 {
+		t.Error()
+	}
+	d, err := FromCompact(b)
+	if err != nil {
+		t.Error()
+	}
+	if d.String() != decision.String() {
+		t.Error()
+	}
+	_, err = FromCompact(b[:len(b)-1])
+	if err == nil {
+		t.Error()
+	}
+	//
+	// A corrupt length fails rather than allocating what it claims.
+	//
+	if _, err := FromCompact([]byte("ID3C\x03\xff\xff\xff\xff\x07")); err == nil {
+		t.Error()
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	rule := &Decision{
+		Column: "outlook",
+		Cases: []*Case{
+			{Value: "overcast", Class: "yes", Counts: map[string]int{"yes": 4}},
+			{Value: "rain", Counts: map[string]int{"yes": 3, "no": 2}, Decide: &Decision{
+				Column:  "wind",
+				Default: "no",
+				Cases: []*Case{
+					{Value: "weak", Class: "yes"},
+					{Value: "it's windy", Class: "no"},
+				},
+			}},
+		},
+	}
+	s, err := rule.ToSQL(MySQL)
+	if err != nil {
+		t.Error()
+	}
+	expected := "CASE `outlook`\n" +
+		"  WHEN 'overcast' THEN 'yes'\n" +
+		"  WHEN 'rain' THEN CASE `wind`\n" +
+		"      WHEN 'weak' THEN 'yes'\n" +
+		"      WHEN 'it''s windy' THEN 'no'\n" +
+		"      ELSE 'no'\n" +
+		"    END\n" +
+		"  ELSE CASE\n" +
+		"    WHEN `outlook` IS NULL OR `outlook` = '' THEN CASE `wind`\n" +
+		"        WHEN 'weak' THEN 'yes'\n" +
+		"        WHEN 'it''s windy' THEN 'no'\n" +
+		"        ELSE 'no'\n" +
+		"      END\n" +
+		"  END\n" +
+		"END"
+	if s != expected {
+		t.Error(s)
+	}
+	//
+	// A case for the empty value also takes NULL.
+	//
+	rule.Cases[0].Value = ""
+	if s, _ = rule.ToSQL(ANSISQL); !strings.Contains(s, `WHEN "outlook" IS NULL OR "outlook" = '' THEN 'yes'`) {
+		t.Error(s)
+	}
+}
+
+func TestRules(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	rules := decision.ToRules()
+	if len(rules) != 5 {
+		t.Error()
+	}
+	var lines []string
+	for _, r := range rules {
+		lines = append(lines, r.Format("play"))
+	}
+	text := "# Learned rules.\n" + strings.Join(lines, "\n")
+	if !strings.Contains(text, "IF outlook=sunny AND humidity=high THEN play=no") {
+		t.Error()
+	}
+	parsed, class, err := ParseRules(text)
+	if err != nil || class != "play" || len(parsed) != 5 {
+		t.Error()
+	}
+	d, err := FromRules(parsed)
+	if err != nil {
+		t.Error()
+	}
+	if d.String() != (&Decision{Column: decision.Column, Cases: stripCounts(decision.Cases)}).String() {
+		t.Error()
+	}
+	//
+	// Quoting.
+	//
+	r := Rule{Conditions: []Condition{{Column: "sky colour", Value: "a=b"}}, Class: ""}
+	parsed, class, err = ParseRules(r.Format("class"))
+	if err != nil || class != "class" || parsed[0].Conditions[0] != r.Conditions[0] {
+		t.Error()
+	}
+	//
+	// A rule that is a prefix of another.
+	//
+	_, err = FromRules([]Rule{
+		{Conditions: []Condition{{Column: "outlook", Value: "sunny"}}, Class: "no"},
+		{Conditions: []Condition{{Column: "outlook", Value: "sunny"}, {Column: "wind", Value: "weak"}}, Class: "yes"},
+	})
+	if err == nil {
+		t.Error()
+	}
+}
+
+func stripCounts(cases []*Case) []*Case {
+	var stripped []*Case
+	for _, c := range cases {
+		s := &Case{Value: c.Value, Class: c.Class}
+		if c.Decide != nil {
+			s.Decide = &Decision{Column: c.Decide.Column, Cases: stripCounts(c.Decide.Cases)}
+		}
+		stripped = append(stripped, s)
+	}
+	return stripped
+}
+
+func TestToMarkdown(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToMarkdown("play")
+	if err != nil {
+		t.Error()
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, "| Rule | play | Coverage | Accuracy |\n") {
+		t.Error()
+	}
+	if !strings.Contains(s, "| outlook=overcast | yes | 4 (28.6%) | 100.0% |\n") {
+		t.Error()
+	}
+	b, _ = (&Decision{Column: "a|b", Cases: []*Case{{Value: "x", Class: "y"}}}).ToMarkdown("c")
+	if string(b) != "| Rule | c |\n|---|---|\n| a\\|b=x | y |\n" {
+		t.Error()
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	e, err := NewEnvelope(decision, "play", view, map[string]float64{"accuracy": 1})
+	if err != nil {
+		t.Error()
+	}
+	b, err := e.ToJSON(true)
+	if err != nil {
+		t.Error()
+	}
+	if !strings.Contains(string(b), `"formatVersion": 1`) {
+		t.Error()
+	}
+	e, err = EnvelopeFromJSON(b)
+	if err != nil || e.ClassColumn != "play" || e.Metrics["accuracy"] != 1 || len(e.Columns) != 5 {
+		t.Error()
+	}
+	d, err := FromJSON(b)
+	if err != nil || d.String() != decision.String() {
+		t.Error()
+	}
+	//
+	// Legacy bare trees, and future versions.
+	//
+	b, _ = decision.ToJSON(false)
+	e, err = EnvelopeFromJSON(b)
+	if err != nil || e.FormatVersion != 0 {
+		t.Error()
+	}
+	_, err = FromJSON([]byte(`{"formatVersion": 99, "payload": {}}`))
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestJSONStreaming(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	for _, indent := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := decision.ToJSONWriter(&buf, indent); err != nil {
+			t.Error()
+		}
+		b, _ := decision.ToJSON(indent)
+		if buf.String() != string(b) {
+			t.Error()
+		}
+		d, err := FromJSONReader(&buf)
+		if err != nil || d.String() != decision.String() {
+			t.Error()
+		}
+	}
+	e, _ := NewEnvelope(decision, "play", nil, nil)
+	b, _ := e.ToJSON(false)
+	d, err := FromJSONReader(bytes.NewReader(b))
+	if err != nil || d.String() != decision.String() {
+		t.Error()
+	}
+	_, err = FromJSONReader(strings.NewReader(`{"Column": "outlook", "Cases": [{"Value": 1}]}`))
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	a, _ := Learn(view, "play")
+	b, _ := Learn(view, "play")
+	ja, _ := a.ToJSON(false)
+	jb, _ := b.ToJSON(false)
+	if string(ja) != string(jb) {
+		t.Error()
+	}
+	//
+	// Reordering cases does not change the hash.
+	//
+	b.Cases[0], b.Cases[2] = b.Cases[2], b.Cases[0]
+	if a.Hash() != b.Hash() {
+		t.Error()
+	}
+	c := a.Canonical()
+	if c.Cases[0].Value != "overcast" || a.Cases[0].Value == "overcast" {
+		t.Error()
+	}
+	b.Cases[0].Class = "maybe"
+	if a.Hash() == b.Hash() {
+		t.Error()
+	}
+}
+
+func TestFiles(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	dir := t.TempDir()
+	for _, ext := range []string{".json", ".yaml", ".gob", ".pb"} {
+		path := filepath.Join(dir, "model"+ext)
+		if err := SaveFile(path, decision); err != nil {
+			t.Error(ext)
+		}
+		d, err := LoadFile(path)
+		if err != nil || d.String() != decision.String() {
+			t.Error(ext)
+		}
+	}
+	if err := SaveFile(filepath.Join(dir, "model.dot"), decision); err != nil {
+		t.Error()
+	}
+	if _, err := LoadFile(filepath.Join(dir, "model.dot")); err == nil {
+		t.Error()
+	}
+	if err := SaveFile(filepath.Join(dir, "model.txt"), decision); err == nil {
+		t.Error()
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 5 {
+		t.Error()
+	}
+}
+
+func TestValuerScanner(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	var _ driver.Valuer = decision
+	var _ sql.Scanner = decision
+	v, err := decision.Value()
+	if err != nil {
+		t.Error()
+	}
+	d := new(Decision)
+	if err := d.Scan(v); err != nil || d.String() != decision.String() {
+		t.Error()
+	}
+	if err := d.Scan(string(v.([]byte))); err != nil || d.String() != decision.String() {
+		t.Error()
+	}
+	if err := d.Scan(nil); err != nil || d.Column != "" {
+		t.Error()
+	}
+	if err := d.Scan(42); err == nil {
+		t.Error()
+	}
+	v, _ = (*Decision)(nil).Value()
+	if v != nil {
+		t.Error()
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, _ := decision.ToJSON(true)
+	y, _ := decision.ToYAML()
+	fsys := fstest.MapFS{
+		"models/play.json": {Data: b},
+		"models/play.yaml": {Data: y},
+	}
+	for _, path := range []string{"models/play.json", "models/play.yaml"} {
+		d, err := LoadFS(fsys, path)
+		if err != nil || d.String() != decision.String() {
+			t.Error(path)
+		}
+	}
+	if _, err := LoadFS(fsys, "models/missing.json"); err == nil {
+		t.Error()
+	}
+	if MustFromJSON(b).String() != decision.String() {
+		t.Error()
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error()
+		}
+	}()
+	MustFromJSON([]byte("{"))
+}
+
+func TestDiff(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	a, _ := Learn(view, "play")
+	b := a.Canonical()
+	if len(Diff(a, b)) != 0 {
+		t.Error()
+	}
+	b.caseFor("overcast").Class = "no"
+	b.caseFor("sunny").Decide.Cases = b.caseFor("sunny").Decide.Cases[:1]
+	b.Cases = append(b.Cases, &Case{Value: "foggy", Class: "no"})
+	changes := Diff(a, b)
+	var s []string
+	for _, c := range changes {
+		s = append(s, c.String())
+	}
+	expected := []string{
+		"removed outlook=sunny AND humidity=normal: yes",
+		"changed outlook=overcast: yes -> no",
+		"added outlook=foggy: no",
+	}
+	if strings.Join(s, "\n") != strings.Join(expected, "\n") {
+		t.Error(s)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	a, _ := Learn(view, "play")
+	if !a.Equivalent(a.Canonical(), false) {
+		t.Error()
+	}
+	//
+	// The same function, restructured.
+	//
+	x := &Decision{Column: "outlook", Cases: []*Case{
+		{Value: "sunny", Decide: &Decision{Column: "wind", Cases: []*Case{{Value: "weak", Class: "no"}, {Value: "strong", Class: "yes"}}}},
+		{Value: "rain", Decide: &Decision{Column: "wind", Cases: []*Case{{Value: "weak", Class: "yes"}, {Value: "strong", Class: "yes"}}}},
+	}}
+	y := &Decision{Column: "wind", Cases: []*Case{
+		{Value: "strong", Decide: &Decision{Column: "outlook", Cases: []*Case{{Value: "sunny", Class: "yes"}, {Value: "rain", Class: "yes"}}}},
+		{Value: "weak", Decide: &Decision{Column: "outlook", Cases: []*Case{{Value: "rain", Class: "yes"}, {Value: "sunny", Class: "no"}}}},
+	}}
+	if x.Equivalent(y, false) || !x.Equivalent(y, true) {
+		t.Error()
+	}
+	y.Cases[1].Decide.Cases[1].Class = "yes"
+	if x.Equivalent(y, true) {
+		t.Error()
+	}
+}
+
+func TestSign(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	key := []byte("secret")
+	signature := decision.Sign(key)
+	if decision.Verify(key, signature) != nil || decision.Canonical().Verify(key, signature) != nil {
+		t.Error()
+	}
+	if decision.Verify([]byte("other"), signature) != ErrSignature {
+		t.Error()
+	}
+	if decision.Verify(nil, decision.Hash()) != nil {
+		t.Error()
+	}
+	//
+	// Tampered files are refused.
+	//
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := SaveFileSigned(path, decision, key); err != nil {
+		t.Error()
+	}
+	if _, err := LoadFileSigned(path, key); err != nil {
+		t.Error()
+	}
+	b, _ := os.ReadFile(path)
+	os.WriteFile(path, bytes.Replace(b, []byte(`"no"`), []byte(`"yes"`), 1), 0644)
+	if _, err := LoadFileSigned(path, key); err != ErrSignature {
+		t.Error()
+	}
+}
+
+func TestEdit(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	sunny := []Condition{{Column: "outlook", Value: "sunny"}}
+	if err := d.PruneAt(sunny); err != nil {
+		t.Error()
+	}
+	if c := d.caseFor("sunny"); c.Class != "no" || c.Decide != nil || c.Counts["yes"] != 2 {
+		t.Error()
+	}
+	if err := d.SetClass([]Condition{{Column: "outlook", Value: "rain"}, {Column: "wind", Value: "strong"}}, "maybe"); err != nil {
+		t.Error()
+	}
+	if d.caseFor("rain").Decide.caseFor("strong").Class != "maybe" {
+		t.Error()
+	}
+	if err := d.AddCase([]Condition{{Column: "outlook", Value: "rain"}}, "calm", "yes"); err != nil {
+		t.Error()
+	}
+	if err := d.AddCase(nil, "foggy", "no"); err != nil || d.caseFor("foggy") == nil {
+		t.Error()
+	}
+	//
+	// Invalid edits.
+	//
+	if d.AddCase(nil, "foggy", "no") == nil {
+		t.Error()
+	}
+	if d.SetClass([]Condition{{Column: "wind", Value: "weak"}}, "no") == nil {
+		t.Error()
+	}
+	if d.AddCase(sunny, "x", "y") == nil {
+		t.Error()
+	}
+	if d.SetClass([]Condition{{Column: "outlook", Value: "sunny"}, {Column: "humidity", Value: "high"}}, "no") == nil {
+		t.Error()
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	d := &Decision{Column: "outlook", Cases: []*Case{
+		{Value: "sunny", Decide: &Decision{Column: "humidity", Cases: []*Case{
+			{Value: "high", Class: "no"},
+			{Value: "normal", Decide: &Decision{Column: "wind", Cases: []*Case{{Value: "weak", Class: "yes"}}}},
+		}}},
+		{Value: "overcast", Class: "yes"},
+		{Value: "rain", Decide: &Decision{Column: "wind", Cases: []*Case{
+			{Value: "weak", Class: "yes"},
+			{Value: "strong", Class: "yes"},
+		}}},
+		{Value: "foggy", Class: "no"},
+	}}
+	s := d.Simplify()
+	expected := "outlook = sunny:\n" +
+		"|   humidity = high: no\n" +
+		"|   humidity = normal: yes\n" +
+		"outlook = foggy: no\n" +
+		"outlook = (other): yes\n"
+	if s.String() != expected {
+		t.Error(s.String())
+	}
+	if answer, _ := s.Decide([][]string{{"outlook"}, {"snow"}}); answer[0] != "yes" {
+		t.Error()
+	}
+	//
+	// The original is unchanged, and the default survives serialization.
+	//
+	if d.Cases[2].Decide == nil {
+		t.Error()
+	}
+	b, _ := s.ToCompact()
+	c, _ := FromCompact(b)
+	b, _ = s.ToProto()
+	p, _ := FromProto(b)
+	b, _ = s.MarshalBinary()
+	g := new(Decision)
+	g.UnmarshalBinary(b)
+	b, _ = s.ToYAML()
+	y, _ := FromYAML(b)
+	for _, r := range []*Decision{c, p, g, y} {
+		if r == nil || r.String() != expected {
+			t.Error()
+		}
+	}
+	sql, _ := s.ToSQL(ANSISQL)
+	if !strings.Contains(sql, "ELSE 'yes'") {
+		t.Error()
+	}
+	//
+	// Everything merges into a default at the root.
+	//
+	s = (&Decision{Column: "a", Cases: []*Case{{Value: "x", Class: "c"}, {Value: "y", Class: "c"}}}).Simplify()
+	if len(s.Cases) != 0 || s.Default != "c" {
+		t.Error()
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	s, err := d.Truncate(1)
+	if err != nil {
+		t.Error()
+	}
+	expected := "outlook = rain: yes (5/2)\n" +
+		"outlook = sunny: no (5/2)\n" +
+		"outlook = overcast: yes (4)\n"
+	if s.String() != expected {
+		t.Error(s.String())
+	}
+	s, _ = d.Truncate(2)
+	if s.String() != d.String() {
+		t.Error()
+	}
+	if _, err := d.Truncate(0); err == nil {
+		t.Error()
+	}
+	//
+	// Class frequencies are required.
+	//
+	d = &Decision{Column: d.Column, Cases: stripCounts(d.Cases)}
+	if _, err := d.Truncate(1); err == nil {
+		t.Error()
+	}
+}
+
+func TestValidate(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	if d.Validate(view.Columns()) != nil || d.Validate(nil) != nil {
+		t.Error()
+	}
+	shared := &Decision{Column: "wind", Cases: []*Case{{Value: "weak", Class: "yes"}}}
+	bad := &Decision{Column: "outlook", Cases: []*Case{
+		{Value: "sunny"},
+		{Value: "sunny", Class: "no"},
+		{Value: "rain", Decide: shared},
+		{Value: "overcast", Decide: shared},
+		{Value: "foggy", Class: "no", Decide: &Decision{Column: "visibility", Cases: []*Case{{Value: "low", Class: "no"}}}},
+	}}
+	shared.Cases = append(shared.Cases, &Case{Value: "strong", Decide: bad})
+	err := bad.Validate(view.Columns())
+	v, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal()
+	}
+	expected := []string{
+		"outlook=sunny: case has no class or decision",
+		"outlook=sunny: duplicate case value",
+		"outlook=rain AND wind=strong: decision is shared or cyclic",
+		"outlook=overcast: decision is shared or cyclic",
+		"outlook=foggy: case has both a class and a decision",
+		"outlook=foggy: unknown column 'visibility'",
+	}
+	if strings.Join(v.Problems, "\n") != strings.Join(expected, "\n") {
+		t.Error(v.Problems)
+	}
+}
+
+func TestWriteRules(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	var b bytes.Buffer
+	if err := decision.WriteRules(&b, "play"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != len(decision.ToRules()) || lines[0] != decision.ToRules()[0].Format("play") {
+		t.Error(lines)
+	}
+	rules, class, err := ParseRules(b.String())
+	if d, _ := FromRules(rules); err != nil || class != "play" || !d.Equivalent(decision, true) {
+		t.Error(rules, class, err)
+	}
+}
+
+func TestRuleStats(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	d.caseFor("overcast").Class = "no"
+	stats, err := d.Rules(view, "play")
+	if err != nil || len(stats) != 5 {
+		t.Error(err)
+	}
+	for _, s := range stats {
+		switch s.Conditions[0].Value {
+		case "overcast":
+			if s.Covered != 4 || s.Correct != 0 || s.Accuracy != 0 {
+				t.Error()
+			}
+		default:
+			if s.Covered == 0 || s.Accuracy != 1 {
+				t.Error()
+			}
+		}
+	}
+	if _, err := d.Rules(view, "nope"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+}
+
+func TestRefit(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	//
+	// Fresh data where overcast days are now mostly "no".
+	//
+	fresh, _ := Read(strings.NewReader(strings.Replace(example, "overcast,hot,high,weak,yes", "overcast,hot,high,weak,no", 1) +
+		"overcast,mild,high,weak,no\novercast,cool,high,weak,no\novercast,hot,high,weak,no\n"))
+	r, err := Refit(d, fresh, "play")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := r.caseFor("overcast"); c.Class != "no" || c.Counts["no"] != 4 || c.Counts["yes"] != 3 {
+		t.Error()
+	}
+	if d.caseFor("overcast").Class != "yes" {
+		t.Error()
+	}
+	if len(Diff(d, r)) != 1 {
+		t.Error()
+	}
+	if _, err := Refit(d, fresh, "golf"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+	if _, err := Refit(d, fresh.Drop("wind"), "play"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestCommittee(t *testing.T) {
+	a := &Decision{Column: "outlook", Cases: []*Case{{Value: "sunny", Class: "no"}}, Default: "yes"}
+	b := &Decision{Column: "wind", Cases: []*Case{{Value: "strong", Class: "no"}, {Value: "weak", Class: "yes"}}}
+	c := &Decision{Column: "humidity", Cases: []*Case{{Value: "high", Class: "no"}}}
+	committee, err := BuildCommittee([]*Decision{a, b, c}, []float64{1, 1, 0.5})
+	if err != nil {
+		t.Error()
+	}
+	data := [][]string{
+		{"outlook", "wind", "humidity"},
+		{"sunny", "weak", "normal"},
+		{"rain", "strong", "normal"},
+		{"rain", "strong", "high"},
+	}
+	answer, _ := committee.Decide(data)
+	//
+	// A tie, with the third tree abstaining, then the third tree decides.
+	//
+	if answer[0] != "no" || answer[1] != "no" || answer[2] != "no" {
+		t.Error(answer)
+	}
+	committee.Weights[1] = 0.25
+	if answer, _ := committee.Decide(data); answer[1] != "yes" {
+		t.Error()
+	}
+	b2, err := committee.ToJSON(false)
+	if err != nil {
+		t.Error()
+	}
+	restored, err := CommitteeFromJSON(b2)
+	if err != nil || len(restored.Trees) != 3 || restored.Weights[1] != 0.25 {
+		t.Error()
+	}
+	b2, err = committee.ToCompact()
+	if err != nil {
+		t.Error(err)
+	}
+	if restored, err := CommitteeFromCompact(b2); err != nil || !reflect.DeepEqual(restored, committee) {
+		t.Error(restored, err)
+	}
+	if _, err := FromCompact(b2); err == nil {
+		t.Error()
+	}
+	if _, err := BuildCommittee([]*Decision{a}, []float64{1, 2}); err == nil {
+		t.Error()
+	}
+	if _, err := committee.ToONNX([]string{"outlook", "wind", "humidity"}); err != nil {
+		t.Error()
+	}
+}
+
+func TestLearnForest(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	sample := rowsOf(Bootstrap(view, 1))
+	distinct := make(map[string]bool)
+	for _, row := range sample[1:] {
+		distinct[strings.Join(row, ",")] = true
+	}
+	if len(sample) != 15 || len(distinct) == 14 {
+		t.Error(len(sample), len(distinct))
+	}
+	forest, err := LearnForest(view, "play", 25)
+	if err != nil || len(forest.Trees) != 25 {
+		t.Fatal(err)
+	}
+	//
+	// Trees learned from different samples and columns differ, but the forest
+	// is the same for the same seed.
+	//
+	root := make(map[string]bool)
+	for _, tree := range forest.Trees {
+		root[tree.Column] = true
+	}
+	if len(root) < 2 {
+		t.Error(root)
+	}
+	again, _ := LearnForest(view, "play", 25)
+	if !again.Trees[7].Equivalent(forest.Trees[7], true) {
+		t.Error()
+	}
+	b, _ := forest.ToCompact()
+	j, _ := forest.ToJSON(false)
+	restored, err := ForestFromCompact(b)
+	if err != nil || !reflect.DeepEqual(restored.Weights, forest.Weights) || len(b) >= len(j)/2 {
+		t.Fatal(err, len(b), len(j))
+	}
+	for i, tree := range restored.Trees {
+		if !tree.Equivalent(forest.Trees[i], true) {
+			t.Error(i)
+		}
+	}
+	answer, err := forest.Decide(rowsOf(view))
+	if err != nil {
+		t.Fatal(err)
+	}
+	correct := 0
+	for i, row := range rowsOf(view)[1:] {
+		if answer[i] == row[len(row)-1] {
+			correct++
+		}
+	}
+	if correct < 12 {
+		t.Error(correct)
+	}
+	if _, err := LearnForest(view, "play", 0); err == nil {
+		t.Error()
+	}
+}
+
+func TestLearnBoosted(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	weights := make([]float64, 14)
+	weights[2] = 1
+	for _, row := range rowsOf(Resample(view, weights, 1))[1:] {
+		if strings.Join(row, ",") != "overcast,hot,high,weak,yes" {
+			t.Fatal(row)
+		}
+	}
+	//
+	// Boosted stumps decide the rows better than a single stump.
+	//
+	accuracy := func(decide func([][]string) ([]string, error)) int {
+		data := rowsOf(view)
+		answer, _ := decide(data)
+		correct := 0
+		for i, row := range data[1:] {
+			if i < len(answer) && answer[i] == row[4] {
+				correct++
+			}
+		}
+		return correct
+	}
+	stump, _ := Learn(view, "play", WithMaxDepth(1))
+	model, err := LearnBoosted(view, "play", 20, WithMaxDepth(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Trees) < 2 || accuracy(model.Decide) <= accuracy(stump.Decide) {
+		t.Error(len(model.Trees), accuracy(model.Decide), accuracy(stump.Decide))
+	}
+	b, err := model.ToJSON(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := BoostedModelFromJSON(b)
+	if err != nil || len(restored.Trees) != len(model.Trees) || restored.Weights[1] != model.Weights[1] {
+		t.Error(err)
+	}
+	b, _ = model.ToCompact()
+	if compact, err := BoostedModelFromCompact(b); err != nil || !reflect.DeepEqual(compact, model) {
+		t.Error(err)
+	}
+	if _, err := LearnBoosted(view, "missing", 5); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+}
+
+func TestWeights(t *testing.T) {
+	//
+	// A row of weight 2 learns as two rows, and a row of weight 0 as none.
+	//
+	lines := strings.Split(strings.TrimSpace(example), "\n")
+	weighted := []string{lines[0] + ",weight"}
+	for _, line := range lines[1:] {
+		weighted = append(weighted, line+",1")
+	}
+	weighted[14] = lines[14] + ",2"
+	weighted[1] = lines[1] + ",0"
+	view, _ := Read(strings.NewReader(strings.Join(weighted, "\n")))
+	d, err := Learn(view, "play", WithWeights("weight"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, _ := Read(strings.NewReader(strings.Join(append(append(lines[:1:1], lines[2:]...), lines[14]), "\n")))
+	if want, _ := Learn(copied, "play"); !reflect.DeepEqual(d, want) {
+		t.Error(d, want)
+	}
+	if _, err := Learn(view, "play", WithWeights("nope")); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	weighted[3] = lines[3] + ",-1"
+	view, _ = Read(strings.NewReader(strings.Join(weighted, "\n")))
+	if _, err := Learn(view, "play", WithWeights("weight")); err == nil {
+		t.Error()
+	}
+}
+
+func TestProbabilities(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	learned, _ := Learn(view, "play")
+	decision, _ := learned.Truncate(1)
+	columns := view.Columns()
+	p := decision.Probabilities(columns, []string{"sunny", "hot", "high", "weak", ""})
+	if p["no"] != 0.6 || p["yes"] != 0.4 {
+		t.Error(p)
+	}
+	if decision.Probabilities(columns, []string{"foggy", "hot", "high", "weak", ""}) != nil {
+		t.Error()
+	}
+	if strings.Join(decision.Classes(), ",") != "no,yes" {
+		t.Error()
+	}
+	if strings.Join(learned.Columns(), ",") != "humidity,outlook,wind" {
+		t.Error()
+	}
+}
+
+func TestToMermaid(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	b, err := decision.ToMermaid()
+	if err != nil {
+		t.Error(err)
+	}
+	s := string(b)
+	if !strings.HasPrefix(s, "flowchart TD\n    n0[\"outlook\"]\n") {
+		t.Error(s)
+	}
+	if !strings.Contains(s, "n0 -->|\"overcast\"|") {
+		t.Error(s)
+	}
+}
+
+func TestToGo(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	decision.Cases[0].Decide.Default = "no"
+	b, err := decision.ToGo("play", "Decide")
+	if err != nil {
+		t.Error(err)
+	}
+	s := string(b)
+	if !strings.Contains(s, "func Decide(row map[string]string) (string, bool) {") {
+		t.Error(s)
+	}
+	if !strings.Contains(s, "\tcase \"overcast\":\n\t\treturn \"yes\", true\n") {
+		t.Error(s)
+	}
+	if !strings.Contains(s, "\t\tdefault:\n\t\t\treturn \"no\", true\n") {
+		t.Error(s)
+	}
+	if _, err := decision.ToGo("play", "not valid"); err == nil {
+		t.Error()
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	view, _ := Read(strings.NewReader("id,outlook,play\n1,sunny,no\n2,,yes\n3,sunny,yes\n"))
+	stats := Describe(view.Drop("play"))
+	if len(stats) != 2 {
+		t.Fatal(stats)
+	}
+	if !stats[0].LikelyID || stats[0].Distinct != 3 {
+		t.Error(stats[0])
+	}
+	s := stats[1]
+	if s.LikelyID || s.Distinct != 1 || s.Missing != 1 || s.Top != "sunny" || s.TopCount != 2 {
+		t.Error(s)
+	}
+	if s.MissingRate() != 1.0/3 {
+		t.Error(s.MissingRate())
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	view, _ := Read(strings.NewReader("id,outlook,outlook,play\n1,sunny,x,no\n2,rain,y,yes\n"), WithLogger(logger))
+	if !strings.Contains(b.String(), "duplicate column name") || !strings.Contains(b.String(), "column=id") {
+		t.Error(b.String())
+	}
+	b.Reset()
+	Learn(view.Drop("id"), "play", WithLogger(logger))
+	if !strings.Contains(b.String(), "msg=\"id3: split\" column=outlook") || !strings.Contains(b.String(), "nodes=1") {
+		t.Error(b.String())
+	}
+}
+
+// recorder is a Tracer that records the names of spans and events.
+//
+type recorder struct {
+	names []string
+}
+
+func (r *recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	r.names = append(r.names, name)
+	return ctx, r
+}
+
+func (r *recorder) SetAttributes(attrs ...slog.Attr) {}
+
+func (r *recorder) AddEvent(name string, attrs ...slog.Attr) { r.names = append(r.names, name) }
+
+func (r *recorder) End() { r.names = append(r.names, "end") }
+
+func TestTracer(t *testing.T) {
+	r := new(recorder)
+	view, _ := Read(strings.NewReader(example), WithTracer(r))
+	decision, _ := Learn(view, "play", WithTracer(r))
+	if strings.Join(r.names, ",") != "id3.Read,end,id3.Learn,split,split,split,end" {
+		t.Error(r.names)
+	}
+	r.names = nil
+	data := [][]string{{"outlook", "humidity", "wind"}, {"overcast", "high", "weak"}, {"foggy", "high", "weak"}}
+	if _, err := decision.DecideContext(context.Background(), data, WithTracer(r)); err == nil {
+		t.Error()
+	}
+	if result, err := decision.DecideContext(context.Background(), data[:2]); err != nil || result[0] != "yes" {
+		t.Error(result, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := decision.DecideContext(ctx, data[:2]); err != context.Canceled {
+		t.Error(err)
+	}
+	if strings.Join(r.names, ",") != "id3.Decide,end" {
+		t.Error(r.names)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := Read(strings.NewReader("a,b\n1\n")); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+	view, _ := Read(strings.NewReader(example))
+	if _, err := Learn(view, "golf"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+	if _, err := Learn(view.Select("outlook", "foggy"), "play"); !errors.Is(err, ErrEmptyView) {
+		t.Error(err)
+	}
+	decision, _ := Learn(view, "play")
+	if _, err := decision.Decide([][]string{{"outlook"}, {"foggy"}}); !errors.Is(err, ErrNoMatchingCase) {
+		t.Error(err)
+	}
+	if _, err := decision.Decide([][]string{{"wind"}, {"weak"}}); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	if _, err := decision.Decide([][]string{{"outlook", "wind"}, {"overcast"}}); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrColumnNotFound) {
+			t.Error(err)
+		}
+	}()
+	view.Select("golf", "yes")
+}
+
+func TestTypes(t *testing.T) {
+	view, _ := Read(strings.NewReader("n,x,ok,day,name\n2,1.5,true,2024-01-02,a\n10,-3,false,2024-03-01,b\n9,,true,2023-12-31,c\n"))
+	schema := InferSchema(view)
+	want := Schema{"n": Int, "x": Float, "ok": Bool, "day": Time, "name": String}
+	if len(schema) != len(want) {
+		t.Error(schema)
+	}
+	for c, k := range want {
+		if schema[c] != k {
+			t.Error(c, schema[c])
+		}
+	}
+	//
+	// Numerically 9 < 10, though as strings "10" < "9".
+	//
+	nine, _ := ParseValue("9", Int)
+	rows := 0
+	v := SelectCompare(view, "n", Less, nine)
+	for v.First(); v.Next() != nil; {
+		rows++
+	}
+	if rows != 1 {
+		t.Error(rows)
+	}
+	day, _ := ParseValue("2024-01-01", Time)
+	v = SelectCompare(view, "day", Greater, day).Select("ok", "true")
+	if v.First(); v.Next()[4] != "a" || v.Next() != nil {
+		t.Error("time")
+	}
+	two, _ := ParseValue("2", Float)
+	v = SelectCompare(view, "x", LessOrEqual, two)
+	if v.First(); v.Next() == nil || v.Next() == nil || v.Next() != nil {
+		t.Error("missing values should not be selected")
+	}
+	if _, err := ParseValue("abc", Float); err == nil {
+		t.Error(err)
+	}
+	if k, err := ParseKind("TIME"); k != Time || err != nil {
+		t.Error(k, err)
+	}
+}
+
+func TestSetValued(t *testing.T) {
+	data := `tags,region,escalate
+urgent;billing,north,yes
+billing,north,no
+urgent,south,yes
+login;urgent,south,yes
+login,north,no
+billing;login,south,no
+`
+	view, _ := Read(strings.NewReader(data))
+	decision, err := Learn(view, "escalate", WithSetValued(";", "tags"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Column != "tags" || decision.Test == nil || decision.Test.Operand != "urgent" {
+		t.Fatal(decision)
+	}
+	result, err := decision.Decide([][]string{{"tags"}, {"refund; urgent"}, {"refund"}})
+	if err != nil || strings.Join(result, ",") != "yes,no" {
+		t.Error(result, err)
+	}
+	if s := decision.String(); !strings.Contains(s, "tags contains urgent: yes") || !strings.Contains(s, "tags not contains urgent: no") {
+		t.Error(s)
+	}
+	if err := decision.Validate(nil); err != nil {
+		t.Error(err)
+	}
+	rules := decision.ToRules()
+	if rules[0].Format("escalate") != "IF NOT tags contains urgent THEN escalate=no" {
+		t.Error(rules[0].Format("escalate"))
+	}
+	if d, err := FromRules(rules); err != nil || !d.Equivalent(decision, true) {
+		t.Error(d, err)
+	}
+	//
+	// Round trip through each format.
+	//
+	b, _ := decision.ToJSON(false)
+	fromJSON, _ := FromJSON(b)
+	b, _ = decision.ToYAML()
+	fromYAML, _ := FromYAML(b)
+	b, _ = decision.MarshalBinary()
+	fromGob := new(Decision)
+	fromGob.UnmarshalBinary(b)
+	b, _ = decision.ToCompact()
+	fromCompact, _ := FromCompact(b)
+	b, _ = decision.ToProto()
+	fromProto, _ := FromProto(b)
+	for i, d := range []*Decision{fromJSON, fromYAML, fromGob, fromCompact, fromProto} {
+		if d == nil || len(Diff(decision, d)) > 0 {
+			t.Error(i, d)
+		}
+	}
+	if _, err := decision.ToSQL(ANSISQL); err == nil {
+		t.Error("SQL should fail")
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	data := `complaint,channel,refund
+"Charged twice, want my money back",email,yes
+"App crashes on login",phone,no
+"charged for a cancelled order",email,yes
+"Login page is slow",email,no
+"Money taken twice!",phone,yes
+"Cannot reset password",phone,no
+`
+	view, _ := Read(strings.NewReader(data))
+	tokens := Tokenize(view, "complaint", "refund", 2)
+	columns := tokens.Columns()
+	if len(columns) != 5 || columns[0] != "" || columns[3] != "complaint:charged" || columns[4] != "complaint:login" {
+		t.Fatal(columns)
+	}
+	tokens.First()
+	if row := tokens.Next(); strings.Join(row[3:], ",") != "true,false" {
+		t.Error(row)
+	}
+	decision, err := Learn(tokens, "refund")
+	if err != nil || decision.Column != "complaint:charged" {
+		t.Error(decision, err)
+	}
+	if n := len(Frequency(tokens.Select("complaint:login", "true"), "refund")); n != 1 {
+		t.Error(n)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	view, _ := Read(strings.NewReader("price,qty,day\n\"1.234,5\",\"2.000\",31.12.2024\n\"0,99\",7,01.02.2025\n"))
+	schema := InferSchema(view, WithLocale(EuropeanLocale))
+	if schema["price"] != Float || schema["qty"] != Int || schema["day"] != Time {
+		t.Error(schema)
+	}
+	if schema := InferSchema(view); schema["price"] != String {
+		t.Error(schema)
+	}
+	v, err := EuropeanLocale.ParseValue("1.234,5", Float)
+	if err != nil || v.Float() != 1234.5 {
+		t.Error(v, err)
+	}
+	day, _ := EuropeanLocale.ParseValue("01.01.2025", Time)
+	recent := SelectCompare(view, "day", Greater, day, WithLocale(EuropeanLocale))
+	if recent.First(); recent.Next()[1] != "7" || recent.Next() != nil {
+		t.Error("time")
+	}
+	//
+	// Numbers in the locale are split on a threshold written as Go writes it,
+	// and binned.
+	//
+	view, _ = Read(strings.NewReader("price,dear\n\"1.234,5\",yes\n\"2.000,0\",yes\n\"0,99\",no\n\"9,5\",no\n"))
+	european := WithLocale(EuropeanLocale)
+	decision, err := Learn(view, "dear", WithSchema(InferSchema(view, european)), european)
+	if err != nil || decision.Test == nil || decision.Test.Operand != "9.5" {
+		t.Fatal(decision, err)
+	}
+	result, err := decision.DecideContext(context.Background(), [][]string{{"price"}, {"5,5"}, {"1.000"}}, european)
+	if err != nil || strings.Join(result, ",") != "no,yes" {
+		t.Error(result, err)
+	}
+	step := FitBins(view, "price", 2, european)
+	binned, _ := (&Pipeline{Steps: []Step{step}}).Apply(view)
+	if !reflect.DeepEqual(step.Bounds, []float64{9.5}) || Frequency(binned, "price")["(9.5,+inf)"] != 2 {
+		t.Error(step, Frequency(binned, "price"))
+	}
+}
+
+func TestLearnExternal(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	want, _ := Learn(view, "play")
+	got, err := LearnExternal(strings.NewReader(example), "play")
+	if err != nil || len(Diff(want, got)) > 0 || !reflect.DeepEqual(want, got) {
+		t.Error(got, err)
+	}
+	conflict := "a,b,c\nx,y,1\nx,y,2\nx,y,2\nz,y,1\n"
+	view, _ = Read(strings.NewReader(conflict))
+	want, _ = Learn(view, "c")
+	if got, err := LearnExternal(strings.NewReader(conflict), "c"); err != nil || !reflect.DeepEqual(want, got) {
+		t.Error(got, err)
+	}
+	if _, err := LearnExternal(strings.NewReader(example), "golf"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+	if _, err := LearnExternal(strings.NewReader("a,b\n"), "b"); !errors.Is(err, ErrEmptyView) {
+		t.Error(err)
+	}
+	//
+	// Rows that agree on every column decide the most frequent class.
+	//
+	d, err := LearnExternal(strings.NewReader("a,b,c\nx,y,1\nx,y,2\nx,y,2\nz,y,1\n"), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := d.Decide([][]string{{"a", "b"}, {"x", "y"}, {"z", "y"}}); err != nil || strings.Join(result, ",") != "2,1" {
+		t.Error(result, err)
+	}
+}
+
+func TestParallelism(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	want, _ := Learn(view, "play")
+	got, err := Learn(view, "play", WithParallelism(3))
+	if err != nil || !reflect.DeepEqual(want, got) {
+		t.Error(got, err)
+	}
+	//
+	// The cases of a deeper tree are learned in several goroutines, with the
+	// same tree and statistics.
+	//
+	r := rand.New(rand.NewSource(1))
+	data := [][]string{{"a", "b", "c", "d", "e", "class"}}
+	for i := 0; i < 500; i++ {
+		row := make([]string, 6)
+		for j := 0; j < 5; j++ {
+			row[j] = strconv.Itoa(r.Intn(3))
+		}
+		row[5] = strconv.Itoa((r.Intn(3) + len(row[0]+row[1]) + strings.Count(strings.Join(row, ""), "2")) % 3)
+		data = append(data, row)
+	}
+	wide, _ := NewView(data)
+	var s, p Stats
+	want, _ = Learn(wide, "class", WithStats(&s))
+	got, err = Learn(wide, "class", WithParallelism(4), WithStats(&p))
+	if err != nil || !reflect.DeepEqual(want, got) {
+		t.Error(err)
+	}
+	if s.Nodes != p.Nodes || s.Leaves != p.Leaves || s.Depth != p.Depth || s.Rows != p.Rows || len(s.ByDepth) != len(p.ByDepth) {
+		t.Errorf("%+v %+v", s, p)
+	}
+	want, _ = Learn(wide, "class", WithFeatureSampling(2, 1))
+	got, _ = Learn(wide, "class", WithFeatureSampling(2, 1), WithParallelism(4))
+	if !reflect.DeepEqual(want, got) {
+		t.Error()
+	}
+}
+
+func TestStats(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	var s, e Stats
+	Learn(view, "play", WithStats(&s))
+	if s.Nodes != 3 || s.Leaves != 5 || s.Depth != 1 || s.Rows != 24 || len(s.ByDepth) != 2 || s.PeakMemory == 0 || s.Duration <= 0 {
+		t.Errorf("%+v", s)
+	}
+	LearnExternal(strings.NewReader(example), "play", WithStats(&e))
+	if e.Nodes != s.Nodes || e.Leaves != s.Leaves || e.Depth != s.Depth || e.Rows != 24 || len(e.ByDepth) != 2 {
+		t.Errorf("%+v", e)
+	}
+}
+
+func TestMaxDuration(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	var s Stats
+	d, err := Learn(view, "play", WithMaxDuration(time.Nanosecond), WithStats(&s))
+	if err != nil || d.Column != "outlook" || !s.Stopped || s.Nodes != 1 {
+		t.Fatal(d, err, s)
+	}
+	for _, c := range d.Cases {
+		if c.Decide != nil || c.Class == "" {
+			t.Error(c)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e, err := LearnExternal(strings.NewReader(example), "play", WithContext(ctx))
+	if err != nil || !reflect.DeepEqual(d, e) {
+		t.Error(e, err)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	valid, _ := Read(strings.NewReader(`outlook,temperature,humidity,wind,play
+sunny,mild,high,weak,yes
+sunny,hot,high,strong,yes
+rain,mild,high,strong,no
+rain,cool,normal,weak,yes
+`))
+	d, err := Learn(view, "play", WithValidation(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); !strings.Contains(s, "outlook = sunny: no (5/2)") || !strings.Contains(s, "|   wind = strong: no") {
+		t.Error(s)
+	}
+	other, _ := Read(strings.NewReader("outlook,play\nsunny,no\n"))
+	if _, err := Learn(view, "play", WithValidation(other)); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+}
+
+func TestCosts(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	plain, _ := Learn(view, "play")
+	d, err := Learn(view, "play", WithCosts(map[string]float64{"outlook": 10}))
+	if err != nil || d.Column != "humidity" {
+		t.Error(d, err)
+	}
+	rows, _ := csv.NewReader(strings.NewReader(example)).ReadAll()
+	if result, err := d.Decide(rows); err != nil || len(result) != 14 {
+		t.Error(result, err)
+	}
+	equal := map[string]float64{"outlook": 2, "temperature": 2, "humidity": 2, "wind": 2}
+	if d, _ := Learn(view, "play", WithCosts(equal)); !reflect.DeepEqual(d, plain) {
+		t.Error(d)
+	}
+	if e, _ := LearnExternal(strings.NewReader(example), "play", WithCosts(map[string]float64{"outlook": 10})); e.Column != "humidity" {
+		t.Error(e)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, err := Learn(view, "play", WithExcluded("outlook", "id"))
+	if err != nil || !reflect.DeepEqual(d.Columns(), []string{"humidity", "temperature", "wind"}) {
+		t.Error(d.Columns(), err)
+	}
+	d, err = Learn(view, "play", WithAttributes("wind", "temperature"))
+	if err != nil || !reflect.DeepEqual(d.Columns(), []string{"temperature", "wind"}) {
+		t.Error(d.Columns(), err)
+	}
+	if e, _ := LearnExternal(strings.NewReader(example), "play", WithAttributes("wind", "temperature")); !reflect.DeepEqual(d, e) {
+		t.Error(e)
+	}
+	if _, err := Learn(view, "play", WithAttributes("golf")); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	data := "outlook,wind,play\nsunny,weak,no\nsunny,strong,no\nsunny,weak,no\n,weak,no\nrain,weak,yes\nrain,strong,yes\n,strong,yes\novercast,weak,yes\n"
+	view, _ := Read(strings.NewReader(data))
+	d, err := Learn(view, "play")
+	if err != nil || len(d.Cases) != 4 || d.Cases[1].Value != "" || d.Cases[1].Decide == nil {
+		t.Error(d, err)
+	}
+	d, err = Learn(view, "play", WithMissing(MissingExclude, "outlook"))
+	if err != nil || len(d.Cases) != 3 || d.Cases[0].Decide != nil {
+		t.Error(d, err)
+	}
+	d, err = Learn(view, "play", WithMissing(MissingImpute, "outlook"))
+	if err != nil || !reflect.DeepEqual(d.Cases[0].Counts, map[string]int{"no": 4, "yes": 1}) {
+		t.Error(d, err)
+	}
+	if answer, err := d.Decide([][]string{{"outlook", "wind"}, {"", "weak"}, {"rain", ""}}); err != nil || !reflect.DeepEqual(answer, []string{"no", "yes"}) {
+		t.Error(answer, err)
+	}
+	d, err = Learn(view, "play", WithMissing(MissingDistribute, "outlook"))
+	if err != nil || !reflect.DeepEqual(d.Cases[1].Counts, map[string]int{"yes": 2}) || len(d.Cases) != 4 || d.Cases[3].Value != "" {
+		t.Error(d, err)
+	}
+	//
+	// The rows missing a value are shared between the cases by the fraction
+	// of the other rows taking each, so that each counts once in all.
+	//
+	table := newContingency(3)
+	for _, row := range rowsOf(view)[1:] {
+		table.add(row, 2, []bool{true, false, false}, 1)
+	}
+	split := table.split(0, MissingDistribute)
+	if split["sunny"]["yes"] != 0.5 || split["rain"]["no"] != 1.0/3 || split["overcast"]["no"] != 1.0/6 {
+		t.Error(split)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	data := [][]string{{"outlook", "humidity", "wind"}, {" Sunny ", "normal", "weak"}, {"overcst", "high", "weak"}, {"rian", "high", "strong"}}
+	if _, err := d.DecideContext(context.Background(), data); !errors.Is(err, ErrNoMatchingCase) {
+		t.Error(err)
+	}
+	answer, err := d.DecideContext(context.Background(), data, WithFuzzyMatch(2))
+	if err != nil || !reflect.DeepEqual(answer, []string{"yes", "yes", "no"}) {
+		t.Error(answer, err)
+	}
+	if _, err := d.DecideContext(context.Background(), data, WithFuzzyMatch(0)); !errors.Is(err, ErrNoMatchingCase) {
+		t.Error(err)
+	}
+	if editDistance("kitten", "sitting", 10) != 3 || editDistance("kitten", "sitting", 2) != 2 {
+		t.Error(editDistance("kitten", "sitting", 10))
+	}
+}
+
+func TestDriftReport(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	d, _ := Learn(view, "play")
+	e, _ := NewEnvelope(d, "play", nil, nil)
+	if _, err := DriftReport(e, view); err == nil {
+		t.Error("no distributions")
+	}
+	e, _ = NewEnvelope(d, "play", view, nil)
+	if _, ok := e.Distributions["play"]; ok || len(e.Distributions) != 4 {
+		t.Error(e.Distributions)
+	}
+	b, _ := e.ToJSON(false)
+	e, _ = EnvelopeFromJSON(b)
+	report, err := DriftReport(e, view.Drop("play"))
+	if err != nil || len(report) != 4 || report[0].Column != "humidity" {
+		t.Error(report, err)
+	}
+	for _, r := range report {
+		if r.PSI != 0 || r.TotalVariation != 0 || r.Drifted {
+			t.Error(r)
+		}
+	}
+	shifted, _ := Read(strings.NewReader("outlook,temperature,humidity,wind\nsnow,cold,high,weak\nsnow,cold,high,strong\nsunny,cool,normal,weak\n"))
+	report, err = DriftReport(e, shifted)
+	if err != nil || !report[1].Drifted || report[1].Unseen < 0.66 || report[1].TotalVariation < 0.66 {
+		t.Error(report, err)
+	}
+	e.Distributions = Distributions(view)
+	if report, err = DriftReport(e, shifted); err != nil || len(report) != 4 {
+		t.Error(report, err)
+	}
+	if _, err := DriftReport(e, shifted.Drop("wind")); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	data := `temp,city,go
+12,NY,no
+31,ny,yes
+28,,yes
+15,LA,no
+33,LA,yes
+9,NY,no
+`
+	view, _ := Read(strings.NewReader(data))
+	p := &Pipeline{Steps: []Step{EncodeStep("city", map[string]string{"ny": "NY"})}}
+	encoded, _ := p.Apply(view)
+	p.Steps = append(p.Steps, FitImpute(encoded, "city"), BinStep("temp", []float64{20}, []string{"cold", "warm"}), RenameStep("temp", "weather"))
+	if p.Steps[1].Value != "NY" {
+		t.Error(p.Steps[1])
+	}
+	applied, _ := p.Apply(view)
+	var cities []string
+	for _, row := range rowsOf(applied)[1:] {
+		cities = append(cities, row[1])
+	}
+	if !reflect.DeepEqual(cities, []string{"NY", "NY", "NY", "LA", "LA", "NY"}) {
+		t.Error(cities)
+	}
+	d, err := p.Learn(view, "go")
+	if err != nil || d.Column != "weather" || d.caseFor("warm").Class != "yes" {
+		t.Fatal(d, err)
+	}
+	b, _ := p.ToJSON(false)
+	p, err = PipelineFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, err := p.Decide([][]string{{"temp", "city"}, {"25.5", ""}, {"3", "ny"}})
+	if err != nil || !reflect.DeepEqual(answer, []string{"yes", "no"}) {
+		t.Error(answer, err)
+	}
+	if _, err := p.Apply(view.Drop("temp")); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	if s := FitBins(view, "temp", 2); !reflect.DeepEqual(s.Bounds, []float64{15}) || s.Labels[1] != "(15,+inf)" {
+		t.Error(s)
+	}
+}
+
+func TestRandomSearch(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	params := Params{
+		"columns": {WithExcluded("outlook"), WithExcluded("humidity"), WithExcluded()},
+		"missing": {WithMissing(MissingAsValue), WithMissing(MissingImpute, "wind")},
+	}
+	trials, err := RandomSearch(view, "play", params, 4, 7, 1)
+	if err != nil || len(trials) != 4 {
+		t.Fatal(trials, err)
+	}
+	for i := 1; i < len(trials); i++ {
+		if trials[i].Accuracy > trials[i-1].Accuracy || trials[i].Rows != 14 {
+			t.Error(trials)
+		}
+	}
+	if all, _ := RandomSearch(view, "play", params, 10, 7, 1); len(all) != 6 || all[0].Accuracy < trials[0].Accuracy {
+		t.Error(all)
+	}
+	again, _ := RandomSearch(view, "play", params, 4, 7, 1)
+	if !reflect.DeepEqual(trials, again) {
+		t.Error(again)
+	}
+	if _, err := Learn(view, "play", params.Options(trials[0])...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSuccessiveHalving(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	params := Params{
+		"columns": {WithExcluded("outlook"), WithExcluded("humidity"), WithExcluded("wind"), WithExcluded()},
+		"missing": {WithMissing(MissingAsValue), WithMissing(MissingImpute, "wind")},
+	}
+	trials, err := SuccessiveHalving(view, "play", params, 8, 2, 2, 1)
+	if err != nil || len(trials) != 15 {
+		t.Fatal(trials, err)
+	}
+	if trials[0].Rows != 14 || trials[1].Rows != 8 || trials[len(trials)-1].Rows != 2 {
+		t.Error(trials)
+	}
+	if _, err := SuccessiveHalving(view, "play", params, 8, 2, 1, 1); err == nil {
+		t.Error("eta 1")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	chain := SelectTest(view.Select("outlook", "sunny").Drop("outlook"), "wind", &Test{Op: opContains, Operand: "weak"}, "true")
+	if i, err := indexOf(chain, "humidity"); err != nil || i != 2 {
+		t.Error(i, err)
+	}
+	if _, err := indexOf(chain, "outlook"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	renamed, _ := (&Pipeline{Steps: []Step{RenameStep("wind", "breeze")}}).Apply(chain)
+	if i, err := indexOf(renamed, "breeze"); err != nil || i != 3 {
+		t.Error(i, err)
+	}
+	if _, err := indexOf(renamed, "wind"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	if i, err := indexOf(struct{ View }{chain}, "humidity"); err != nil || i != 2 {
+		t.Error(i, err)
+	}
+	//
+	// Selecting and dropping change nothing shared, so goroutines can do so
+	// from the same view at once.
+	//
+	tokenized, _ := (&Pipeline{Steps: []Step{{Op: "tokens", Column: "outlook", Labels: []string{"sunny"}}}}).Apply(view)
+	for _, v := range []View{view, renamed, tokenized} {
+		counts := make(chan int)
+		for i := 0; i < 4; i++ {
+			go func() {
+				n := 0
+				it := Rows(v.Drop("humidity").Select("play", "no"))
+				for row := it.Next(); row != nil; row = it.Next() {
+					n++
+				}
+				counts <- n
+			}()
+		}
+		for i := 0; i < 4; i++ {
+			if n := <-counts; n != len(rowsOf(v.Select("play", "no")))-1 {
+				t.Error(n)
+			}
+		}
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	want, _ := Learn(view, "play")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var c Checkpoint
+	d, err := Learn(view, "play", WithContext(ctx), WithCheckpoint(&c))
+	if err != nil || len(c.Frontier) != 2 || c.Decision != d || c.Frontier[0].Path[0].Value != "rain" || len(c.Frontier[0].Rows) != 5 {
+		t.Fatal(c, err)
+	}
+	b, _ := c.ToJSON(false)
+	saved, err := CheckpointFromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Checkpoint
+	d, err = Resume(view, saved, WithCheckpoint(&again))
+	if err != nil || !reflect.DeepEqual(d, want) || len(again.Frontier) != 0 {
+		t.Error(d, err)
+	}
+	if _, err := Resume(view, saved, WithContext(ctx), WithCheckpoint(&again)); err != nil || len(again.Frontier) != 2 {
+		t.Error(again, err)
+	}
+}
+
+func TestDegenerate(t *testing.T) {
+	for _, data := range []string{"outlook,wind,play\nsunny,weak,no\n", "outlook,wind,play\nsunny,weak,no\nrain,strong,no\n"} {
+		view, _ := Read(strings.NewReader(data))
+		var s Stats
+		d, err := Learn(view, "play", WithStats(&s))
+		if err != nil || d.Column != "outlook" || len(d.Cases) != 0 || d.Default != "no" || s.Leaves != 1 || d.Validate(nil) != nil {
+			t.Error(d, err)
+		}
+		if answer, err := d.Decide([][]string{{"outlook"}, {"snow"}}); err != nil || answer[0] != "no" {
+			t.Error(answer, err)
+		}
+		if e, err := LearnExternal(strings.NewReader(data), "play"); err != nil || !reflect.DeepEqual(d, e) {
+			t.Error(e, err)
+		}
+	}
+	view, _ := Read(strings.NewReader("outlook,play\n"))
+	if _, err := Learn(view, "play"); !errors.Is(err, ErrEmptyView) {
+		t.Error(err)
+	}
+	view, _ = Read(strings.NewReader("play\nno\nyes\n"))
+	if _, err := Learn(view, "play"); err == nil {
+		t.Error("no column")
+	}
+}
+
+func TestAssociationRules(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	sets := FrequentItemsets(view, 0.25)
+	if len(sets) == 0 || sets[0].Items[0].String() != "play=yes" || math.Abs(sets[0].Support-9.0/14) > 1e-9 {
+		t.Fatal(sets)
+	}
+	for _, s := range sets {
+		if s.Support < 0.25 {
+			t.Error(s)
+		}
+	}
+	rules := AssociationRules(view, 0.25, 0.9)
+	found := false
+	for _, r := range rules {
+		if r.Confidence < 0.9 {
+			t.Error(r)
+		}
+		if r.String() == "outlook=overcast => play=yes" {
+			found = true
+			if r.Confidence != 1 || math.Abs(r.Lift-14.0/9) > 1e-9 || math.Abs(r.Support-4.0/14) > 1e-9 {
+				t.Error(r)
+			}
+		}
+	}
+	if !found {
+		t.Error(rules)
+	}
+	if FrequentItemsets(view, 0) != nil {
+		t.Error("zero support")
+	}
+}
+
+func TestProject(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	v, err := Project(view, "outlook", "humidity", "play")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(v.Columns(), ",") != "outlook,,humidity,,play" {
+		t.Error(v.Columns())
+	}
+	if err := CheckColumns(v.Select("outlook", "sunny"), "temperature"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	decision, err := Learn(v, "play")
+	if err != nil || strings.Join(decision.Columns(), ",") != "humidity,outlook" {
+		t.Error(decision, err)
+	}
+	x, err := Exclude(view, "temperature", "wind")
+	if err != nil || strings.Join(x.Columns(), ",") != strings.Join(v.Columns(), ",") {
+		t.Error(err)
+	}
+	if _, err := Exclude(x, "wind"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	if _, err := Project(view, "golf"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestWhere(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	dropped, _ := Drop(view, "temperature")
+	v := Where(dropped, func(row map[string]string) bool {
+		_, hidden := row["temperature"]
+		return !hidden && strings.HasPrefix(row["outlook"], "s") && row["humidity"] != "normal"
+	})
+	if n := Frequency(v, "play"); n["no"] != 3 || n["yes"] != 0 {
+		t.Error(n)
+	}
+	if n := Frequency(v.Select("wind", "weak"), "play"); n["no"] != 2 {
+		t.Error(n)
+	}
+}
+
+func TestRows(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	dropped, _ := Drop(view, "temperature")
+	where := Where(dropped, func(row map[string]string) bool { return row["humidity"] == "high" })
+	for _, v := range []View{
+		view,
+		view.Select("outlook", "sunny").Drop("wind"),
+		where.Select("play", "no"),
+		&orMissingView{parent: view, col: 3, val: "weak", weightAt: -1},
+		struct{ View }{where},
+	} {
+		want := fmt.Sprint(rowsOf(v)[1:])
+		//
+		// Two iterators interleaved, with First and Next between them.
+		//
+		a, b := Rows(v), Rows(v)
+		var first, second [][]string
+		if row := a.Next(); row != nil {
+			first = append(first, row)
+		}
+		v.First()
+		for row := b.Next(); row != nil; row = b.Next() {
+			second = append(second, row)
+			v.Next()
+		}
+		for row := a.Next(); row != nil; row = a.Next() {
+			first = append(first, row)
+		}
+		if got := fmt.Sprint(first); got != want || fmt.Sprint(second) != want || a.Next() != nil {
+			t.Error(got, want)
+		}
+		//
+		// Several iterators read at once.
+		//
+		counts := make(chan int)
+		for i := 0; i < 4; i++ {
+			go func(it *Iterator) {
+				n := 0
+				for row := it.Next(); row != nil; row = it.Next() {
+					n++
+				}
+				counts <- n
+			}(Rows(v))
+		}
+		for i := 0; i < 4; i++ {
+			if n := <-counts; n != len(first) {
+				t.Error(n, len(first))
+			}
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	indexed := Index(view)
+	for _, pick := range []func(View) View{
+		func(v View) View { return v },
+		func(v View) View { return v.Select("outlook", "sunny") },
+		func(v View) View { return v.Select("wind", "weak").Drop("wind").Select("humidity", "high") },
+		func(v View) View { return v.Select("outlook", "foggy") },
+		func(v View) View {
+			return SelectTest(v.Drop("outlook"), "wind", &Test{Op: opContains, Operand: "weak"}, "true")
+		},
+	} {
+		want, got := pick(view), pick(indexed)
+		if fmt.Sprint(rowsOf(got)) != fmt.Sprint(rowsOf(want)) {
+			t.Error(rowsOf(got), rowsOf(want))
+		}
+		if fmt.Sprint(Likelihood(got, "play")) != fmt.Sprint(Likelihood(want, "play")) {
+			t.Error(Likelihood(got, "play"))
+		}
+	}
+	if _, err := indexOf(indexed.Drop("wind"), "wind"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	want, _ := Learn(view, "play")
+	got, err := Learn(indexed, "play")
+	if err != nil || got.String() != want.String() {
+		t.Error(got, err)
+	}
+}
+
+func TestNewView(t *testing.T) {
+	view, err := NewView([][]string{{"outlook", "play"}, {"sunny", "no"}, {"rain", "yes"}})
+	if err != nil || Frequency(view, "play")["no"] != 1 {
+		t.Error(err)
+	}
+	for _, data := range [][][]string{
+		nil,
+		{{"outlook", ""}},
+		{{"outlook", "outlook"}},
+		{{"outlook", "play"}, {"sunny"}},
+	} {
+		if _, err := NewView(data); !errors.Is(err, ErrSchemaMismatch) {
+			t.Error(data, err)
+		}
+	}
+	records := []map[string]string{{"outlook": "sunny", "play": "no"}, {"play": "yes"}}
+	view, err = NewViewFromMaps(records, nil)
+	if err != nil || strings.Join(view.Columns(), ",") != "outlook,play" || Frequency(view, "outlook")[""] != 1 {
+		t.Error(err)
+	}
+	if _, err := NewViewFromMaps(records, []string{"play"}); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+}
+
+// tableDriver is a database/sql driver whose every query returns the rows of
+// the same table, for testing NewSQLView.
+//
+type tableDriver [][]driver.Value
+
+func (t tableDriver) Open(string) (driver.Conn, error)           { return t, nil }
+func (t tableDriver) Prepare(string) (driver.Stmt, error)        { return t, nil }
+func (t tableDriver) Close() error                               { return nil }
+func (t tableDriver) Begin() (driver.Tx, error)                  { return nil, errors.New("no transactions") }
+func (t tableDriver) NumInput() int                              { return -1 }
+func (t tableDriver) Exec([]driver.Value) (driver.Result, error) { return nil, errors.New("no exec") }
+func (t tableDriver) Query([]driver.Value) (driver.Rows, error)  { return &tableRows{table: t}, nil }
+
+type tableRows struct {
+	table tableDriver
+	next  int
+}
+
+func (r *tableRows) Columns() []string {
+	columns := make([]string, len(r.table[0]))
+	for i, c := range r.table[0] {
+		columns[i] = c.(string)
+	}
+	return columns
+}
+
+func (r *tableRows) Close() error { return nil }
+
+func (r *tableRows) Next(dest []driver.Value) error {
+	if r.next++; r.next == len(r.table) {
+		return io.EOF
+	}
+	copy(dest, r.table[r.next])
+	return nil
+}
+
+func TestSQLView(t *testing.T) {
+	sql.Register("id3table", tableDriver{
+		{"outlook", "humidity", "play"},
+		{"sunny", int64(85), "no"},
+		{"overcast", 1.5, "yes"},
+		{nil, []byte("high"), true},
+	})
+	db, err := sql.Open("id3table", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := NewSQLView(context.Background(), db, "SELECT outlook, humidity, play FROM weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := rowsOf(view)
+	if len(data) != 4 || strings.Join(data[1], ",") != "sunny,85,no" || strings.Join(data[3], ",") != ",high,true" {
+		t.Error(data)
+	}
+	if _, err := Learn(view, "play"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	lines := `{"outlook": "sunny", "temperature": 85, "windy": false, "play": "no"}
+{"outlook": "rain", "temperature": 70.5, "windy": null, "play": "yes"}
+
+{"outlook": "?", "play": "yes"}
+`
+	view, err := ReadJSONLines(strings.NewReader(lines), WithMissingMarkers("?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := rowsOf(view)
+	if strings.Join(data[0], ",") != "outlook,play,temperature,windy" || strings.Join(data[1], ",") != "sunny,no,85,false" {
+		t.Error(data)
+	}
+	if strings.Join(data[2], ",") != "rain,yes,70.5," || strings.Join(data[3], ",") != ",yes,," {
+		t.Error(data)
+	}
+	array, err := ReadJSON(strings.NewReader(`[{"outlook": "sunny", "play": "no"}, {"outlook": "rain", "play": "yes"}]`))
+	if err != nil || len(rowsOf(array)) != 3 {
+		t.Error(err)
+	}
+	if _, err := ReadJSONLines(strings.NewReader(`{"tags": ["a", "b"]}`)); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+}
+
+// weatherParquet is a Parquet file of five rows in two row groups, with a
+// dictionary encoded string column, a data page of version 2, nulls, a date
+// column and snappy and gzip compression.
+//
+const weatherParquet = "" +
+	"UEFSMRUEFSoVLkwVBBUAAAAVUAgAAABvdmVyY2FzdAUAAABzdW5ueRUAFRoVHiwVBhUQFQYVBgAA" +
+	"DTACAAAAAwcCAgECAQIAFQAVMBU4LBUGFQAVBhUGAAAfiwgAAAAAAAL/C2WAgAAoHQylAZGIpQEY" +
+	"AAAAFQYVLBUuXBUGFQIVBhUAFQwVABEAAAIBAgACARAAAA4BAAhgVUASCAAIgFNAFQAVAhUCLBUG" +
+	"FQAVBhUGAAACFQAVGBUYLBUGFQAVBhUGAAALTQAADE0AAA1NAAAVABUmFSYsFQYVABUGFQYAABMU" +
+	"AgAAAG5vFgYAGAMAAAB5ZXMVBBUQFRRMFQIVAAAACBwEAAAAcmFpbhUAFRIVFiwVBBUQFQYVBgAA" +
+	"CSACAAAAAwICAgAVABUgFTQsFQQVABUGFQYAAB+LCAAAAAAAAv9zY4AAFygNAFwYgzwQAAAAFQYV" +
+	"KBUmXBUEFQAVBBUAFQgVABEAAAIBAgEQAAASAQAEWEAWCAAEVEAVABUCFQIsFQQVABUGFQYAAAAV" +
+	"ABUQFRAsFQQVABUGFQYAAA5NAAAPTQAAFQAVHBUYLBUEFQAVBhUGAAAOGAMAAAB5ZXMaBwAVAhl8" +
+	"SAZzY2hlbWEVDAAVDCUCGAdvdXRsb29rJQAAFQQlABgLdGVtcGVyYXR1cmUAFQolAhgIaHVtaWRp" +
+	"dHkAFQAlABgFd2luZHkAFQIlABgDZGF5JQwAFQwlABgEcGxheSUAABYKGSwZbCYIHBUMGTUABhAZ" +
+	"GAdvdXRsb29rFQIWBhaAARaIASZQJggAACaQARwVBBklAAYZGAt0ZW1wZXJhdHVyZRUEFgYWUhZa" +
+	"JpABAAAm6gEcFQoZJQAGGRgIaHVtaWRpdHkVAhYGFlgWWibqAQAAJsQCHBUAGSUABhkYBXdpbmR5" +
+	"FQAWBhYkFiQmxAIAACboAhwVAhklAAYZGANkYXkVABYGFjoWOiboAgAAJqIDHBUMGSUABhkYBHBs" +
+	"YXkVAhYGFkgWSCaiAwAAFsgBFgYAGWwm6gMcFQwZNQAGEBkYB291dGxvb2sVAhYEFl4WZiaYBCbq" +
+	"AwAAJtAEHBUEGSUABhkYC3RlbXBlcmF0dXJlFQQWBBZCFlYm0AQAACamBRwVChklAAYZGAhodW1p" +
+	"ZGl0eRUCFgQWVBZSJqYFAAAm+AUcFQAZJQAGGRgFd2luZHkVABYEFiQWJCb4BQAAJpwGHBUCGSUA" +
+	"BhkYA2RheRUAFgQWMhYyJpwGAAAmzgYcFQwZJQAGGRgEcGxheRUCFgQWPhY6Js4GAAAWyAEWBAAo" +
+	"CGlkMyB0ZXN0AA8CAABQQVIx"
+
+func TestReadParquet(t *testing.T) {
+	b, _ := base64.StdEncoding.DecodeString(weatherParquet)
+	view, schema, err := ReadParquet(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := rowsOf(view)
+	if len(data) != 6 || strings.Join(data[0], ",") != "outlook,temperature,humidity,windy,day,play" {
+		t.Fatal(data)
+	}
+	if strings.Join(data[1], ",") != "sunny,85,85.5,false,2024-01-01,no" || strings.Join(data[2], ",") != "sunny,80,,true,2024-01-02,no" {
+		t.Error(data)
+	}
+	if strings.Join(data[4], ",") != ",70,96,false,2024-01-04,yes" {
+		t.Error(data[4])
+	}
+	if schema["temperature"] != Int || schema["humidity"] != Float || schema["windy"] != Bool || schema["day"] != Time || schema["outlook"] != String {
+		t.Error(schema)
+	}
+	if _, err := Learn(view, "play", WithSchema(schema), WithExcluded("day")); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := ReadParquet(bytes.NewReader(b[:len(b)-20]), int64(len(b)-20)); err == nil {
+		t.Error()
+	}
+	if _, _, err := ReadParquet(strings.NewReader(example), int64(len(example))); err == nil {
+		t.Error()
+	}
+}
+
+func TestDialect(t *testing.T) {
+	data := "# weather\noutlook; temperature;play\nsunny; 29,5;no\nrain;mild \"day\";yes\n"
+	view, err := Read(strings.NewReader(data), WithDialect(Dialect{Comma: ';', Comment: '#', TrimLeadingSpace: true, LazyQuotes: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := rowsOf(view)
+	if strings.Join(rows[0], "|") != "outlook|temperature|play" || rows[1][1] != "29,5" || rows[2][1] != `mild "day"` {
+		t.Error(rows)
+	}
+	headerless := "sunny,no\nrain,yes\n"
+	view, err = Read(strings.NewReader(headerless), WithDialect(Dialect{NoHeader: true}))
+	if err != nil || len(rowsOf(view)) != 3 || Frequency(view, "column2")["yes"] != 1 {
+		t.Error(err)
+	}
+	d, err := LearnExternal(strings.NewReader(headerless), "column2", WithDialect(Dialect{NoHeader: true}))
+	if err != nil || d.Column != "column1" || len(d.Cases) != 2 {
+		t.Error(d, err)
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	headerless := "sunny,no\nrain,yes\n"
+	view, err := Read(strings.NewReader(headerless), WithColumnNames("outlook", "play"))
+	if err != nil || len(rowsOf(view)) != 3 || Frequency(view, "outlook")["sunny"] != 1 {
+		t.Fatal(err)
+	}
+	d, err := LearnExternal(strings.NewReader(headerless), "play", WithColumnNames("outlook", "play"))
+	if err != nil || d.Column != "outlook" || len(d.Cases) != 2 {
+		t.Error(d, err)
+	}
+	if _, err := Read(strings.NewReader(headerless), WithColumnNames("outlook", "wind", "play")); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+	if _, err := Read(strings.NewReader(headerless), WithColumnNames("outlook", "outlook")); !errors.Is(err, ErrSchemaMismatch) {
+		t.Error(err)
+	}
+}
+
+func TestGainReport(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	report, err := GainReport(view, "play")
+	if err != nil || len(report) != 4 {
+		t.Fatal(report, err)
+	}
+	if report[0].Column != "outlook" || math.Abs(report[0].Gain-0.2467) > 1e-4 || report[0].Ratio != GainRatioOf(view, "outlook", "play") {
+		t.Error(report[0])
+	}
+	for i := 1; i < len(report); i++ {
+		if report[i].Gain > report[i-1].Gain {
+			t.Error(report)
+		}
+	}
+	if _, err := GainReport(view, "golf"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+}
+
+func TestCheckColumns(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	if v, err := Select(view, "outlook", "sunny"); err != nil || len(Frequency(v, "play")) != 2 {
+		t.Error(err)
+	}
+	if _, err := Select(view, "golf", "yes"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	v, err := Drop(view, "outlook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Drop(v, "outlook"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	if err := CheckColumns(v, "play", "wind"); err != nil {
+		t.Error(err)
+	}
+	if err := CheckColumns(v, "play", ""); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	data := `temperature,wind,play
+64,weak,yes
+65,strong,no
+68,weak,yes
+69,weak,yes
+70,strong,yes
+71,strong,no
+72,weak,no
+75,weak,yes
+80,strong,no
+81,weak,no
+83,weak,no
+85,weak,no
+`
+	view, _ := Read(strings.NewReader(data))
+	schema := InferSchema(view)
+	if schema["temperature"] != Int {
+		t.Fatal(schema)
+	}
+	decision, err := Learn(view, "play", WithSchema(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Column != "temperature" || decision.Test == nil || decision.Test.String() != "<= 75" {
+		t.Fatal(decision)
+	}
+	result, err := decision.Decide([][]string{{"temperature", "wind"}, {"90", "weak"}, {"66.5", "weak"}, {"", "weak"}})
+	if err != nil || strings.Join(result, ",") != "no,yes,no" {
+		t.Error(result, err)
+	}
+	if s := decision.String(); !strings.Contains(s, "temperature > 75: no") {
+		t.Error(s)
+	}
+	if err := decision.Validate(nil); err != nil {
+		t.Error(err)
+	}
+	rules := decision.ToRules()
+	if last := rules[len(rules)-1].Format("play"); last != "IF temperature > 75 THEN play=no" {
+		t.Error(last)
+	}
+	if !decision.Equivalent(decision.clone(), true) {
+		t.Error("not equivalent to itself")
+	}
+	if s, err := decision.ToSQL(ANSISQL); err != nil || !strings.Contains(s, `WHEN "temperature" <= 75 THEN`) {
+		t.Error(s, err)
+	}
+	if b, err := decision.ToGo("rules", "play"); err != nil || !strings.Contains(string(b), "v <= 75") {
+		t.Error(string(b), err)
+	}
+}
+
+func TestGainRatio(t *testing.T) {
+	data := `code,flag,class
+1,a,y
+2,a,y
+3,a,y
+4,a,y
+5,a,n
+6,b,n
+7,b,n
+8,b,n
+`
+	view, _ := Read(strings.NewReader(data))
+	if r := GainRatioOf(view, "code", "class"); math.Abs(r-1.0/3) > 1e-9 {
+		t.Error(r)
+	}
+	if r := GainRatioOf(view, "flag", "class"); r < 0.5 || r > 0.6 {
+		t.Error(r)
+	}
+	decision, _ := Learn(view, "class")
+	if decision.Column != "code" {
+		t.Error(decision.Column)
+	}
+	decision, _ = Learn(view, "class", WithCriterion(GainRatio))
+	if decision.Column != "flag" {
+		t.Error(decision.Column)
+	}
+	external, err := LearnExternal(strings.NewReader(data), "class", WithCriterion(GainRatio))
+	if err != nil || len(Diff(decision, external)) > 0 {
+		t.Error(external, err)
+	}
+}
+
+func TestImpurity(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	if g := Gini(view, "play"); math.Abs(g-(1-(9.0*9+5*5)/(14*14))) > 1e-9 {
+		t.Error(g)
+	}
+	if m := Misclassification.Measure([]float64{0.25, 0.75}); m != 0.25 {
+		t.Error(m)
+	}
+	if h := Shannon.Measure([]float64{0.5, 0.5}); h != 1 {
+		t.Error(h)
+	}
+	entropy, _ := Learn(view, "play")
+	shannon, _ := Learn(view, "play", WithImpurity(Shannon))
+	if len(Diff(entropy, shannon)) > 0 {
+		t.Error(shannon)
+	}
+	for _, m := range []Impurity{GiniIndex, Misclassification} {
+		d, err := Learn(view, "play", WithImpurity(m))
+		if err != nil || d.Column != "outlook" {
+			t.Fatal(d, err)
+		}
+		result, _ := d.Decide(rowsOf(view))
+		for i, row := range rowsOf(view)[1:] {
+			if result[i] != row[4] {
+				t.Error(m, row, result[i])
+			}
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play", WithDefaults())
+	if decision.Default != "yes" || decision.Cases[0].Decide.Default == "" {
+		t.Fatal(decision)
+	}
+	result, err := decision.Decide([][]string{{"outlook", "humidity", "wind"}, {"foggy", "high", "weak"}, {"sunny", "damp", "weak"}})
+	if err != nil || strings.Join(result, ",") != "yes,no" {
+		t.Error(result, err)
+	}
+	external, err := LearnExternal(strings.NewReader(example), "play", WithDefaults())
+	if err != nil || len(Diff(decision, external)) > 0 {
+		t.Error(external, err)
+	}
+}
+
+func TestMissingMarkers(t *testing.T) {
+	data := strings.Replace(example, "rain,mild,high,weak,yes", "rain,mild,?,weak,yes", 1)
+	view, _ := Read(strings.NewReader(data), WithMissingMarkers("?", "NA"))
+	if f := Frequency(view, "humidity"); f[""] != 1 || f["?"] != 0 {
+		t.Fatal(f)
+	}
+	decision, _ := Learn(view, "play")
+	//
+	// A missing wind takes the case of the most rows, a weak wind.
+	//
+	rows := [][]string{{"outlook", "temperature", "humidity", "wind"}, {"rain", "hot", "normal", ""}, {"rain", "hot", "normal", "NA"}}
+	if _, err := decision.Decide(rows); !errors.Is(err, ErrNoMatchingCase) {
+		t.Error(err)
+	}
+	result, err := decision.DecideContext(context.Background(), rows, WithMissingMarkers("NA"))
+	if err != nil || strings.Join(result, ",") != "yes,yes" {
+		t.Error(result, err)
+	}
+}
+
+func TestMissingFallback(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	rows := [][]string{{"outlook", "humidity", "wind", "play"}, {"", "normal", "weak", "yes"}}
+	//
+	// A missing outlook takes the case of the most rows, whatever walks the
+	// decision.
+	//
+	if result, err := decision.Decide(rows); err != nil || result[0] != "yes" {
+		t.Error(result, err)
+	}
+	test, _ := NewView(rows)
+	if m, _ := Evaluate(decision, test, "play"); m.Counts["yes"]["yes"] != 1 {
+		t.Error(m.Counts)
+	}
+	if p := decision.Probabilities(rows[0], rows[1]); p["yes"] != 1 {
+		t.Error(p)
+	}
+	if at, c, err := decision.Follow(map[string]string{"outlook": "", "humidity": "normal", "wind": "weak"}); err != nil || at == decision || c.Class != "yes" {
+		t.Error(at, c, err)
+	}
+	//
+	// Without a fallback there is no rule.
+	//
+	none := WithMissingFallback(FallbackNone)
+	if _, err := decision.DecideContext(context.Background(), rows, none); !errors.Is(err, ErrNoMatchingCase) {
+		t.Error(err)
+	}
+	if m, _ := Evaluate(decision, test, "play", none); m.Counts["yes"][Undecided] != 1 {
+		t.Error(m.Counts)
+	}
+	if at, _, err := decision.Follow(map[string]string{"outlook": ""}, none); !errors.Is(err, ErrNoMatchingCase) || at != decision {
+		t.Error(err)
+	}
+	//
+	// A default class comes before the fallback.
+	//
+	decision.Default = "maybe"
+	if result, err := decision.Decide(rows); err != nil || result[0] != "maybe" {
+		t.Error(result, err)
+	}
+	if m, _ := Evaluate(decision, test, "play"); m.Counts["yes"]["maybe"] != 1 {
+		t.Error(m.Counts)
+	}
+	if at, c, err := decision.Follow(map[string]string{"outlook": ""}); err != nil || c != nil || at.Default != "maybe" {
+		t.Error(at, c, err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	for _, opts := range [][]Option{{WithMaxDepth(1)}, {WithMinSamplesSplit(6)}} {
+		decision, err := Learn(view, "play", opts...)
+		if err != nil || decision.Column != "outlook" {
+			t.Fatal(decision, err)
+		}
+		for _, c := range decision.Cases {
+			if c.Decide != nil {
+				t.Error(c)
+			}
+		}
+		if c := decision.Cases[1]; c.Value != "sunny" || c.Class != "no" {
+			t.Error(c)
+		}
+	}
+	decision, err := Learn(view, "play", WithMinSamplesLeaf(5))
+	if err != nil || decision.String() != "humidity = high: no (7/3)\nhumidity = normal: yes (7/1)\n" {
+		t.Error(decision, err)
+	}
+	external, err := LearnExternal(strings.NewReader(example), "play", WithMinSamplesLeaf(5))
+	if err != nil || len(Diff(decision, external)) > 0 {
+		t.Error(external, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	if pruned, err := Prune(decision, view, "play"); err != nil || len(Diff(decision, pruned)) > 0 {
+		t.Error(pruned, err)
+	}
+	//
+	// The sunny decision on humidity is wrong for both rows, so becomes the
+	// leaf for most of its training rows, and the rain decision is reached by
+	// no row.
+	//
+	noisy, _ := Read(strings.NewReader(`outlook,temperature,humidity,wind,play
+sunny,mild,normal,weak,no
+sunny,cool,normal,strong,no
+`))
+	pruned, _ := Prune(decision, noisy, "play")
+	if s := pruned.String(); s != "outlook = rain: yes (5/2)\noutlook = sunny: no (5/2)\noutlook = overcast: yes (4)\n" {
+		t.Error(s)
+	}
+	if decision.Cases[0].Decide == nil {
+		t.Error("original pruned")
+	}
+	if _, err := Prune(decision, noisy, "golf"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+	if _, err := Prune(decision, noisy.Drop("outlook"), "play"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestKFold(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	folds, err := KFold(view, 3, "play")
+	if err != nil || len(folds) != 3 {
+		t.Fatal(folds, err)
+	}
+	tested := 0
+	for _, f := range folds {
+		train, test := Frequency(f.Train, "play"), Frequency(f.Test, "play")
+		if train["yes"]+test["yes"] != 9 || train["no"]+test["no"] != 5 || test["yes"] < 3 || test["no"] < 1 {
+			t.Error(train, test)
+		}
+		tested += test["yes"] + test["no"]
+	}
+	if tested != 14 {
+		t.Error(tested)
+	}
+	if _, err := KFold(view, 15, ""); err == nil {
+		t.Error("15 folds of 14 rows")
+	}
+	if _, err := KFold(view, 2, "colour"); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+	mean, stddev, err := CrossValidate(view, "play", 2)
+	if err != nil || mean < 0 || mean > 1 || stddev < 0 {
+		t.Error(mean, stddev, err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	m, err := Evaluate(decision, view, "play")
+	if err != nil || m.Rows != 14 || m.Accuracy() != 1 || m.F1("yes") != 1 {
+		t.Fatal(m, err)
+	}
+	test, _ := Read(strings.NewReader(`outlook,temperature,humidity,wind,play
+sunny,hot,high,weak,yes
+sunny,hot,normal,weak,yes
+overcast,hot,high,weak,no
+foggy,hot,high,weak,no
+`))
+	m, _ = Evaluate(decision, test, "play")
+	if m.Accuracy() != 0.25 || m.Counts["no"][Undecided] != 1 || strings.Join(m.Classes(), ",") != "no,yes,(none)" {
+		t.Error(m)
+	}
+	if p, r := m.Precision("yes"), m.Recall("yes"); p != 0.5 || r != 0.5 || m.F1("yes") != 0.5 || m.Support("yes") != 2 {
+		t.Error(p, r)
+	}
+	if p, r := m.Precision("no"), m.Recall("no"); p != 0 || r != 0 || m.F1("no") != 0 {
+		t.Error(p, r)
+	}
+	if _, err := Evaluate(decision, test, "colour"); !errors.Is(err, ErrClassColumnMissing) {
+		t.Error(err)
+	}
+}
+
+func TestDecideRow(t *testing.T) {
+	view, _ := Read(strings.NewReader(example))
+	decision, _ := Learn(view, "play")
+	if class, err := decision.DecideRow(map[string]string{"outlook": "sunny", "humidity": "normal"}); err != nil || class != "yes" {
+		t.Error(class, err)
+	}
+	if _, err := decision.DecideRow(map[string]string{"outlook": "foggy"}); !errors.Is(err, ErrNoMatchingCase) {
+		t.Error(err)
+	}
+	if _, err := decision.DecideRow(map[string]string{"outlook": "rain"}); !errors.Is(err, ErrColumnNotFound) {
+		t.Error(err)
+	}
+}
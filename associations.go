@@ -0,0 +1,239 @@
+package id3
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An Itemset is a set of conditions, each a column having a value, that hold
+// together in some of the rows of a view.
+//
+type Itemset struct {
+	Items   []Condition // In the order of the columns of the view.
+	Support float64     // The fraction of the rows in which all the items hold.
+}
+
+// An AssociationRule states that where its If conditions hold, its Then
+// conditions tend to hold too, such as "outlook=overcast => play=yes".
+//
+type AssociationRule struct {
+	If         []Condition
+	Then       []Condition
+	Support    float64 // The fraction of the rows in which all the conditions hold.
+	Confidence float64 // The fraction of the rows meeting If that also meet Then.
+	Lift       float64 // The confidence relative to the support of Then, above 1 where If makes Then more likely.
+}
+
+// String returns the rule as text, for example "outlook=overcast =>
+// play=yes".
+//
+func (r AssociationRule) String() string {
+	return joinConditions(r.If) + " => " + joinConditions(r.Then)
+}
+
+func joinConditions(conditions []Condition) string {
+	s := make([]string, len(conditions))
+	for i, c := range conditions {
+		s[i] = c.String()
+	}
+	return strings.Join(s, " AND ")
+}
+
+// FrequentItemsets returns the sets of conditions on the visible columns of
+// the view with at least the minimum support, which must be more than zero, by
+// the Apriori algorithm: the single conditions are counted with Frequency, and
+// each larger set is counted in one pass over the rows only if every smaller
+// set within it is frequent. Missing values are not items. The itemsets are in
+// decreasing support, then by size and text.
+//
+func FrequentItemsets(view View, minSupport float64) []Itemset {
+	columns := view.Columns()
+	rows := 0
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		rows++
+	}
+	if rows == 0 || minSupport <= 0 {
+		return nil
+	}
+	var frequent []Itemset
+	var level [][]item
+	for i, c := range columns {
+		if c == "" {
+			continue
+		}
+		for v, n := range Frequency(view, c) {
+			if s := float64(n) / float64(rows); v != "" && s >= minSupport {
+				level = append(level, []item{{i, v}})
+				frequent = append(frequent, itemset(columns, level[len(level)-1], s))
+			}
+		}
+	}
+	known := make(map[string]bool)
+	for _, set := range level {
+		known[itemKey(set)] = true
+	}
+	for len(level) > 1 {
+		candidates := joinItemsets(level, known)
+		if len(candidates) == 0 {
+			break
+		}
+		counts := make([]int, len(candidates))
+		view.First()
+		for row := view.Next(); row != nil; row = view.Next() {
+			for j, set := range candidates {
+				if holdsItems(set, row) {
+					counts[j]++
+				}
+			}
+		}
+		level = level[:0]
+		for j, set := range candidates {
+			if s := float64(counts[j]) / float64(rows); s >= minSupport {
+				level = append(level, set)
+				known[itemKey(set)] = true
+				frequent = append(frequent, itemset(columns, set, s))
+			}
+		}
+	}
+	sort.Slice(frequent, func(i, j int) bool {
+		a, b := frequent[i], frequent[j]
+		switch {
+		case a.Support != b.Support:
+			return a.Support > b.Support
+		case len(a.Items) != len(b.Items):
+			return len(a.Items) < len(b.Items)
+		}
+		return joinConditions(a.Items) < joinConditions(b.Items)
+	})
+	return frequent
+}
+
+// AssociationRules returns the rules between the conditions of each frequent
+// itemset of the view, as found by FrequentItemsets, with at least the minimum
+// confidence. The rules are in decreasing confidence, then lift, then by text.
+//
+func AssociationRules(view View, minSupport, minConfidence float64) []AssociationRule {
+	sets := FrequentItemsets(view, minSupport)
+	support := make(map[string]float64, len(sets))
+	for _, s := range sets {
+		support[joinConditions(s.Items)] = s.Support
+	}
+	var rules []AssociationRule
+	for _, s := range sets {
+		n := len(s.Items)
+		if n < 2 {
+			continue
+		}
+		//
+		// Every non-empty proper subset of the items may be the If part, and
+		// being frequent its support is known.
+		//
+		for mask := 1; mask < 1<<n-1; mask++ {
+			var when, then []Condition
+			for i, c := range s.Items {
+				if mask&(1<<i) != 0 {
+					when = append(when, c)
+				} else {
+					then = append(then, c)
+				}
+			}
+			confidence := s.Support / support[joinConditions(when)]
+			if confidence < minConfidence {
+				continue
+			}
+			rules = append(rules, AssociationRule{
+				If:         when,
+				Then:       then,
+				Support:    s.Support,
+				Confidence: confidence,
+				Lift:       confidence / support[joinConditions(then)],
+			})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		switch {
+		case a.Confidence != b.Confidence:
+			return a.Confidence > b.Confidence
+		case a.Lift != b.Lift:
+			return a.Lift > b.Lift
+		}
+		return a.String() < b.String()
+	})
+	return rules
+}
+
+// item is a column, by index, having a value.
+//
+type item struct {
+	col   int
+	value string
+}
+
+// itemKey identifies the items, which are in column order.
+//
+func itemKey(items []item) string {
+	var b strings.Builder
+	for _, it := range items {
+		b.WriteString(strconv.Itoa(it.col))
+		b.WriteByte('=')
+		b.WriteString(it.value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// joinItemsets returns the candidate itemsets one larger than those of the
+// level, from each pair that differ only in their last item, on different
+// columns, whose every subset one smaller is known to be frequent.
+//
+func joinItemsets(level [][]item, known map[string]bool) [][]item {
+	sort.Slice(level, func(i, j int) bool { return itemKey(level[i]) < itemKey(level[j]) })
+	var candidates [][]item
+	for i, a := range level {
+		for _, b := range level[i+1:] {
+			n := len(a)
+			if itemKey(a[:n-1]) != itemKey(b[:n-1]) {
+				break
+			}
+			last := []item{a[n-1], b[n-1]}
+			switch {
+			case last[0].col == last[1].col:
+				continue
+			case last[0].col > last[1].col:
+				last[0], last[1] = last[1], last[0]
+			}
+			set := append(a[:n-1:n-1], last...)
+			frequent := true
+			for drop := range set {
+				subset := append(append([]item(nil), set[:drop]...), set[drop+1:]...)
+				frequent = frequent && known[itemKey(subset)]
+			}
+			if frequent {
+				candidates = append(candidates, set)
+			}
+		}
+	}
+	return candidates
+}
+
+// holdsItems reports whether the row has every item.
+//
+func holdsItems(items []item, row []string) bool {
+	for _, it := range items {
+		if row[it.col] != it.value {
+			return false
+		}
+	}
+	return true
+}
+
+func itemset(columns []string, items []item, support float64) Itemset {
+	s := Itemset{Support: support}
+	for _, it := range items {
+		s.Items = append(s.Items, Condition{Column: columns[it.col], Value: it.value})
+	}
+	return s
+}
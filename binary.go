@@ -0,0 +1,408 @@
+package id3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+func init() {
+	gob.Register(&Decision{})
+}
+
+// gobDecision and gobCase mirror Decision and Case, so that the gob encoding
+// within MarshalBinary does not recurse back into MarshalBinary.
+//
+type gobDecision struct {
+	Column  string
+	Cases   []gobCase
+	Default string
+	Test    *Test
+}
+
+type gobCase struct {
+	Value  string
+	Class  string
+	Decide *gobDecision
+	Counts map[string]int
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler using gob, which also makes
+// a decision directly usable with gob encoders and net/rpc.
+//
+func (d *Decision) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(d.toGob()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing this
+// decision with the one encoded by MarshalBinary.
+//
+func (d *Decision) UnmarshalBinary(b []byte) error {
+	g := new(gobDecision)
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(g); err != nil {
+		return err
+	}
+	*d = *g.fromGob()
+	return nil
+}
+
+func (d *Decision) toGob() *gobDecision {
+	g := &gobDecision{Column: d.Column, Cases: make([]gobCase, len(d.Cases)), Default: d.Default, Test: d.Test}
+	for i, c := range d.Cases {
+		g.Cases[i] = gobCase{Value: c.Value, Class: c.Class, Counts: c.Counts}
+		if c.Decide != nil {
+			g.Cases[i].Decide = c.Decide.toGob()
+		}
+	}
+	return g
+}
+
+func (g *gobDecision) fromGob() *Decision {
+	d := &Decision{Column: g.Column, Cases: make([]*Case, len(g.Cases)), Default: g.Default, Test: g.Test}
+	for i, c := range g.Cases {
+		d.Cases[i] = &Case{Value: c.Value, Class: c.Class, Counts: c.Counts}
+		if c.Decide != nil {
+			d.Cases[i].Decide = c.Decide.fromGob()
+		}
+	}
+	return d
+}
+
+// The compact binary format starts with compactMagic and a version byte, then
+// has a table of all the distinct strings followed by the trees and the weight
+// of each tree in a committee. Each decision
+// is a record of its column, as an index into the string table, its cases and
+// its default class, then a flag for whether it has a test followed by the op,
+// operand and separator of any test. Each case is the value, a flag for whether
+// it is a leaf and either the class and class frequencies or the subsequent
+// decision. All integers are unsigned varints, and each weight is the 8 bytes
+// of a float64, little endian. Version 1 has no default classes, version 2 no
+// tests, and version 3 no weights, so that every tree has a weight of one.
+//
+const (
+	compactMagic   = "ID3C"
+	compactVersion = 4
+	compactLeaf    = 0
+	compactDecide  = 1
+	compactNoTest  = 0
+	compactTest    = 1
+)
+
+// ToCompact returns this decision in a compact binary format, which is much
+// smaller and faster to read than JSON for large trees.
+//
+func (d *Decision) ToCompact() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCompact(&buf, []*Decision{d}, []float64{1}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FromCompact translates the given compact binary format into a decision.
+//
+func FromCompact(b []byte) (*Decision, error) {
+	trees, _, err := readCompact(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(trees) != 1 {
+		return nil, fmt.Errorf("id3: compact format has %d trees, expected 1", len(trees))
+	}
+	return trees[0], nil
+}
+
+// ToCompact returns this committee, with the weight of each tree, in the
+// compact binary format of Decision.ToCompact. The strings of all the trees
+// are held once, so a forest of many trees is much smaller than as JSON.
+//
+func (c *Committee) ToCompact() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCompact(&buf, c.Trees, c.Weights); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CommitteeFromCompact translates the given compact binary format, as from
+// Committee.ToCompact, into a committee.
+//
+func CommitteeFromCompact(b []byte) (*Committee, error) {
+	trees, weights, err := readCompact(b)
+	if err != nil {
+		return nil, err
+	}
+	return BuildCommittee(trees, weights)
+}
+
+type compactWriter struct {
+	w       *bufio.Writer
+	strings map[string]uint64
+	table   []string
+}
+
+func writeCompact(w io.Writer, trees []*Decision, weights []float64) error {
+	c := &compactWriter{w: bufio.NewWriter(w), strings: make(map[string]uint64)}
+	for _, t := range trees {
+		c.collect(t)
+	}
+	c.w.WriteString(compactMagic)
+	c.w.WriteByte(compactVersion)
+	c.uvarint(uint64(len(c.table)))
+	for _, s := range c.table {
+		c.uvarint(uint64(len(s)))
+		c.w.WriteString(s)
+	}
+	c.uvarint(uint64(len(trees)))
+	for _, t := range trees {
+		c.decision(t)
+	}
+	for _, weight := range weights {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(weight))
+		c.w.Write(buf[:])
+	}
+	return c.w.Flush()
+}
+
+func (c *compactWriter) intern(s string) {
+	if _, ok := c.strings[s]; !ok {
+		c.strings[s] = uint64(len(c.table))
+		c.table = append(c.table, s)
+	}
+}
+
+func (c *compactWriter) collect(d *Decision) {
+	c.intern(d.Column)
+	c.intern(d.Default)
+	if d.Test != nil {
+		c.intern(d.Test.Op)
+		c.intern(d.Test.Operand)
+		c.intern(d.Test.Separator)
+	}
+	for _, k := range d.Cases {
+		c.intern(k.Value)
+		c.intern(k.Class)
+		for class := range k.Counts {
+			c.intern(class)
+		}
+		if k.Decide != nil {
+			c.collect(k.Decide)
+		}
+	}
+}
+
+func (c *compactWriter) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	c.w.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
+
+func (c *compactWriter) decision(d *Decision) {
+	c.uvarint(c.strings[d.Column])
+	c.uvarint(uint64(len(d.Cases)))
+	for _, k := range d.Cases {
+		c.uvarint(c.strings[k.Value])
+		if k.Decide != nil {
+			c.w.WriteByte(compactDecide)
+			c.decision(k.Decide)
+			continue
+		}
+		c.w.WriteByte(compactLeaf)
+		c.uvarint(c.strings[k.Class])
+		classes := make([]string, 0, len(k.Counts))
+		for class := range k.Counts {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		c.uvarint(uint64(len(classes)))
+		for _, class := range classes {
+			c.uvarint(c.strings[class])
+			c.uvarint(uint64(k.Counts[class]))
+		}
+	}
+	c.uvarint(c.strings[d.Default])
+	if d.Test == nil {
+		c.w.WriteByte(compactNoTest)
+		return
+	}
+	c.w.WriteByte(compactTest)
+	c.uvarint(c.strings[d.Test.Op])
+	c.uvarint(c.strings[d.Test.Operand])
+	c.uvarint(c.strings[d.Test.Separator])
+}
+
+type compactReader struct {
+	r       *bytes.Reader
+	version byte
+	table   []string
+}
+
+// readCompact returns the trees of the compact binary format and the weight of
+// each.
+//
+func readCompact(b []byte) ([]*Decision, []float64, error) {
+	r := bytes.NewReader(b)
+	magic := make([]byte, len(compactMagic)+1)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, nil, err
+	}
+	if string(magic[:len(compactMagic)]) != compactMagic {
+		return nil, nil, errors.New("id3: not the compact binary format")
+	}
+	version := magic[len(compactMagic)]
+	if version < 1 || version > compactVersion {
+		return nil, nil, fmt.Errorf("id3: unsupported compact binary version %d", version)
+	}
+	c := &compactReader{r: r, version: version}
+	n, err := c.length()
+	if err != nil {
+		return nil, nil, err
+	}
+	c.table = make([]string, n)
+	for i := range c.table {
+		size, err := c.length()
+		if err != nil {
+			return nil, nil, err
+		}
+		b := make([]byte, size)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, nil, err
+		}
+		c.table[i] = string(b)
+	}
+	n, err = c.length()
+	if err != nil {
+		return nil, nil, err
+	}
+	trees := make([]*Decision, n)
+	for i := range trees {
+		if trees[i], err = c.decision(); err != nil {
+			return nil, nil, err
+		}
+	}
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1
+		if version > 3 {
+			var buf [8]byte
+			if _, err := io.ReadFull(r, buf[:]); err != nil {
+				return nil, nil, err
+			}
+			weights[i] = math.Float64frombits(binary.LittleEndian.Uint64(buf[:]))
+		}
+	}
+	return trees, weights, nil
+}
+
+// length reads a count or size, rejecting values larger than could possibly be
+// present in the remaining data, as each entry counted takes at least a byte,
+// so that a corrupt length fails rather than allocates.
+//
+func (c *compactReader) length() (int, error) {
+	v, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return 0, err
+	}
+	if v > uint64(c.r.Len()) {
+		return 0, errors.New("id3: corrupt compact binary length")
+	}
+	return int(v), nil
+}
+
+func (c *compactReader) string() (string, error) {
+	i, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return "", err
+	}
+	if i >= uint64(len(c.table)) {
+		return "", errors.New("id3: corrupt compact binary string index")
+	}
+	return c.table[i], nil
+}
+
+func (c *compactReader) decision() (*Decision, error) {
+	column, err := c.string()
+	if err != nil {
+		return nil, err
+	}
+	n, err := c.length()
+	if err != nil {
+		return nil, err
+	}
+	d := &Decision{Column: column}
+	for i := 0; i < n; i++ {
+		k := new(Case)
+		if k.Value, err = c.string(); err != nil {
+			return nil, err
+		}
+		flag, err := c.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch flag {
+		case compactDecide:
+			if k.Decide, err = c.decision(); err != nil {
+				return nil, err
+			}
+		case compactLeaf:
+			if k.Class, err = c.string(); err != nil {
+				return nil, err
+			}
+			m, err := c.length()
+			if err != nil {
+				return nil, err
+			}
+			for j := 0; j < m; j++ {
+				class, err := c.string()
+				if err != nil {
+					return nil, err
+				}
+				count, err := binary.ReadUvarint(c.r)
+				if err != nil {
+					return nil, err
+				}
+				if k.Counts == nil {
+					k.Counts = make(map[string]int, m)
+				}
+				k.Counts[class] = int(count)
+			}
+		default:
+			return nil, errors.New("id3: corrupt compact binary case")
+		}
+		d.Cases = append(d.Cases, k)
+	}
+	if c.version > 1 {
+		if d.Default, err = c.string(); err != nil {
+			return nil, err
+		}
+	}
+	if c.version > 2 {
+		flag, err := c.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch flag {
+		case compactNoTest:
+		case compactTest:
+			d.Test = new(Test)
+			for _, s := range []*string{&d.Test.Op, &d.Test.Operand, &d.Test.Separator} {
+				if *s, err = c.string(); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, errors.New("id3: corrupt compact binary test")
+		}
+	}
+	return d, nil
+}
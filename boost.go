@@ -0,0 +1,157 @@
+package id3
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// A BoostedModel is a committee of decision trees learned in turn by
+// LearnBoosted, each weighted in the vote by how well it decides.
+//
+type BoostedModel struct {
+	Committee
+}
+
+// LearnBoosted learns up to the given number of decision trees for the class
+// column of the view by AdaBoost.M1. Each row has a weight, at first the same
+// for all, and each tree is learned from the rows counted by their weights, as
+// WithWeights counts them, scaled so that the weights sum to the number of
+// rows. The rows the tree decides correctly then have their weights reduced,
+// so the next tree attends to those it did not. A tree with weighted error e
+// has a vote of log((1-e)/e). Boosting stops early when a tree decides every
+// row, or has an error of one half or more, which is only kept if it is the
+// first. The other options are those of Learn, and WithMaxDepth(1) boosts
+// decision stumps.
+//
+func LearnBoosted(view View, class string, rounds int, opts ...Option) (*BoostedModel, error) {
+	if rounds < 1 {
+		return nil, errors.New("id3: boosting needs at least one round")
+	}
+	data := rowsOf(view)
+	classAt, err := find(data[0], class)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	if len(data) < 2 {
+		return nil, ErrEmptyView
+	}
+	rows := data[1:]
+	weights := make([]float64, len(rows))
+	for i := range weights {
+		weights[i] = 1 / float64(len(rows))
+	}
+	m := new(BoostedModel)
+	for round := 0; round < rounds; round++ {
+		scaled := make([]float64, len(weights))
+		for i, w := range weights {
+			scaled[i] = w * float64(len(rows))
+		}
+		tree, err := Learn(view, class, append(opts[:len(opts):len(opts)], withRowWeights(scaled))...)
+		if err != nil {
+			return nil, err
+		}
+		//
+		// Find the weighted error of the tree on every row, counting a row it
+		// has no rule for as an error.
+		//
+		index := make(map[string]int)
+		correct := make([]bool, len(rows))
+		e := 0.0
+		for i, row := range rows {
+			c, ok := tree.classify(row, data[0], index, nil)
+			if correct[i] = ok && c == row[classAt]; !correct[i] {
+				e += weights[i]
+			}
+		}
+		if e >= 0.5 {
+			if round == 0 {
+				m.Trees, m.Weights = append(m.Trees, tree), append(m.Weights, 1)
+			}
+			break
+		}
+		m.Trees, m.Weights = append(m.Trees, tree), append(m.Weights, math.Log((1-math.Max(e, 1e-10))/math.Max(e, 1e-10)))
+		if e == 0 {
+			break
+		}
+		//
+		// Reduce the weights of the rows decided correctly, and normalize.
+		//
+		beta := e / (1 - e)
+		total := 0.0
+		for i := range weights {
+			if correct[i] {
+				weights[i] *= beta
+			}
+			total += weights[i]
+		}
+		for i := range weights {
+			weights[i] /= total
+		}
+	}
+	return m, nil
+}
+
+// BoostedModelFromJSON translates the given JSON formatted byte slice, as from
+// ToJSON, into a boosted model.
+//
+func BoostedModelFromJSON(b []byte) (*BoostedModel, error) {
+	c, err := CommitteeFromJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return &BoostedModel{*c}, nil
+}
+
+// BoostedModelFromCompact translates the given compact binary format, as from
+// ToCompact, into a boosted model.
+//
+func BoostedModelFromCompact(b []byte) (*BoostedModel, error) {
+	c, err := CommitteeFromCompact(b)
+	if err != nil {
+		return nil, err
+	}
+	return &BoostedModel{*c}, nil
+}
+
+// BoostedModelFromProto translates the given protocol buffers Committee
+// message, as from ToProto, into a boosted model.
+//
+func BoostedModelFromProto(b []byte) (*BoostedModel, error) {
+	c, err := CommitteeFromProto(b)
+	if err != nil {
+		return nil, err
+	}
+	return &BoostedModel{*c}, nil
+}
+
+// Resample returns a view of as many rows as the view has, drawn from them at
+// random with replacement, each with a probability in proportion to its
+// weight. The weights are in the order of the rows, and a row without a
+// positive weight is never drawn. If no row has one, every row has the same
+// weight, as for Bootstrap. The sample is the same for the same seed.
+//
+func Resample(view View, weights []float64, seed int64) View {
+	data := rowsOf(view)
+	cumulative := make([]float64, len(data)-1)
+	total := 0.0
+	for i := range cumulative {
+		if i < len(weights) && weights[i] > 0 {
+			total += weights[i]
+		}
+		cumulative[i] = total
+	}
+	if total == 0 {
+		return Bootstrap(view, seed)
+	}
+	r := rand.New(rand.NewSource(seed))
+	sample := make([][]string, len(data))
+	sample[0] = data[0]
+	for i := 1; i < len(data); i++ {
+		x := r.Float64() * total
+		sample[i] = data[1+sort.Search(len(cumulative), func(j int) bool { return cumulative[j] > x })]
+	}
+	return newBaseView(sample)
+}
@@ -0,0 +1,35 @@
+package id3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// Canonical returns a copy of this decision with the cases of every decision
+// sorted by value. Trees that differ only in the order of their cases have the
+// same canonical form.
+//
+func (d *Decision) Canonical() *Decision {
+	c := d.clone()
+	c.canonicalize()
+	return c
+}
+
+func (d *Decision) canonicalize() {
+	sort.SliceStable(d.Cases, func(i, j int) bool { return d.Cases[i].Value < d.Cases[j].Value })
+	for _, c := range d.Cases {
+		if c.Decide != nil {
+			c.Decide.canonicalize()
+		}
+	}
+}
+
+// Hash returns the hex encoded SHA-256 digest of the compact JSON for the
+// canonical form of this decision, which identifies the model.
+//
+func (d *Decision) Hash() string {
+	b, _ := d.Canonical().ToJSON(false)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
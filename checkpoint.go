@@ -0,0 +1,231 @@
+package id3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// A Checkpoint is a decision tree left unfinished by WithMaxDuration or
+// WithContext, with what is needed to go on learning it with Resume, perhaps
+// in another process. Each case still to be decided decides the most frequent
+// class of its rows meanwhile, so the tree can be used as it is.
+//
+type Checkpoint struct {
+	Class    string     // The class column.
+	Decision *Decision  // The tree learned so far.
+	Frontier []Frontier // The cases still to be decided.
+}
+
+// A Frontier is a case still to be decided, and the rows reaching it.
+//
+type Frontier struct {
+	Path []Condition // The conditions from the root to the case.
+	Rows []int       // The index of each row of the view reaching the case, counting from 0.
+}
+
+// WithCheckpoint has Learn and Resume fill in the checkpoint with the tree
+// they return and, if they are stopped by WithMaxDuration or WithContext, the
+// cases they left unfinished. The checkpoint has no frontier if the tree is
+// finished.
+//
+func WithCheckpoint(c *Checkpoint) Option {
+	return func(o *options) { o.resume = c }
+}
+
+// Resume goes on learning the tree of the checkpoint from the view it was
+// learned from, whose rows must be in the same order, deciding each case of
+// the frontier from its rows as Learn would have. It may be stopped again, and
+// with WithCheckpoint leaves a checkpoint to resume from again. WithValidation
+// is not used. A row given part of its weight by MissingDistribute has all of
+// it again in the cases of the frontier it reaches.
+//
+func Resume(view View, c *Checkpoint, opts ...Option) (*Decision, error) {
+	l := &learner{options: newOptions(opts), class: c.Class, began: time.Now()}
+	l.span = l.start("id3.Resume")
+	defer l.span.End()
+	if c.Decision == nil {
+		return nil, errors.New("id3: checkpoint has no decision")
+	}
+	if _, err := indexOf(view, c.Class); err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, c.Class)
+	}
+	d := c.Decision.clone()
+	view, err := l.weigh(view)
+	if err != nil {
+		return nil, err
+	}
+	data := rowsOf(l.number(view))
+	for _, f := range c.Frontier {
+		k, err := d.caseAt(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		rows := [][]string{data[0]}
+		for _, i := range f.Rows {
+			if i < 0 || i+1 >= len(data) {
+				return nil, fmt.Errorf("%w: no row %d", ErrSchemaMismatch, i)
+			}
+			rows = append(rows, data[i+1])
+		}
+		//
+		// Learn from the rows as Learn would have, without the columns already
+		// decided on the path.
+		//
+		var v View = newBaseView(rows)
+		for _, p := range f.Path {
+			if p.Test == nil {
+				v = v.Drop(p.Column)
+			}
+		}
+		if l.stop() {
+			if l.frontier != nil {
+				l.frontier[k] = f.Rows
+			}
+			continue
+		}
+		if next := l.learn(v, nil, len(f.Path)); next != nil {
+			k.Class, k.Decide = "", next
+		}
+	}
+	l.finish()
+	l.save(d)
+	l.log(slog.LevelInfo, "id3: resumed decision tree", "class", c.Class, "cases", len(c.Frontier), "nodes", l.stats.Nodes, "duration", l.stats.Duration)
+	return d, nil
+}
+
+// number returns the view with a hidden column numbering its rows from 0, if
+// there is a checkpoint to fill in, so that the rows reaching each case of the
+// frontier can be found. Otherwise it returns the view.
+//
+func (l *learner) number(view View) View {
+	if l.resume == nil {
+		return view
+	}
+	l.frontier = make(map[*Case][]int)
+	columns := view.Columns()
+	return &numberedView{parent: view, columns: append(columns[:len(columns):len(columns)], "")}
+}
+
+// pause records the case as unfinished, with the rows of the view reaching
+// it, if there is a checkpoint to fill in.
+//
+func (l *learner) pause(c *Case, view View) {
+	if l.resume == nil {
+		return
+	}
+	at := len(view.Columns()) - 1
+	var rows []int
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		i, _ := strconv.Atoi(row[at])
+		rows = append(rows, i)
+	}
+	l.frontier[c] = rows
+}
+
+// save fills in the checkpoint, if any, with the tree and its unfinished cases
+// in the order of the tree.
+//
+func (l *learner) save(d *Decision) {
+	if l.resume == nil {
+		return
+	}
+	*l.resume = Checkpoint{Class: l.class, Decision: d}
+	var walk func(d *Decision, path []Condition)
+	walk = func(d *Decision, path []Condition) {
+		for _, c := range d.Cases {
+			p := append(path[:len(path):len(path)], Condition{Column: d.Column, Value: c.Value, Test: d.Test})
+			if rows, ok := l.frontier[c]; ok {
+				l.resume.Frontier = append(l.resume.Frontier, Frontier{Path: p, Rows: rows})
+			}
+			if c.Decide != nil {
+				walk(c.Decide, p)
+			}
+		}
+	}
+	walk(d, nil)
+}
+
+// ToJSON returns this checkpoint as a JSON formatted bytes slice.
+//
+func (c *Checkpoint) ToJSON(indent bool) ([]byte, error) {
+	switch indent {
+	case false:
+		return json.Marshal(c)
+	default:
+		return json.MarshalIndent(c, "", "    ")
+	}
+}
+
+// CheckpointFromJSON translates the given JSON formatted byte slice into a
+// checkpoint.
+//
+func CheckpointFromJSON(b []byte) (*Checkpoint, error) {
+	c := new(Checkpoint)
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type numberedView struct {
+	parent  View     // Inherit from the parent view.
+	columns []string // The parent columns followed by the hidden row number.
+	next    int      // The number of the next row.
+}
+
+func (n *numberedView) Columns() []string { return n.columns }
+
+func (n *numberedView) lookup(column string) (int, bool) { return lookupIn(n.parent, column) }
+
+func (n *numberedView) First() {
+	n.parent.First()
+	n.next = 0
+}
+
+func (n *numberedView) Next() []string {
+	row := n.parent.Next()
+	if row == nil {
+		return nil
+	}
+	n.next++
+	return numbered(row, n.next-1)
+}
+
+func (n *numberedView) rows() func() []string {
+	next, number := rowsIn(n.parent), 0
+	return func() []string {
+		row := next()
+		if row == nil {
+			return nil
+		}
+		number++
+		return numbered(row, number-1)
+	}
+}
+
+// numbered returns a copy of the row followed by its number.
+//
+func numbered(row []string, number int) []string {
+	out := make([]string, len(row), len(row)+1)
+	copy(out, row)
+	return append(out, strconv.Itoa(number))
+}
+
+func (n *numberedView) Select(column, value string) View {
+	return &selectView{
+		parent: n,
+		col:    mustIndexOf(n, column),
+		val:    value,
+	}
+}
+
+func (n *numberedView) Drop(column string) View {
+	return newDropView(n, mustIndexOf(n, column))
+}
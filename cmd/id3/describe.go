@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/gbkr-com/id3"
+)
+
+// describe prints statistics for each column of a CSV file.
+//
+func describe(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("describe", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	data := flags.String("data", "", "the CSV `file` to describe, with a header row")
+	format := flags.String("format", "text", "the report `format`, text or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" {
+		fmt.Fprintln(stderr, "id3 describe: --data is required")
+		flags.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "id3 describe: unknown format '%s'\n", *format)
+		return 2
+	}
+	view, err := readCSV(*data)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 describe: %v\n", err)
+		return 1
+	}
+	stats := id3.Describe(view)
+	if *format == "json" {
+		type column struct {
+			id3.ColumnStats
+			MissingRate float64
+		}
+		columns := make([]column, len(stats))
+		for i, s := range stats {
+			columns[i] = column{s, s.MissingRate()}
+		}
+		b, err := json.MarshalIndent(columns, "", "    ")
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 describe: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(b))
+		return 0
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "column\tdistinct\tmissing\ttop\tentropy")
+	for _, s := range stats {
+		note := ""
+		if s.LikelyID {
+			note = "\tlikely an ID, drop before training"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%s (%d)\t%.3f%s\n", s.Column, s.Distinct, 100*s.MissingRate(), s.Top, s.TopCount, s.Entropy, note)
+	}
+	tw.Flush()
+	if len(stats) > 0 {
+		fmt.Fprintf(stdout, "\n%d rows\n", stats[0].Rows)
+	}
+	return 0
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gbkr-com/id3"
+)
+
+// evaluate applies a model to labelled CSV data and reports how well it does.
+//
+func evaluate(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("evaluate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	model := flags.String("model", "", "the model `file` to evaluate")
+	data := flags.String("data", "", "the labelled CSV `file` to test on, with a header row")
+	class := flags.String("class", "", "the name of the class `column`")
+	format := flags.String("format", "text", "the report `format`, text or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *model == "" || *data == "" || *class == "" {
+		fmt.Fprintln(stderr, "id3 evaluate: --model, --data and --class are required")
+		flags.Usage()
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "id3 evaluate: unknown format '%s'\n", *format)
+		return 2
+	}
+	decision, err := id3.LoadFile(*model)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 evaluate: %v\n", err)
+		return 1
+	}
+	rows, err := readRows(*data)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 evaluate: %v\n", err)
+		return 1
+	}
+	at := indexOf(rows[0], *class)
+	if at < 0 {
+		fmt.Fprintf(stderr, "id3 evaluate: no column '%s' in %s\n", *class, *data)
+		return 1
+	}
+	e := newEvaluation()
+	e.add(decision, rows[0], rows[1:], at)
+	if err := e.write(stdout, *format); err != nil {
+		fmt.Fprintf(stderr, "id3 evaluate: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// crossval estimates the accuracy of learning from CSV data by k-fold cross
+// validation. The folds are those of id3.KFold, stratified on the class, and
+// their mean and standard deviation those of id3.CrossValidate.
+//
+func crossval(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("crossval", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	data := flags.String("data", "", "the CSV `file` to learn from, with a header row")
+	class := flags.String("class", "", "the name of the class `column`")
+	k := flags.Int("k", 10, "the `number` of folds")
+	format := flags.String("format", "text", "the report `format`, text or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" || *class == "" {
+		fmt.Fprintln(stderr, "id3 crossval: --data and --class are required")
+		flags.Usage()
+		return 2
+	}
+	if *k < 2 {
+		fmt.Fprintln(stderr, "id3 crossval: -k must be at least 2")
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "id3 crossval: unknown format '%s'\n", *format)
+		return 2
+	}
+	rows, err := readRows(*data)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+		return 1
+	}
+	view, err := id3.NewView(rows)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+		return 1
+	}
+	if indexOf(rows[0], *class) < 0 {
+		fmt.Fprintf(stderr, "id3 crossval: no column '%s' in %s\n", *class, *data)
+		return 1
+	}
+	folds, err := id3.KFold(view, *k, *class)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+		return 1
+	}
+	e := newEvaluation()
+	for _, f := range folds {
+		decision, err := id3.Learn(f.Train, *class)
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+			return 1
+		}
+		m, err := id3.Evaluate(decision, f.Test, *class)
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+			return 1
+		}
+		e.merge(&evaluation{ConfusionMatrix: *m})
+		e.folds = append(e.folds, m.Accuracy())
+	}
+	if e.mean, e.stddev, err = id3.CrossValidate(view, *class, *k); err != nil {
+		fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+		return 1
+	}
+	if err := e.write(stdout, *format); err != nil {
+		fmt.Fprintf(stderr, "id3 crossval: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// An evaluation accumulates a confusion matrix of actual against predicted
+// classes, and for cross validation the accuracy of each fold.
+//
+type evaluation struct {
+	id3.ConfusionMatrix
+	folds  []float64 // The accuracy of each fold.
+	mean   float64   // The mean accuracy of the folds.
+	stddev float64   // The standard deviation of the accuracy of the folds.
+}
+
+func newEvaluation() *evaluation {
+	return &evaluation{ConfusionMatrix: id3.ConfusionMatrix{Counts: make(map[string]map[string]int)}}
+}
+
+func (e *evaluation) count(actual, predicted string, n int) {
+	if e.Counts[actual] == nil {
+		e.Counts[actual] = make(map[string]int)
+	}
+	e.Counts[actual][predicted] += n
+	e.Rows += n
+}
+
+// add decides each row and counts the result against the class at index at.
+//
+func (e *evaluation) add(decision *id3.Decision, header []string, rows [][]string, at int) {
+	for _, row := range rows {
+		predicted, err := decideRow(decision, header, row)
+		if err != nil {
+			predicted = id3.Undecided
+		}
+		e.count(row[at], predicted, 1)
+	}
+}
+
+func (e *evaluation) merge(other *evaluation) {
+	for actual, m := range other.Counts {
+		for predicted, n := range m {
+			e.count(actual, predicted, n)
+		}
+	}
+}
+
+// A classReport holds the metrics for one class.
+//
+type classReport struct {
+	Class     string  `json:"class"`
+	Support   int     `json:"support"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+func (e *evaluation) report(class string) classReport {
+	return classReport{
+		Class:     class,
+		Support:   e.Support(class),
+		Precision: e.Precision(class),
+		Recall:    e.Recall(class),
+		F1:        e.F1(class),
+	}
+}
+
+func (e *evaluation) write(w io.Writer, format string) error {
+	classes := e.Classes()
+	var reports []classReport
+	for _, c := range classes {
+		if c != id3.Undecided {
+			reports = append(reports, e.report(c))
+		}
+	}
+	if format == "json" {
+		v := struct {
+			Rows      int                       `json:"rows"`
+			Accuracy  float64                   `json:"accuracy"`
+			Folds     []float64                 `json:"folds,omitempty"`
+			Mean      *float64                  `json:"foldMean,omitempty"`
+			Stddev    *float64                  `json:"foldStddev,omitempty"`
+			Classes   []classReport             `json:"classes"`
+			Confusion map[string]map[string]int `json:"confusion"`
+		}{
+			Rows:      e.Rows,
+			Accuracy:  e.Accuracy(),
+			Folds:     e.folds,
+			Classes:   reports,
+			Confusion: e.Counts,
+		}
+		if len(e.folds) > 0 {
+			v.Mean, v.Stddev = &e.mean, &e.stddev
+		}
+		b, err := json.MarshalIndent(v, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	fmt.Fprintf(w, "rows: %d\naccuracy: %.4f\n", e.Rows, e.Accuracy())
+	if len(e.folds) > 0 {
+		fmt.Fprintf(w, "folds: %d, mean %.4f, stddev %.4f\n", len(e.folds), e.mean, e.stddev)
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "class\tprecision\trecall\tf1\tsupport")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%.4f\t%d\n", r.Class, r.Precision, r.Recall, r.F1, r.Support)
+	}
+	tw.Flush()
+	//
+	// The confusion matrix has a row for each actual class and a column for
+	// each predicted class.
+	//
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "actual\\predicted\t"+strings.Join(classes, "\t"))
+	for _, actual := range classes {
+		if actual == id3.Undecided {
+			continue
+		}
+		fmt.Fprint(tw, actual)
+		for _, predicted := range classes {
+			fmt.Fprintf(tw, "\t%d", e.Counts[actual][predicted])
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// readRows reads all of a CSV file, which must have a header row.
+//
+func readRows(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s has no header row", path)
+	}
+	return rows, nil
+}
+
+func indexOf(slice []string, x string) int {
+	for i, s := range slice {
+		if s == x {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gbkr-com/id3"
+)
+
+// exporters convert a model to each format, given the export flags.
+//
+var exporters = map[string]func(d *id3.Decision, o exportOptions) ([]byte, error){
+	"dot": func(d *id3.Decision, o exportOptions) ([]byte, error) {
+		return d.ToDOT(o.counts)
+	},
+	"rules": func(d *id3.Decision, o exportOptions) ([]byte, error) {
+		var b bytes.Buffer
+		err := d.WriteRules(&b, o.class)
+		return b.Bytes(), err
+	},
+	"sql": func(d *id3.Decision, o exportOptions) ([]byte, error) {
+		dialect, ok := map[string]id3.SQLDialect{"ansi": id3.ANSISQL, "mysql": id3.MySQL, "sqlserver": id3.SQLServer}[o.dialect]
+		if !ok {
+			return nil, fmt.Errorf("unknown SQL dialect '%s'", o.dialect)
+		}
+		s, err := d.ToSQL(dialect)
+		return []byte(s + "\n"), err
+	},
+	"mermaid": func(d *id3.Decision, o exportOptions) ([]byte, error) {
+		return d.ToMermaid()
+	},
+	"go": func(d *id3.Decision, o exportOptions) ([]byte, error) {
+		return d.ToGo(o.pkg, o.function)
+	},
+}
+
+type exportOptions struct {
+	class    string
+	counts   bool
+	dialect  string
+	pkg      string
+	function string
+}
+
+// export writes a model in another representation.
+//
+func export(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	model := flags.String("model", "", "the model `file` to export")
+	format := flags.String("format", "", "the `format` to export to: dot, rules, sql, mermaid or go")
+	out := flags.String("out", "", "the `file` to write, by default stdout")
+	var o exportOptions
+	flags.StringVar(&o.class, "class", "class", "the `name` of the class column, for rules")
+	flags.BoolVar(&o.counts, "counts", false, "add the class frequencies to leaves, for dot")
+	flags.StringVar(&o.dialect, "dialect", "ansi", "the SQL `dialect`: ansi, mysql or sqlserver")
+	flags.StringVar(&o.pkg, "package", "main", "the Go `package` name, for go")
+	flags.StringVar(&o.function, "func", "Decide", "the Go function `name`, for go")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *model == "" || *format == "" {
+		fmt.Fprintln(stderr, "id3 export: --model and --format are required")
+		flags.Usage()
+		return 2
+	}
+	exporter, ok := exporters[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "id3 export: unknown format '%s'\n", *format)
+		return 2
+	}
+	decision, err := id3.LoadFile(*model)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 export: %v\n", err)
+		return 1
+	}
+	b, err := exporter(decision, o)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 export: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		_, err = stdout.Write(b)
+	} else {
+		err = os.WriteFile(*out, b, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 export: %v\n", err)
+		return 1
+	}
+	return 0
+}
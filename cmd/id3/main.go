@@ -0,0 +1,73 @@
+// Command id3 learns, applies and inspects ID3 decision trees from CSV data.
+//
+// Usage:
+//
+//	id3 <command> [flags]
+//
+// The commands are:
+//
+//	describe summarise the columns of CSV data
+//	train    learn a decision tree from CSV data
+//	predict  add the decisions of a model to CSV data
+//	score    predict for large CSV files, resuming if interrupted
+//	evaluate measure the accuracy of a model on labelled CSV data
+//	crossval estimate the accuracy of learning from CSV data
+//	export   convert a model to DOT, rules, SQL, Mermaid or Go
+//	serve    answer prediction requests over HTTP
+//
+// Run "id3 <command> -h" for the flags of each command.
+//
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// A command runs with the arguments following its name, and returns the
+// process exit code.
+//
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{"describe", "summarise the columns of CSV data", describe},
+	{"train", "learn a decision tree from CSV data", train},
+	{"predict", "add the decisions of a model to CSV data", predict},
+	{"score", "predict for large CSV files, resuming if interrupted", score},
+	{"evaluate", "measure the accuracy of a model on labelled CSV data", evaluate},
+	{"crossval", "estimate the accuracy of learning from CSV data", crossval},
+	{"export", "convert a model to DOT, rules, SQL, Mermaid or Go", export},
+	{"serve", "answer prediction requests over HTTP", serve},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "id3: unknown command '%s'\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: id3 <command> [flags]")
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gbkr-com/id3"
+)
+
+const example = `outlook,temperature,humidity,wind,play
+sunny,hot,high,weak,no
+sunny,hot,high,strong,no
+overcast,hot,high,weak,yes
+rain,mild,high,weak,yes
+rain,cool,normal,weak,yes
+rain,cool,normal,strong,no
+overcast,cool,normal,strong,yes
+sunny,mild,high,weak,no
+sunny,cool,normal,weak,yes
+rain,mild,normal,weak,yes
+sunny,mild,normal,strong,yes
+overcast,mild,high,strong,yes
+overcast,hot,normal,weak,yes
+rain,mild,high,strong,no
+`
+
+// setup writes the example data to a temporary directory, returning the path.
+//
+func setup(t *testing.T) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "play.csv")
+	if err := os.WriteFile(path, []byte(example), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if run(nil, &stdout, &stderr) != 2 || !strings.Contains(stderr.String(), "train") {
+		t.Error()
+	}
+	if run([]string{"nonsense"}, &stdout, &stderr) != 2 {
+		t.Error()
+	}
+}
+
+func TestTrain(t *testing.T) {
+	data := setup(t)
+	out := filepath.Join(filepath.Dir(data), "model.json")
+	var stdout, stderr bytes.Buffer
+	if run([]string{"train", "--data", data, "--class", "play", "--out", out}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	d, err := id3.LoadFile(out)
+	if err != nil || d.Column != "outlook" {
+		t.Error()
+	}
+	stderr.Reset()
+	if run([]string{"train", "--data", data, "--class", "play", "--stats", "--out", out}, &stdout, &stderr) != 0 || !strings.Contains(stderr.String(), "3 decisions, 5 leaves") {
+		t.Error(stderr.String())
+	}
+	if run([]string{"train", "--data", data, "--class", "play", "--external", "--out", out}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if e, _ := id3.LoadFile(out); !e.Equivalent(d, false) {
+		t.Error(e)
+	}
+	if run([]string{"train", "--data", data, "--class", "play", "--exclude", "outlook,humidity", "--out", out}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if e, _ := id3.LoadFile(out); e.Column != "wind" {
+		t.Error(e)
+	}
+	//
+	// The criterion, limits and pruning.
+	//
+	if run([]string{"train", "--data", data, "--class", "play", "--criterion", "ratio", "--out", out}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	view, _ := id3.Read(strings.NewReader(example))
+	ratio, _ := id3.Learn(view, "play", id3.WithCriterion(id3.GainRatio))
+	if e, _ := id3.LoadFile(out); !e.Equivalent(ratio, false) {
+		t.Error(e)
+	}
+	if run([]string{"train", "--data", data, "--class", "play", "--criterion", "gini"}, &stdout, &stderr) != 2 {
+		t.Error()
+	}
+	for _, flag := range []string{"--max-depth=1", "--min-samples-split=6"} {
+		if run([]string{"train", "--data", data, "--class", "play", flag, "--out", out}, &stdout, &stderr) != 0 {
+			t.Error(stderr.String())
+		}
+		if e, _ := id3.LoadFile(out); e.Column != "outlook" || e.Cases[0].Decide != nil || e.Cases[1].Decide != nil {
+			t.Error(flag, e)
+		}
+	}
+	if run([]string{"train", "--data", data, "--class", "play", "--min-samples-leaf", "6", "--out", out}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if e, _ := id3.LoadFile(out); e.Column == "outlook" {
+		t.Error(e)
+	}
+	pruning := filepath.Join(filepath.Dir(data), "pruning.csv")
+	os.WriteFile(pruning, []byte(strings.ReplaceAll(example, ",no\n", ",yes\n")), 0644)
+	if run([]string{"train", "--data", data, "--class", "play", "--prune", pruning, "--out", out}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if e, _ := id3.LoadFile(out); e.Equivalent(d, false) {
+		t.Error(e)
+	}
+	if run([]string{"train", "--data", data, "--class", "play", "--prune", pruning + ".missing"}, &stdout, &stderr) != 1 {
+		t.Error()
+	}
+	noOutlook := filepath.Join(filepath.Dir(data), "nooutlook.csv")
+	os.WriteFile(noOutlook, []byte("temperature,play\nhot,no\n"), 0644)
+	if run([]string{"train", "--data", data, "--class", "play", "--prune", noOutlook}, &stdout, &stderr) != 1 {
+		t.Error()
+	}
+	if run([]string{"train", "--data", data}, &stdout, &stderr) != 2 {
+		t.Error()
+	}
+	if run([]string{"train", "--data", data + ".missing", "--class", "play"}, &stdout, &stderr) != 1 {
+		t.Error()
+	}
+}
+
+func TestPredict(t *testing.T) {
+	data := setup(t)
+	dir := filepath.Dir(data)
+	model := filepath.Join(dir, "model.json")
+	var stdout, stderr bytes.Buffer
+	run([]string{"train", "--data", data, "--class", "play", "--out", model}, &stdout, &stderr)
+	if run([]string{"predict", "--model", model, "--data", data, "--probabilities"}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if lines[0] != "outlook,temperature,humidity,wind,play,prediction,prediction_no,prediction_yes" {
+		t.Error(lines[0])
+	}
+	if lines[1] != "sunny,hot,high,weak,no,no,1,0" {
+		t.Error(lines[1])
+	}
+	//
+	// Unseen values are reported by row.
+	//
+	unseen := filepath.Join(dir, "unseen.csv")
+	os.WriteFile(unseen, []byte("outlook,humidity,wind\nsunny,high,weak\nfoggy,high,weak\n"), 0644)
+	out := filepath.Join(dir, "scored.csv")
+	stderr.Reset()
+	if run([]string{"predict", "--model", model, "--data", unseen, "--out", out}, &stdout, &stderr) != 1 {
+		t.Error()
+	}
+	if !strings.Contains(stderr.String(), "row 3:") {
+		t.Error(stderr.String())
+	}
+	b, _ := os.ReadFile(out)
+	if string(b) != "outlook,humidity,wind,prediction\nsunny,high,weak,no\nfoggy,high,weak,\n" {
+		t.Error(string(b))
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	data := setup(t)
+	model := filepath.Join(filepath.Dir(data), "model.json")
+	var stdout, stderr bytes.Buffer
+	run([]string{"train", "--data", data, "--class", "play", "--out", model}, &stdout, &stderr)
+	if run([]string{"evaluate", "--model", model, "--data", data, "--class", "play"}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "accuracy: 1.0000") {
+		t.Error(stdout.String())
+	}
+	stdout.Reset()
+	if run([]string{"crossval", "--data", data, "--class", "play", "-k", "7", "--format", "json"}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	var report struct {
+		Rows     int
+		Folds    []float64
+		FoldMean float64
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Error(err)
+	}
+	if report.Rows != 14 || len(report.Folds) != 7 {
+		t.Error(report)
+	}
+	view, _ := id3.Read(strings.NewReader(example))
+	if mean, _, _ := id3.CrossValidate(view, "play", 7); report.FoldMean != mean {
+		t.Error(report.FoldMean, mean)
+	}
+	if run([]string{"crossval", "--data", data, "--class", "play", "-k", "1"}, &stdout, &stderr) != 2 {
+		t.Error()
+	}
+}
+
+func TestExport(t *testing.T) {
+	data := setup(t)
+	model := filepath.Join(filepath.Dir(data), "model.json")
+	var stdout, stderr bytes.Buffer
+	run([]string{"train", "--data", data, "--class", "play", "--out", model}, &stdout, &stderr)
+	for format, want := range map[string]string{
+		"dot":     "digraph id3 {",
+		"rules":   "IF outlook=overcast THEN play=yes",
+		"sql":     "CASE \"outlook\"",
+		"mermaid": "flowchart TD",
+		"go":      "func Decide(row map[string]string) (string, bool) {",
+	} {
+		stdout.Reset()
+		if run([]string{"export", "--model", model, "--format", format, "--class", "play"}, &stdout, &stderr) != 0 {
+			t.Error(format, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), want) {
+			t.Error(format, stdout.String())
+		}
+	}
+	if run([]string{"export", "--model", model, "--format", "xml"}, &stdout, &stderr) != 2 {
+		t.Error()
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	data := setup(t)
+	var stdout, stderr bytes.Buffer
+	if run([]string{"describe", "--data", data}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "outlook") || !strings.Contains(stdout.String(), "14 rows") {
+		t.Error(stdout.String())
+	}
+}
+
+func TestServe(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if run([]string{"serve"}, &stdout, &stderr) != 2 {
+		t.Error()
+	}
+	if run([]string{"serve", "--model", filepath.Join(t.TempDir(), "none.json")}, &stdout, &stderr) != 1 {
+		t.Error()
+	}
+}
+
+func TestScore(t *testing.T) {
+	data := setup(t)
+	dir := filepath.Dir(data)
+	model := filepath.Join(dir, "model.json")
+	var stdout, stderr bytes.Buffer
+	run([]string{"train", "--data", data, "--class", "play", "--out", model}, &stdout, &stderr)
+	out := filepath.Join(dir, "scored.csv")
+	if run([]string{"score", "--model", model, "--data", data, "--out", out, "--chunk", "4"}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	want, _ := os.ReadFile(out)
+	if strings.Count(string(want), "\n") != 15 {
+		t.Error(string(want))
+	}
+	//
+	// Resume from a checkpoint after 8 rows, with a partly written row after
+	// that.
+	//
+	lines := strings.SplitAfter(string(want), "\n")
+	partial := strings.Join(lines[:9], "")
+	os.WriteFile(out, []byte(partial+"sunny,ho"), 0644)
+	os.WriteFile(out+".checkpoint", []byte(fmt.Sprintf(`{"model": "%s", "rows": 8, "offset": %d}`, hash(t, model), len(partial))), 0644)
+	stderr.Reset()
+	if run([]string{"score", "--model", model, "--data", data, "--out", out, "--quiet"}, &stdout, &stderr) != 0 {
+		t.Error(stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "resuming after row 8") {
+		t.Error(stderr.String())
+	}
+	if got, _ := os.ReadFile(out); string(got) != string(want) {
+		t.Error(string(got))
+	}
+	if _, err := os.Stat(out + ".checkpoint"); err == nil {
+		t.Error()
+	}
+}
+
+func hash(t *testing.T, path string) string {
+	d, err := id3.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.Hash()
+}
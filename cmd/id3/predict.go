@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/gbkr-com/id3"
+)
+
+// predict reads a CSV file a row at a time and writes it out with the decided
+// class in a new column, and optionally the class probabilities. Rows without
+// a decision are reported to stderr and left blank, and make the exit code 1.
+//
+func predict(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("predict", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	model := flags.String("model", "", "the model `file` to apply")
+	data := flags.String("data", "", "the CSV `file` to score, with a header row")
+	out := flags.String("out", "", "the CSV `file` to write, by default stdout")
+	column := flags.String("column", "prediction", "the `name` of the new column")
+	probabilities := flags.Bool("probabilities", false, "add a column for the probability of each class")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *model == "" || *data == "" {
+		fmt.Fprintln(stderr, "id3 predict: --model and --data are required")
+		flags.Usage()
+		return 2
+	}
+	decision, err := id3.LoadFile(*model)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 predict: %v\n", err)
+		return 1
+	}
+	in, err := os.Open(*data)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 predict: %v\n", err)
+		return 1
+	}
+	defer in.Close()
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 predict: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	failed, err := predictRows(decision, csv.NewReader(in), csv.NewWriter(w), *column, *probabilities, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 predict: %v\n", err)
+		return 1
+	}
+	if failed > 0 {
+		fmt.Fprintf(stderr, "id3 predict: %d rows without a decision\n", failed)
+		return 1
+	}
+	return 0
+}
+
+// predictRows copies rows from r to w, adding the predictions, and returns the
+// number of rows without a decision.
+//
+func predictRows(decision *id3.Decision, r *csv.Reader, w *csv.Writer, column string, probabilities bool, stderr io.Writer) (int, error) {
+	header, err := r.Read()
+	if err != nil {
+		return 0, err
+	}
+	classes := decision.Classes()
+	out := append(header[:len(header):len(header)], column)
+	if probabilities {
+		for _, c := range classes {
+			out = append(out, column+"_"+c)
+		}
+	}
+	if err := w.Write(out); err != nil {
+		return 0, err
+	}
+	failed := 0
+	for n := 2; ; n++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return failed, err
+		}
+		class, err := decideRow(decision, header, row)
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 predict: row %d: %v\n", n, err)
+			failed++
+		}
+		out := append(row[:len(row):len(row)], class)
+		if probabilities {
+			p := decision.Probabilities(header, row)
+			for _, c := range classes {
+				if p == nil {
+					out = append(out, "")
+				} else {
+					out = append(out, strconv.FormatFloat(p[c], 'g', 4, 64))
+				}
+			}
+		}
+		if err := w.Write(out); err != nil {
+			return failed, err
+		}
+	}
+	w.Flush()
+	return failed, w.Error()
+}
+
+// decideRow applies the decision to a single row.
+//
+func decideRow(decision *id3.Decision, header, row []string) (string, error) {
+	result, err := decision.Decide([][]string{header, row})
+	if err != nil {
+		return "", err
+	}
+	return result[0], nil
+}
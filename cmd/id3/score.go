@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gbkr-com/id3"
+)
+
+// A checkpoint records how far scoring has got, so that an interrupted run can
+// resume. Rows after Offset in the output may be incomplete, so are discarded.
+//
+type checkpoint struct {
+	Model  string `json:"model"`  // The hash of the model.
+	Rows   int    `json:"rows"`   // The number of data rows scored.
+	Offset int64  `json:"offset"` // The length of the output after those rows.
+	Failed int    `json:"failed"` // The number of those rows without a decision.
+}
+
+// score is like predict for very large files. It works through the data in
+// chunks, after each of which it writes a checkpoint file. If the command is
+// run again after an interruption, it resumes from the checkpoint, which is
+// removed once the whole file is scored.
+//
+func score(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("score", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	model := flags.String("model", "", "the model `file` to apply")
+	data := flags.String("data", "", "the CSV `file` to score, with a header row")
+	out := flags.String("out", "", "the CSV `file` to write")
+	column := flags.String("column", "prediction", "the `name` of the new column")
+	chunk := flags.Int("chunk", 100000, "the `number` of rows between checkpoints")
+	quiet := flags.Bool("quiet", false, "do not show progress")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *model == "" || *data == "" || *out == "" {
+		fmt.Fprintln(stderr, "id3 score: --model, --data and --out are required")
+		flags.Usage()
+		return 2
+	}
+	if *chunk < 1 {
+		fmt.Fprintln(stderr, "id3 score: --chunk must be at least 1")
+		return 2
+	}
+	decision, err := id3.LoadFile(*model)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 score: %v\n", err)
+		return 1
+	}
+	s := &scorer{
+		decision:   decision,
+		column:     *column,
+		chunk:      *chunk,
+		checkpoint: *out + ".checkpoint",
+		stderr:     stderr,
+	}
+	if !*quiet {
+		s.progress = stderr
+	}
+	if err := s.run(*data, *out); err != nil {
+		fmt.Fprintf(stderr, "id3 score: %v\n", err)
+		return 1
+	}
+	if s.at.Failed > 0 {
+		fmt.Fprintf(stderr, "id3 score: %d rows without a decision\n", s.at.Failed)
+		return 1
+	}
+	return 0
+}
+
+type scorer struct {
+	decision   *id3.Decision
+	column     string
+	chunk      int
+	checkpoint string    // The checkpoint file name.
+	stderr     io.Writer // For row errors.
+	progress   io.Writer // For the progress bar, if not nil.
+	at         checkpoint
+	shown      time.Time // When progress was last shown.
+}
+
+func (s *scorer) run(data, out string) error {
+	hash := s.decision.Hash()
+	resume, err := readCheckpoint(s.checkpoint)
+	if err != nil {
+		return err
+	}
+	if resume != nil && resume.Model != hash {
+		return fmt.Errorf("%s is for a different model; remove it to start again", s.checkpoint)
+	}
+	in, err := os.Open(data)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	counter := &countingReader{r: in}
+	r := csv.NewReader(counter)
+	header, err := r.Read()
+	if err != nil {
+		return err
+	}
+	var f *os.File
+	if resume != nil {
+		//
+		// Discard any output after the checkpoint, and skip the rows already
+		// scored.
+		//
+		f, err = os.OpenFile(out, os.O_RDWR, 0644)
+		if err == nil {
+			err = f.Truncate(resume.Offset)
+		}
+		if err == nil {
+			_, err = f.Seek(resume.Offset, io.SeekStart)
+		}
+		if err != nil {
+			return err
+		}
+		for i := 0; i < resume.Rows; i++ {
+			if _, err := r.Read(); err != nil {
+				f.Close()
+				return fmt.Errorf("%s has fewer rows than the checkpoint", data)
+			}
+		}
+		s.at = *resume
+		fmt.Fprintf(s.stderr, "id3 score: resuming after row %d\n", s.at.Rows)
+	} else {
+		s.at = checkpoint{Model: hash}
+		f, err = os.Create(out)
+		if err != nil {
+			return err
+		}
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if resume == nil {
+		if err := w.Write(append(header[:len(header):len(header)], s.column)); err != nil {
+			return err
+		}
+	}
+	for {
+		done, err := s.scoreChunk(header, r, w)
+		if err != nil {
+			return err
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		if s.at.Offset, err = f.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+		s.show(counter.n, info.Size(), done)
+		if done {
+			break
+		}
+		if err := writeCheckpoint(s.checkpoint, s.at); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(s.checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return f.Close()
+}
+
+// scoreChunk scores up to a chunk of rows, returning true at the end of the
+// data.
+//
+func (s *scorer) scoreChunk(header []string, r *csv.Reader, w *csv.Writer) (bool, error) {
+	for i := 0; i < s.chunk; i++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		s.at.Rows++
+		class, err := decideRow(s.decision, header, row)
+		if err != nil {
+			fmt.Fprintf(s.stderr, "id3 score: row %d: %v\n", s.at.Rows+1, err)
+			s.at.Failed++
+		}
+		if err := w.Write(append(row[:len(row):len(row)], class)); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// show draws a progress bar for the fraction of the input read, at most once a
+// second unless done.
+//
+func (s *scorer) show(read, size int64, done bool) {
+	if s.progress == nil || (!done && time.Since(s.shown) < time.Second) {
+		return
+	}
+	s.shown = time.Now()
+	fraction := 1.0
+	if size > 0 && !done {
+		fraction = float64(read) / float64(size)
+	}
+	const width = 40
+	n := int(fraction * width)
+	fmt.Fprintf(s.progress, "\r[%s%s] %3.0f%% %d rows", strings.Repeat("=", n), strings.Repeat(" ", width-n), 100*fraction, s.at.Rows)
+	if done {
+		fmt.Fprintln(s.progress)
+	}
+}
+
+func readCheckpoint(path string) (*checkpoint, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := new(checkpoint)
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// writeCheckpoint replaces the checkpoint file atomically, so that an
+// interruption leaves either the old or the new checkpoint.
+//
+func writeCheckpoint(path string, c checkpoint) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// countingReader counts the bytes read through it, for progress.
+//
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
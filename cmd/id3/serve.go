@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gbkr-com/id3"
+	"github.com/gbkr-com/id3/server"
+)
+
+// serve answers prediction requests over HTTP until interrupted, then waits
+// for requests in progress to complete. With TLS, gRPC calls are answered too.
+//
+func serve(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	model := flags.String("model", "", "the model `file` to serve")
+	addr := flags.String("addr", ":8080", "the `address` to listen on")
+	cert := flags.String("cert", "", "the TLS certificate `file`, to serve HTTPS and gRPC")
+	key := flags.String("key", "", "the TLS private key `file`")
+	workers := flags.Int("workers", 0, "the most prediction requests worked on at once, or 0 no limit")
+	queue := flags.Int("queue", 0, "the most prediction requests waiting for a worker")
+	timeout := flags.Duration("timeout", 0, "the longest a prediction request may take, or 0 no limit")
+	verbose := flags.Bool("v", false, "log fallbacks, unknown values and rejected requests")
+	reload := flags.Duration("reload", 0, "how often to check the model file for changes, or 0 never")
+	grace := flags.Duration("shutdown-timeout", 10*time.Second, "the longest to wait for requests in progress on shutdown")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *model == "" {
+		fmt.Fprintln(stderr, "id3 serve: --model is required")
+		flags.Usage()
+		return 2
+	}
+	if (*cert == "") != (*key == "") {
+		fmt.Fprintln(stderr, "id3 serve: --cert and --key must be given together")
+		return 2
+	}
+	decision, err := id3.LoadFile(*model)
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 serve: %v\n", err)
+		return 1
+	}
+	if err := decision.Validate(nil); err != nil {
+		fmt.Fprintf(stderr, "id3 serve: %v\n", err)
+		return 1
+	}
+	handler := server.New(decision)
+	handler.MaxConcurrent, handler.MaxQueue, handler.Timeout = *workers, *queue, *timeout
+	if *verbose {
+		handler.Logger = slog.New(slog.NewTextHandler(stderr, nil))
+	}
+	srv := &http.Server{Addr: *addr, Handler: handler}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *reload > 0 {
+		w := server.NewModelWatcher(*model, *reload, func(d *id3.Decision) {
+			handler.SetDecision(d)
+			fmt.Fprintf(stdout, "id3 serve: reloaded %s\n", *model)
+		})
+		w.OnError = func(err error) {
+			fmt.Fprintf(stderr, "id3 serve: reload: %v\n", err)
+		}
+		go w.Run(ctx)
+	}
+	failed := make(chan error, 1)
+	go func() {
+		var err error
+		if *cert != "" {
+			err = srv.ListenAndServeTLS(*cert, *key)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+	fmt.Fprintf(stdout, "id3 serve: serving %s on %s\n", *model, *addr)
+	select {
+	case err := <-failed:
+		fmt.Fprintf(stderr, "id3 serve: %v\n", err)
+		return 1
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), *grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		fmt.Fprintf(stderr, "id3 serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gbkr-com/id3"
+)
+
+// train learns a decision tree from a CSV file and saves it in the format given
+// by the output file extension, or writes JSON to stdout.
+//
+func train(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("train", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	data := flags.String("data", "", "the CSV `file` to learn from, with a header row")
+	class := flags.String("class", "", "the name of the class `column`")
+	out := flags.String("out", "", "the model `file` to write, by default JSON to stdout")
+	verbose := flags.Bool("v", false, "log warnings about the data and each split")
+	external := flags.Bool("external", false, "learn by reading the file once per tree level, for files larger than memory")
+	parallel := flags.Int("parallel", 1, "the most `columns` to evaluate at once")
+	exclude := flags.String("exclude", "", "a comma separated `list` of columns not to decide on")
+	validation := flags.String("validation", "", "a CSV `file` of rows on which to stop growing the tree early")
+	budget := flags.Duration("max-duration", 0, "stop expanding the tree after this `duration`, if not zero")
+	stats := flags.Bool("stats", false, "report the size of the tree and the time and memory taken to stderr")
+	criterion := flags.String("criterion", "gain", "how to choose each split, by information `gain` or by gain ratio")
+	depth := flags.Int("max-depth", 0, "the most `decisions` from the root to a leaf, if not zero")
+	split := flags.Int("min-samples-split", 0, "the fewest `rows` to decide on below the root")
+	leaf := flags.Int("min-samples-leaf", 0, "the fewest `rows` for each case of a decision")
+	prune := flags.String("prune", "", "a CSV `file` of rows to prune the tree against by reduced error")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *data == "" || *class == "" {
+		fmt.Fprintln(stderr, "id3 train: --data and --class are required")
+		flags.Usage()
+		return 2
+	}
+	var measure id3.Criterion
+	switch *criterion {
+	case "gain":
+		measure = id3.InformationGain
+	case "ratio":
+		measure = id3.GainRatio
+	default:
+		fmt.Fprintf(stderr, "id3 train: --criterion must be gain or ratio, not '%s'\n", *criterion)
+		return 2
+	}
+	var s id3.Stats
+	opts := []id3.Option{
+		id3.WithParallelism(*parallel),
+		id3.WithMaxDuration(*budget),
+		id3.WithCriterion(measure),
+		id3.WithMaxDepth(*depth),
+		id3.WithMinSamplesSplit(*split),
+		id3.WithMinSamplesLeaf(*leaf),
+	}
+	if *stats {
+		opts = append(opts, id3.WithStats(&s))
+	}
+	if *verbose {
+		opts = append(opts, id3.WithLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+	if *exclude != "" {
+		opts = append(opts, id3.WithExcluded(strings.Split(*exclude, ",")...))
+	}
+	if *validation != "" {
+		valid, err := readCSV(*validation)
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 train: %v\n", err)
+			return 1
+		}
+		opts = append(opts, id3.WithValidation(valid))
+	}
+	var decision *id3.Decision
+	var err error
+	if *external {
+		decision, err = learnExternal(*data, *class, opts...)
+	} else {
+		var view id3.View
+		if view, err = readCSV(*data, opts...); err == nil {
+			decision, err = id3.Learn(view, *class, opts...)
+		}
+	}
+	if err == nil && *prune != "" {
+		decision, err = pruneWith(decision, *prune, *class)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "id3 train: %v\n", err)
+		return 1
+	}
+	if *stats {
+		fmt.Fprintf(stderr, "id3 train: %d decisions, %d leaves, depth %d, %d rows counted, %v, peak heap %d bytes\n",
+			s.Nodes, s.Leaves, s.Depth, s.Rows, s.Duration, s.PeakMemory)
+	}
+	if *out == "" {
+		b, err := decision.ToJSON(true)
+		if err != nil {
+			fmt.Fprintf(stderr, "id3 train: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(b))
+		return 0
+	}
+	if err := id3.SaveFile(*out, decision); err != nil {
+		fmt.Fprintf(stderr, "id3 train: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func readCSV(path string, opts ...id3.Option) (id3.View, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return id3.Read(f, opts...)
+}
+
+// pruneWith returns the decision pruned against the rows of the CSV file, which
+// must have the class column and those the decision decides on.
+//
+func pruneWith(decision *id3.Decision, path, class string) (*id3.Decision, error) {
+	view, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	return id3.Prune(decision, view, class)
+}
+
+func learnExternal(path, class string, opts ...id3.Option) (*id3.Decision, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return id3.LearnExternal(f, class, opts...)
+}
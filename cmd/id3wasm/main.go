@@ -0,0 +1,127 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command id3wasm makes decision trees usable from JavaScript, for example to
+// classify form input in a browser without a round trip to a server. Build it
+// with
+//
+//	GOOS=js GOARCH=wasm go build -o id3.wasm ./cmd/id3wasm
+//
+// and load it with the wasm_exec.js that comes with Go. It defines a global
+// id3 object with one function:
+//
+//	id3.load(json)
+//
+// which takes a model in JSON and returns an object with the methods
+//
+//	decide(record)        // {class: "no"} or {error: "..."}
+//	probabilities(record) // {no: 0.6, yes: 0.4}, or null if not known
+//	columns()             // the columns a record must have
+//	classes()             // the classes decided
+//
+// or an object with just an error property if the model cannot be read. A
+// record is an object mapping column names to string values.
+//
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/gbkr-com/id3"
+)
+
+func main() {
+	js.Global().Set("id3", map[string]interface{}{
+		"load": js.FuncOf(load),
+	})
+	//
+	// Keep the program running for the functions to be called.
+	//
+	select {}
+}
+
+func load(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return failure("load takes a model in JSON")
+	}
+	d, err := id3.FromJSON([]byte(args[0].String()))
+	if err != nil {
+		return failure(err.Error())
+	}
+	if err := d.Validate(nil); err != nil {
+		return failure(err.Error())
+	}
+	columns := d.Columns()
+	return map[string]interface{}{
+		"decide": js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			row, err := record(columns, args)
+			if err != nil {
+				return failure(err.Error())
+			}
+			class, err := decide(d, columns, row)
+			if err != nil {
+				return failure(err.Error())
+			}
+			return map[string]interface{}{"class": class}
+		}),
+		"probabilities": js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			row, err := record(columns, args)
+			if err != nil {
+				return failure(err.Error())
+			}
+			p := d.Probabilities(columns, row)
+			if p == nil {
+				return nil
+			}
+			o := make(map[string]interface{}, len(p))
+			for class, f := range p {
+				o[class] = f
+			}
+			return o
+		}),
+		"columns": js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			return strings(columns)
+		}),
+		"classes": js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			return strings(d.Classes())
+		}),
+	}
+}
+
+// record returns the values of the columns from the record object.
+//
+func record(columns []string, args []js.Value) ([]string, error) {
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return nil, fmt.Errorf("expected a record object")
+	}
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		v := args[0].Get(c)
+		if v.Type() != js.TypeString {
+			return nil, fmt.Errorf("the record has no string value for '%s'", c)
+		}
+		row[i] = v.String()
+	}
+	return row, nil
+}
+
+func decide(d *id3.Decision, columns, row []string) (string, error) {
+	result, err := d.Decide([][]string{columns, row})
+	if err != nil {
+		return "", err
+	}
+	return result[0], nil
+}
+
+func failure(message string) map[string]interface{} {
+	return map[string]interface{}{"error": message}
+}
+
+func strings(s []string) []interface{} {
+	a := make([]interface{}, len(s))
+	for i, x := range s {
+		a[i] = x
+	}
+	return a
+}
@@ -0,0 +1,112 @@
+package id3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Committee is a set of decision trees, perhaps learned from different slices of
+// data, that decide by weighted vote.
+//
+type Committee struct {
+	Trees   []*Decision
+	Weights []float64
+}
+
+// BuildCommittee returns a committee of the trees with the given weights, which
+// must be non-negative. If weights is nil every tree has a weight of one.
+//
+func BuildCommittee(trees []*Decision, weights []float64) (*Committee, error) {
+	if len(trees) == 0 {
+		return nil, errors.New("id3: a committee needs at least one tree")
+	}
+	if weights == nil {
+		weights = make([]float64, len(trees))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(trees) {
+		return nil, fmt.Errorf("id3: %d weights for %d trees", len(weights), len(trees))
+	}
+	for i, t := range trees {
+		if t == nil {
+			return nil, fmt.Errorf("id3: tree %d is nil", i)
+		}
+		if weights[i] < 0 {
+			return nil, fmt.Errorf("id3: tree %d has a negative weight", i)
+		}
+	}
+	return &Committee{Trees: trees, Weights: weights}, nil
+}
+
+// Decide on the given CSV conformant data. The first row must be the column
+// headings. Each tree votes for its class with its weight, and the class with
+// the greatest total wins, with ties going to the least class value. Trees
+// without a rule for a row abstain, and if all do it fails with
+// ErrNoMatchingCase.
+//
+func (c *Committee) Decide(data [][]string) (result []string, err error) {
+	index := make(map[string]int)
+	for i := range data {
+		if i == 0 {
+			continue
+		}
+		class, _ := c.vote(data[i], data[0], index)
+		if class == "" {
+			return nil, fmt.Errorf("%w: no tree has a rule for row %d", ErrNoMatchingCase, i)
+		}
+		result = append(result, class)
+	}
+	return
+}
+
+// vote returns the winning class for the row and the total weight for each
+// class.
+//
+func (c *Committee) vote(row, columns []string, index map[string]int) (string, map[string]float64) {
+	votes := make(map[string]float64)
+	for i, t := range c.Trees {
+		if class, ok := t.classify(row, columns, index, nil); ok {
+			votes[class] += c.Weights[i]
+		}
+	}
+	winner, max := "", -1.0
+	for class, v := range votes {
+		if v > max || (v == max && class < winner) {
+			winner, max = class, v
+		}
+	}
+	return winner, votes
+}
+
+// ToJSON returns this committee as a JSON formatted bytes slice.
+//
+func (c *Committee) ToJSON(indent bool) ([]byte, error) {
+	switch indent {
+	case false:
+		return json.Marshal(c)
+	default:
+		return json.MarshalIndent(c, "", "    ")
+	}
+}
+
+// CommitteeFromJSON translates the given JSON formatted byte slice into a
+// committee.
+//
+func CommitteeFromJSON(b []byte) (*Committee, error) {
+	c := new(Committee)
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	return BuildCommittee(c.Trees, c.Weights)
+}
+
+// ToONNX returns this committee as a serialized ONNX model, as for
+// Decision.ToONNX. The probabilities output is the weighted sum of the
+// probabilities from each tree.
+//
+func (c *Committee) ToONNX(columns []string) ([]byte, error) {
+	return toONNX(c.Trees, c.Weights, columns)
+}
@@ -0,0 +1,117 @@
+package id3
+
+import "sort"
+
+// contingency holds the class frequencies of some rows, overall and by each
+// value of each of the columns counted. Learning a decision needs only these,
+// which take a single pass over the rows. The frequencies are the sums of the
+// weights of the rows, which are whole numbers of rows unless learning
+// WithWeights or distributing missing values.
+//
+type contingency struct {
+	classes map[string]float64              // The class frequencies.
+	counts  []map[string]map[string]float64 // The class frequencies by column then value, or nil for a column not counted.
+}
+
+func newContingency(columns int) *contingency {
+	return &contingency{classes: make(map[string]float64), counts: make([]map[string]map[string]float64, columns)}
+}
+
+// add counts the row, whose class is at classAt, with its weight for each
+// column counted. A row without weight is not counted.
+//
+func (t *contingency) add(row []string, classAt int, counted []bool, w float64) {
+	if w == 0 {
+		return
+	}
+	c := row[classAt]
+	t.classes[c] += w
+	for i, ok := range counted {
+		if !ok {
+			continue
+		}
+		if t.counts[i] == nil {
+			t.counts[i] = make(map[string]map[string]float64)
+		}
+		if t.counts[i][row[i]] == nil {
+			t.counts[i][row[i]] = make(map[string]float64)
+		}
+		t.counts[i][row[i]][c] += w
+	}
+}
+
+// entropy returns the total entropy of the class, as TotalEntropy does.
+//
+func (t *contingency) entropy() float64 {
+	return likelihoodEntropy(t.classes)
+}
+
+// gain returns the decrease in impurity from the column, which for a nil
+// measure is the information gain, summing in the same order as TotalEntropy
+// and AverageEntropy so that ties are broken the same way.
+//
+func (t *contingency) gain(i int, m Impurity) float64 {
+	return gainFrom(t.classes, t.counts[i], m)
+}
+
+// gainFrom returns the decrease in impurity from the class frequencies by each
+// value of a column, given the class frequencies overall.
+//
+func gainFrom(classes map[string]float64, counts map[string]map[string]float64, m Impurity) float64 {
+	avg := 0.0
+	for _, v := range valueLikelihood(counts) {
+		avg += v.Probability * impurityOf(m, counts[v.Value])
+	}
+	return impurityOf(m, classes) - avg
+}
+
+// likelihood returns the probability of each distinct value in the column,
+// sorted as by Likelihood.
+//
+func (t *contingency) likelihood(i int) []Distinct {
+	return valueLikelihood(t.counts[i])
+}
+
+// valueLikelihood returns the probability of each value from its class
+// frequencies, sorted as by Likelihood.
+//
+func valueLikelihood(counts map[string]map[string]float64) []Distinct {
+	frequency := make(map[string]float64, len(counts))
+	for value, classes := range counts {
+		for _, n := range classes {
+			frequency[value] += n
+		}
+	}
+	return likelihoodFrom(frequency)
+}
+
+// likelihoodFrom returns the probability of each value from its frequency,
+// sorted as by Likelihood.
+//
+func likelihoodFrom(frequency map[string]float64) []Distinct {
+	total := 0.0
+	for _, n := range frequency {
+		total += n
+	}
+	var sorted []Distinct
+	for k, n := range frequency {
+		sorted = append(sorted, Distinct{Value: k, Probability: n / total})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Probability == sorted[j].Probability {
+			return sorted[i].Value < sorted[j].Value
+		}
+		return sorted[i].Probability > sorted[j].Probability
+	})
+	return sorted
+}
+
+// likelihoodEntropy returns the entropy of the class frequencies, summed in
+// the same order as TotalEntropy.
+//
+func likelihoodEntropy(classes map[string]float64) (h float64) {
+	for _, v := range likelihoodFrom(classes) {
+		h += Entropy(v.Probability)
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package id3
+
+import "strconv"
+
+// Criterion is how Learn measures the worth of splitting on a column.
+//
+type Criterion int
+
+// The criteria.
+//
+const (
+	InformationGain Criterion = iota // The information gain, as by ID3.
+	GainRatio                        // The gain ratio, as by C4.5.
+)
+
+var criterionNames = []string{"gain", "ratio"}
+
+func (c Criterion) String() string {
+	if c < 0 || int(c) >= len(criterionNames) {
+		return "Criterion(" + strconv.Itoa(int(c)) + ")"
+	}
+	return criterionNames[c]
+}
+
+// WithCriterion has Learn choose each split by the criterion, such as
+// WithCriterion(GainRatio). The information gain, the default, favours columns
+// with many distinct values, such as row identifiers, whose cases each hold few
+// rows. GainRatio divides the gain by the split information, the entropy of
+// the cases themselves, as GainRatioOf does, to correct that. A test of a
+// set-valued or numeric column has two cases.
+//
+func WithCriterion(c Criterion) Option {
+	return func(o *options) { o.measure = c }
+}
+
+// GainRatioOf returns the information gain from the attribute column, that is
+// the total entropy of the class less the average entropy, divided by the
+// total entropy of the attribute column. It is zero for a column with a single
+// value.
+//
+func GainRatioOf(view View, attribute, class string) float64 {
+	split := TotalEntropy(view, attribute)
+	if split == 0 {
+		return 0
+	}
+	return (TotalEntropy(view, class) - AverageEntropy(view, attribute, class)) / split
+}
+
+// worth returns the gain from a split, or its ratio to the split information
+// for GainRatio, given the class frequencies by each case.
+//
+func (o *options) worth(gain float64, counts map[string]map[string]float64) float64 {
+	if o.measure != GainRatio {
+		return gain
+	}
+	split := 0.0
+	for _, c := range valueLikelihood(counts) {
+		split += Entropy(c.Probability)
+	}
+	if split == 0 {
+		return 0
+	}
+	return gain / split
+}
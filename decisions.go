@@ -2,24 +2,138 @@ package id3
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
 )
 
 // Decision represents a decision within the decision tree for a single column.
 // Each distinct value in that column is a case. The cases are in decreasing
-// probability sequence.
+// probability sequence. Values without a case decide the default class, if
+// there is one. A decision with a Test has a case for each outcome of the test
+// instead.
 //
 type Decision struct {
-	Column string  // The name of the data column.
-	Cases  []*Case // The cases for that column.
+	Column  string  // The name of the data column.
+	Cases   []*Case // The cases for that column.
+	Default string  `json:",omitempty"` // The class decided for values without a case, or "" for none.
+	Test    *Test   `json:",omitempty"` // The test of the column values, or nil to match the values themselves.
+}
+
+// Test is a test of the values of a column, whose outcome "true" or "false" is
+// matched with the cases of a decision. The test "contains" is of a set-valued
+// column holding values separated by the Separator, such as "urgent;billing",
+// for whether the set contains the Operand. The test "<=" is of a numeric
+// column, for whether the value is a number at most the Operand.
+//
+type Test struct {
+	Op        string // The test, "contains" or "<=".
+	Operand   string // The value tested for.
+	Separator string `json:",omitempty"` // Between the values of a set-valued column.
+}
+
+// opContains is the Op of a Test for whether a set contains a value.
+//
+const opContains = "contains"
+
+// opLessOrEqual is the Op of a Test for whether a number is at most a
+// threshold.
+//
+const opLessOrEqual = "<="
+
+// Outcome returns the outcome of the test for the value, "true" or "false". A
+// number is read as Go writes it, such as "1234.5".
+//
+func (t *Test) Outcome(value string) string {
+	return t.outcome(value, Locale{})
+}
+
+// outcome returns the outcome of the test for the value, reading a number as
+// written in the locale. The threshold is always written as Go writes it.
+//
+func (t *Test) outcome(value string, l Locale) string {
+	switch t.Op {
+	case opContains:
+		if containsToken(value, t.Separator, t.Operand) {
+			return "true"
+		}
+	case opLessOrEqual:
+		if f, err := strconv.ParseFloat(l.number(value), 64); err == nil && f <= t.threshold() {
+			return "true"
+		}
+	}
+	return "false"
+}
+
+// threshold returns the operand of a "<=" test as a number, or NaN if it is
+// not one, so that no value is at most it.
+//
+func (t *Test) threshold() float64 {
+	f, err := strconv.ParseFloat(t.Operand, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// String returns the test as text, for example "contains urgent" or "<= 20.5".
+//
+func (t *Test) String() string {
+	return t.Op + " " + t.Operand
+}
+
+// negation returns the test as text for the outcome "false", for example "not
+// contains urgent" or "> 20.5".
+//
+func (t *Test) negation() string {
+	if t.Op == opLessOrEqual {
+		return "> " + t.Operand
+	}
+	return "not " + t.String()
+}
+
+// key returns the value matched with the cases for the column value, reading
+// a number tested as written in the locale.
+//
+func (d *Decision) key(value string, l Locale) string {
+	if d.Test != nil {
+		return d.Test.outcome(value, l)
+	}
+	return value
+}
+
+// label returns the text for the case of this decision, which is its value
+// unless the decision has a test.
+//
+func (d *Decision) label(c *Case) string {
+	switch {
+	case d.Test == nil || c.Value == otherValue:
+		return c.Value
+	case c.Value == "true":
+		return d.Test.String()
+	}
+	return d.Test.negation()
+}
+
+// condition returns the text for taking the case of this decision, such as
+// "outlook = sunny" or "tags contains urgent".
+//
+func (d *Decision) condition(c *Case) string {
+	if d.Test == nil || c.Value == otherValue {
+		return d.Column + " = " + c.Value
+	}
+	return d.Column + " " + d.label(c)
 }
 
 // A Case is a distinct value and its associated action; either a decided class
 // value or a subsequent decision.
 //
 type Case struct {
-	Value  string    // The distinct column value.
-	Class  string    // The decided class value, or "" if further decision(s) are needed.
-	Decide *Decision // The subsequent decision, or nil.
+	Value  string         // The distinct column value.
+	Class  string         // The decided class value, or "" if further decision(s) are needed.
+	Decide *Decision      // The subsequent decision, or nil.
+	Counts map[string]int `json:",omitempty"` // The class frequencies of the training rows for this case, if known.
 }
 
 // ToJSON returns this decision as a JSON formatted bytes slice.
@@ -33,40 +147,295 @@ func (d *Decision) ToJSON(indent bool) ([]byte, error) {
 	}
 }
 
-// FromJSON translates the given JSON formatted byte slice into a decision.
+// FromJSON translates the given JSON formatted byte slice into a decision. The
+// slice may hold either a bare decision or an Envelope.
 //
 func FromJSON(b []byte) (*Decision, error) {
-	d := new(Decision)
-	err := json.Unmarshal(b, d)
+	e, err := EnvelopeFromJSON(b)
 	if err != nil {
 		return nil, err
 	}
-	return d, nil
+	return e.Decision()
 }
 
 // Decide on the given CSV conformant data. The first row must be the column
-// headings.
+// headings. A missing value, that is an empty one, without a case of its own
+// takes the case the most training rows took, as the most likely. It fails
+// with ErrNoMatchingCase if there is no rule for a row, ErrColumnNotFound if
+// the headings lack a column the decision tests, or ErrSchemaMismatch if a row
+// has the wrong number of values.
 //
-func (d *Decision) Decide(data [][]string) (result []string) {
+func (d *Decision) Decide(data [][]string) (result []string, err error) {
+	var index map[string]int
+	if len(data) > 0 {
+		index = newIndex(data[0])
+	}
 	for i := range data {
 		if i == 0 {
 			continue
 		}
-		result = append(result, d.decide(data, i))
+		class, err := d.decide(data, i, index, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, class)
 	}
 	return
 }
 
-func (d *Decision) decide(data [][]string, at int) string {
-	i := find(data[0], d.Column)
-	value := data[at][i]
+// DecideRow decides on a single record, keyed by column name, as Decide does
+// on a row of data, such as a request to a web service. It fails with
+// ErrNoMatchingCase if there is no rule for the record, or ErrColumnNotFound if
+// it lacks a column the decision tests.
+//
+func (d *Decision) DecideRow(record map[string]string) (string, error) {
+	header := make([]string, 0, len(record))
+	row := make([]string, 0, len(record))
+	index := make(map[string]int, len(record))
+	for column, value := range record {
+		index[column] = len(header)
+		header = append(header, column)
+		row = append(row, value)
+	}
+	return d.decide([][]string{header, row}, 1, index, nil)
+}
+
+// otherValue stands for the values without a case when rendering a decision.
+//
+const otherValue = "(other)"
+
+// rendered returns the cases of this decision followed, if there is a default
+// class, by a case deciding that for otherValue.
+//
+func (d *Decision) rendered() []*Case {
+	if d.Default == "" {
+		return d.Cases
+	}
+	return append(d.Cases[:len(d.Cases):len(d.Cases)], &Case{Value: otherValue, Class: d.Default})
+}
+
+// match returns the case for the column value, otherwise a case deciding the default
+// class, or nil if there is no default.
+//
+func (d *Decision) match(value string) *Case {
+	value = d.key(value, Locale{})
 	for _, c := range d.Cases {
-		if value == c.Value {
-			if c.Class != "" {
-				return c.Class
+		if c.Value == value {
+			return c
+		}
+	}
+	if d.Default != "" {
+		return &Case{Value: value, Class: d.Default}
+	}
+	return nil
+}
+
+// classify returns the class this decision decides for the row, matching
+// values by the options, which may be nil, or false if it has no rule for the
+// row or there is no column. The column indices are cached in index.
+//
+func (d *Decision) classify(row []string, columns []string, index map[string]int, o *options) (string, bool) {
+	at, c, err := d.walk(row, columns, index, o)
+	switch {
+	case err != nil:
+		return "", false
+	case c != nil:
+		return c.Class, true
+	}
+	return at.Default, at.Default != ""
+}
+
+// clone returns a deep copy of this decision.
+//
+func (d *Decision) clone() *Decision {
+	c := &Decision{Column: d.Column, Cases: make([]*Case, len(d.Cases)), Default: d.Default}
+	if d.Test != nil {
+		t := *d.Test
+		c.Test = &t
+	}
+	for i, k := range d.Cases {
+		c.Cases[i] = k.clone()
+	}
+	return c
+}
+
+// clone returns a deep copy of this case.
+//
+func (c *Case) clone() *Case {
+	copied := *c
+	if c.Counts != nil {
+		copied.Counts = make(map[string]int, len(c.Counts))
+		for class, n := range c.Counts {
+			copied.Counts[class] = n
+		}
+	}
+	if c.Decide != nil {
+		copied.Decide = c.Decide.clone()
+	}
+	return &copied
+}
+
+// Probabilities returns the probability of each class for the row, which has
+// the given column names, from the class frequencies of the leaf it reaches. It
+// returns nil if there is no rule for the row or the leaf has no frequencies.
+//
+func (d *Decision) Probabilities(columns, row []string) map[string]float64 {
+	c := d.leaf(row, columns, make(map[string]int))
+	if c == nil || len(c.Counts) == 0 {
+		return nil
+	}
+	total := 0
+	for _, n := range c.Counts {
+		total += n
+	}
+	p := make(map[string]float64, len(c.Counts))
+	for class, n := range c.Counts {
+		p[class] = float64(n) / float64(total)
+	}
+	return p
+}
+
+// Classes returns the distinct classes decided anywhere in this decision, in
+// sorted order.
+//
+func (d *Decision) Classes() []string {
+	var classes []string
+	var walk func(d *Decision)
+	walk = func(d *Decision) {
+		if d.Default != "" && !contains(classes, d.Default) {
+			classes = append(classes, d.Default)
+		}
+		for _, c := range d.Cases {
+			switch {
+			case c.Decide != nil:
+				walk(c.Decide)
+			case !contains(classes, c.Class):
+				classes = append(classes, c.Class)
 			}
-			return c.Decide.decide(data, at)
 		}
 	}
-	panic("id3: no rule for column " + d.Column)
+	walk(d)
+	sort.Strings(classes)
+	return classes
+}
+
+// Columns returns the distinct columns tested anywhere in this decision, in
+// sorted order. These are the columns a row must have to be decided.
+//
+func (d *Decision) Columns() []string {
+	var columns []string
+	var walk func(d *Decision)
+	walk = func(d *Decision) {
+		if !contains(columns, d.Column) {
+			columns = append(columns, d.Column)
+		}
+		for _, c := range d.Cases {
+			if c.Decide != nil {
+				walk(c.Decide)
+			}
+		}
+	}
+	walk(d)
+	sort.Strings(columns)
+	return columns
+}
+
+// decide returns the class for the row of the data at the given index, finding
+// columns in the index of the header, and matching values by the options,
+// which may be nil.
+//
+func (d *Decision) decide(data [][]string, at int, index map[string]int, o *options) (string, error) {
+	if len(data[at]) != len(data[0]) {
+		return "", fmt.Errorf("%w: row %d has %d values for %d columns", ErrSchemaMismatch, at, len(data[at]), len(data[0]))
+	}
+	stop, c, err := d.walk(data[at], data[0], index, o)
+	switch {
+	case err != nil:
+		return "", err
+	case c != nil:
+		return c.Class, nil
+	case stop.Default != "":
+		return stop.Default, nil
+	}
+	return "", fmt.Errorf("%w: row %d: %s=%s", ErrNoMatchingCase, at, stop.Column, data[at][index[stop.Column]])
+}
+
+// Follow follows the decision for a single record, keyed by column name, as
+// DecideRow does, matching values by the options, such as WithFuzzyMatch,
+// WithMissingMarkers or WithMissingFallback. It returns the decision where it
+// stops and the leaf case taken there. If that decision has no case for the
+// value the case is nil, and the record takes the default class of the
+// decision, or if there is none Follow fails with ErrNoMatchingCase. It fails
+// with ErrColumnNotFound if the record lacks a column the decision tests.
+//
+func (d *Decision) Follow(record map[string]string, opts ...Option) (*Decision, *Case, error) {
+	header := make([]string, 0, len(record))
+	row := make([]string, 0, len(record))
+	index := make(map[string]int, len(record))
+	for column, value := range record {
+		index[column] = len(header)
+		header = append(header, column)
+		row = append(row, value)
+	}
+	at, c, err := d.walk(row, header, index, newOptions(opts))
+	if err == nil && c == nil && at.Default == "" {
+		err = fmt.Errorf("%w: %s=%s", ErrNoMatchingCase, at.Column, record[at.Column])
+	}
+	return at, c, err
+}
+
+// walk follows this decision for the row, which has the given column names,
+// and returns the decision where it stops and the leaf case taken there, or a
+// nil case if that decision has none for the value. It fails with
+// ErrColumnNotFound if there is no column the decision tests. The column
+// indices are cached in index, and values are matched by the options, which
+// may be nil. Every prediction from a decision is made through here, so that
+// they all agree.
+//
+func (d *Decision) walk(row, columns []string, index map[string]int, o *options) (*Decision, *Case, error) {
+	for {
+		i, ok := index[d.Column]
+		if !ok {
+			var err error
+			if i, err = find(columns, d.Column); err != nil {
+				return d, nil, err
+			}
+			index[d.Column] = i
+		}
+		c := d.caseOf(row[i], o)
+		if c == nil || c.Decide == nil {
+			return d, c, nil
+		}
+		d = c.Decide
+	}
+}
+
+// caseOf returns the case of this decision for the value, matched by the
+// options, which may be nil, or nil if there is none. A number tested is read
+// as written in the locale WithLocale gives. A missing value, that is
+// empty or a marker, without a case of its own takes the case the most
+// training rows took, unless there is a default class or the options have no
+// fallback. Otherwise, given WithFuzzyMatch, a value takes the case of the
+// closest value.
+//
+func (d *Decision) caseOf(value string, o *options) *Case {
+	var l Locale
+	if o != nil {
+		l = o.locale
+		if contains(o.markers, value) {
+			value = ""
+		}
+	}
+	if c := d.caseFor(d.key(value, l)); c != nil {
+		return c
+	}
+	if value == "" && d.Default == "" && (o == nil || o.absent == FallbackMostFrequent) {
+		if c := d.mostFrequent(); c != nil {
+			return c
+		}
+	}
+	if o != nil && o.fuzzy {
+		return d.closest(value, o.edits)
+	}
+	return nil
 }
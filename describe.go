@@ -0,0 +1,76 @@
+package id3
+
+import "sort"
+
+// ColumnStats summarises the values of one column of a view.
+//
+type ColumnStats struct {
+	Column   string
+	Rows     int     // The number of rows in the view.
+	Distinct int     // The number of distinct values, excluding "".
+	Missing  int     // The number of rows with an empty value.
+	Top      string  // The most frequent value, excluding "".
+	TopCount int     // The number of rows having the Top value.
+	Entropy  float64 // The entropy of the values, in bits, including "".
+	LikelyID bool    // Whether every present value is different.
+}
+
+// MissingRate returns the fraction of rows with an empty value, or zero.
+//
+func (s ColumnStats) MissingRate() float64 {
+	if s.Rows == 0 {
+		return 0
+	}
+	return float64(s.Missing) / float64(s.Rows)
+}
+
+// Describe returns statistics for each visible column of the view, in order,
+// in a single pass over the rows. A column that is LikelyID, such as a key or
+// row number, has the greatest possible information gain but is useless on new
+// data, so should be dropped before learning.
+//
+func Describe(view View) []ColumnStats {
+	columns := view.Columns()
+	counts := make([]map[string]int, len(columns))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	rows := 0
+	view.First()
+	for {
+		row := view.Next()
+		if row == nil {
+			break
+		}
+		rows++
+		for i, v := range row {
+			counts[i][v]++
+		}
+	}
+	var stats []ColumnStats
+	for i, column := range columns {
+		if column == "" {
+			continue
+		}
+		s := ColumnStats{Column: column, Rows: rows, Missing: counts[i][""]}
+		values := make([]string, 0, len(counts[i]))
+		for v := range counts[i] {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		for _, v := range values {
+			n := counts[i][v]
+			s.Entropy += Entropy(float64(n) / float64(rows))
+			if v == "" {
+				continue
+			}
+			s.Distinct++
+			if n > s.TopCount {
+				s.Top, s.TopCount = v, n
+			}
+		}
+		s.LikelyID = s.Distinct > 1 && s.Distinct == rows-s.Missing
+		stats = append(stats, s)
+	}
+	return stats
+}
@@ -0,0 +1,72 @@
+package id3
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// A Dialect describes how CSV data is written where it differs from RFC 4180,
+// such as the semicolon separated files written where the comma is the
+// decimal point.
+//
+type Dialect struct {
+	Comma            rune // The field separator, or ',' if zero.
+	Comment          rune // Lines starting with this are skipped, if not zero.
+	TrimLeadingSpace bool // Whether leading white space in a field is ignored.
+	LazyQuotes       bool // Whether a quote may appear in an unquoted field, and a lone quote in a quoted field.
+	NoHeader         bool // Whether the first row is data, with columns named "column1", "column2" and so on.
+}
+
+// WithDialect has Read and LearnExternal read CSV data written in the dialect,
+// for example:
+//
+//	view, err := id3.Read(f, id3.WithDialect(id3.Dialect{Comma: ';', Comment: '#'}))
+//
+func WithDialect(d Dialect) Option {
+	return func(o *options) { o.dialect = d }
+}
+
+// WithColumnNames has Read and LearnExternal take every row of CSV data as a
+// row of data, with the columns named as given, for data written without a
+// header. Each row must have a value for every name.
+//
+func WithColumnNames(names ...string) Option {
+	return func(o *options) { o.names = names }
+}
+
+// headerless reports whether CSV data has no header row.
+//
+func (o *options) headerless() bool {
+	return o.dialect.NoHeader || o.names != nil
+}
+
+// csvReader returns a reader of CSV data in the dialect.
+//
+func (o *options) csvReader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	if o.names != nil {
+		cr.FieldsPerRecord = len(o.names)
+	}
+	if o.dialect.Comma != 0 {
+		cr.Comma = o.dialect.Comma
+	}
+	cr.Comment = o.dialect.Comment
+	cr.TrimLeadingSpace = o.dialect.TrimLeadingSpace
+	cr.LazyQuotes = o.dialect.LazyQuotes
+	return cr
+}
+
+// columnNames returns the names of the columns of data without a header, whose
+// first row is given.
+//
+func (o *options) columnNames(first []string) []string {
+	if o.names != nil {
+		return o.names
+	}
+	names := make([]string, len(first))
+	for i := range names {
+		names[i] = "column" + strconv.Itoa(i+1)
+	}
+	return names
+}
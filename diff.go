@@ -0,0 +1,214 @@
+package id3
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind classifies a change between two decision trees.
+//
+type ChangeKind string
+
+// The kinds of change reported by Diff.
+//
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// A Change is a difference between two decision trees at the end of a path of
+// conditions. Before and After describe the outcome there in each tree, either
+// a decided class or "decide <column>", and are empty where there is none.
+//
+type Change struct {
+	Kind   ChangeKind
+	Path   []Condition
+	Before string
+	After  string
+}
+
+// String returns the change as text, for example
+// "changed outlook=sunny AND humidity=high: no -> yes".
+//
+func (c Change) String() string {
+	path := make([]string, len(c.Path))
+	for i, p := range c.Path {
+		path[i] = p.String()
+	}
+	where := strings.Join(path, " AND ")
+	if where == "" {
+		where = "(root)"
+	}
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("added %s: %s", where, c.After)
+	case Removed:
+		return fmt.Sprintf("removed %s: %s", where, c.Before)
+	default:
+		return fmt.Sprintf("changed %s: %s -> %s", where, c.Before, c.After)
+	}
+}
+
+// Diff returns the changes needed to turn decision a into decision b. Cases are
+// matched by value, so the order of cases is ignored. Where a decision tests a
+// different column the whole subtree is reported as one change. Changes to a
+// default class have a path ending in the value "(other)".
+//
+func Diff(a, b *Decision) []Change {
+	var changes []Change
+	diff(a, b, nil, &changes)
+	return changes
+}
+
+func diff(a, b *Decision, path []Condition, changes *[]Change) {
+	if a.Column != b.Column || !sameTest(a.Test, b.Test) {
+		*changes = append(*changes, Change{Kind: Changed, Path: path, Before: a.decides(), After: b.decides()})
+		return
+	}
+	at := func(c *Case) []Condition {
+		if c.Value == otherValue {
+			return append(path[:len(path):len(path)], Condition{Column: a.Column, Value: c.Value})
+		}
+		return append(path[:len(path):len(path)], Condition{Column: a.Column, Value: c.Value, Test: a.Test})
+	}
+	for _, ca := range a.Cases {
+		cb := b.caseFor(ca.Value)
+		switch {
+		case cb == nil:
+			*changes = append(*changes, Change{Kind: Removed, Path: at(ca), Before: ca.outcome()})
+		case ca.Decide != nil && cb.Decide != nil:
+			diff(ca.Decide, cb.Decide, at(ca), changes)
+		case ca.outcome() != cb.outcome():
+			*changes = append(*changes, Change{Kind: Changed, Path: at(ca), Before: ca.outcome(), After: cb.outcome()})
+		}
+	}
+	for _, cb := range b.Cases {
+		if a.caseFor(cb.Value) == nil {
+			*changes = append(*changes, Change{Kind: Added, Path: at(cb), After: cb.outcome()})
+		}
+	}
+	other := &Case{Value: otherValue}
+	switch {
+	case a.Default == b.Default:
+	case a.Default == "":
+		*changes = append(*changes, Change{Kind: Added, Path: at(other), After: b.Default})
+	case b.Default == "":
+		*changes = append(*changes, Change{Kind: Removed, Path: at(other), Before: a.Default})
+	default:
+		*changes = append(*changes, Change{Kind: Changed, Path: at(other), Before: a.Default, After: b.Default})
+	}
+}
+
+// caseFor returns the case for the value, or nil.
+//
+func (d *Decision) caseFor(value string) *Case {
+	for _, c := range d.Cases {
+		if c.Value == value {
+			return c
+		}
+	}
+	return nil
+}
+
+// decides describes what the decision tests.
+//
+func (d *Decision) decides() string {
+	if d.Test != nil {
+		return "decide " + d.Column + " " + d.Test.String()
+	}
+	return "decide " + d.Column
+}
+
+// outcome describes the action of the case.
+//
+func (c *Case) outcome() string {
+	if c.Decide != nil {
+		return c.Decide.decides()
+	}
+	return c.Class
+}
+
+// Equivalent reports whether this decision and the other are the same up to the
+// order of cases, ignoring any class frequencies. If logical is true, they need
+// only make the same decision, or both have no rule, for every combination of
+// the column values that appear in either tree, so that for example a tree
+// testing wind then outlook can be equivalent to one testing outlook then wind.
+// For a set-valued column, the values tried are each value tested for alone.
+//
+func (d *Decision) Equivalent(other *Decision, logical bool) bool {
+	if !logical {
+		return len(Diff(d, other)) == 0
+	}
+	domain := make(map[string][]string)
+	d.domain(domain)
+	other.domain(domain)
+	//
+	// Also try a value that has no case in either tree, to compare defaults.
+	//
+	for column := range domain {
+		domain[column] = append(domain[column], unseenValue)
+	}
+	return equivalent(&Case{Decide: d}, &Case{Decide: other}, domain, make(map[string]string))
+}
+
+// unseenValue stands for any value without a case when testing equivalence.
+//
+const unseenValue = "\x00"
+
+// domain adds the values of each column tested in this decision. For a
+// threshold, that is the threshold and the next number above it.
+//
+func (d *Decision) domain(domain map[string][]string) {
+	if d.Test != nil && !contains(domain[d.Column], d.Test.Operand) {
+		domain[d.Column] = append(domain[d.Column], d.Test.Operand)
+	}
+	if d.Test != nil && d.Test.Op == opLessOrEqual {
+		above := strconv.FormatFloat(math.Nextafter(d.Test.threshold(), math.Inf(1)), 'g', -1, 64)
+		if !contains(domain[d.Column], above) {
+			domain[d.Column] = append(domain[d.Column], above)
+		}
+	}
+	for _, c := range d.Cases {
+		if d.Test == nil && !contains(domain[d.Column], c.Value) {
+			domain[d.Column] = append(domain[d.Column], c.Value)
+		}
+		if c.Decide != nil {
+			c.Decide.domain(domain)
+		}
+	}
+}
+
+// equivalent compares the outcomes of two cases, either of which may be nil
+// for no rule, given the column values fixed so far.
+//
+func equivalent(x, y *Case, domain map[string][]string, fixed map[string]string) bool {
+	if x != nil && x.Decide == nil && y != nil && y.Decide == nil {
+		return x.Class == y.Class
+	}
+	if x == nil || x.Decide == nil {
+		if y == nil || y.Decide == nil {
+			return x == nil && y == nil
+		}
+		x, y = y, x
+	}
+	//
+	// Expand the decision in x, either following the fixed value or trying
+	// each value in the domain.
+	//
+	column := x.Decide.Column
+	if v, ok := fixed[column]; ok {
+		return equivalent(x.Decide.match(v), y, domain, fixed)
+	}
+	for _, v := range domain[column] {
+		fixed[column] = v
+		ok := equivalent(x.Decide.match(v), y, domain, fixed)
+		delete(fixed, column)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToDOT returns this decision as Graphviz DOT source. Decisions are drawn as
+// boxes labelled with the column name, cases as edges labelled with the value
+// and decided classes as ellipses. If counts is true, and the tree carries the
+// class frequencies from learning, those are added to each leaf.
+//
+func (d *Decision) ToDOT(counts bool) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("digraph id3 {\n")
+	buf.WriteString("\tnode [shape=box];\n")
+	next := 0
+	var walk func(d *Decision) int
+	walk = func(d *Decision) int {
+		id := next
+		next++
+		fmt.Fprintf(&buf, "\tn%d [label=\"%s\"];\n", id, dotEscape(d.Column))
+		for _, c := range d.rendered() {
+			var child int
+			if c.Decide != nil {
+				child = walk(c.Decide)
+			} else {
+				child = next
+				next++
+				label := c.Class
+				if counts && len(c.Counts) > 0 {
+					label += "\n" + formatCounts(c.Counts)
+				}
+				fmt.Fprintf(&buf, "\tn%d [label=\"%s\", shape=ellipse];\n", child, dotEscape(label))
+			}
+			fmt.Fprintf(&buf, "\tn%d -> n%d [label=\"%s\"];\n", id, child, dotEscape(d.label(c)))
+		}
+		return id
+	}
+	walk(d)
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// dotEscape makes the string safe to use within a quoted DOT label.
+//
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatCounts returns the class frequencies as "class=n" pairs, sorted by
+// class.
+//
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%d", k, counts[k])
+	}
+	return strings.Join(parts, " ")
+}
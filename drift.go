@@ -0,0 +1,119 @@
+package id3
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// DriftThreshold is the population stability index above which a column is
+// taken to have drifted. By convention, an index below 0.1 is no change, and
+// one above 0.2 a significant change.
+//
+const DriftThreshold = 0.2
+
+// ColumnDrift compares the values of one column of new data with those of the
+// training data.
+//
+type ColumnDrift struct {
+	Column         string
+	TotalVariation float64 // Half the sum of the differences in the probability of each value, from 0 to 1.
+	PSI            float64 // The population stability index.
+	Unseen         float64 // The fraction of new rows with a value not in the training data.
+	Drifted        bool    // Whether the PSI is above the DriftThreshold.
+}
+
+// Distributions returns the probability of each value of each visible column of
+// the view, for the Distributions of an Envelope. It returns nil if the view
+// has no rows.
+//
+func Distributions(view View) map[string]map[string]float64 {
+	columns := view.Columns()
+	counts := make([]map[string]int, len(columns))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	rows := 0
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		rows++
+		for i, v := range row {
+			counts[i][v]++
+		}
+	}
+	if rows == 0 {
+		return nil
+	}
+	dist := make(map[string]map[string]float64, len(columns))
+	for i, c := range columns {
+		if c == "" {
+			continue
+		}
+		dist[c] = make(map[string]float64, len(counts[i]))
+		for v, n := range counts[i] {
+			dist[c][v] = float64(n) / float64(rows)
+		}
+	}
+	return dist
+}
+
+// DriftReport compares the distribution of the values of each column in the
+// view with that recorded in the Distributions of the envelope, in order of
+// column name, so that new data which no longer looks like the training data
+// can be noticed. The class column is left out, as new data is not labelled.
+// It fails with ErrColumnNotFound if the view lacks a recorded column, or
+// ErrEmptyView if it has no rows.
+//
+func DriftReport(e *Envelope, view View) ([]ColumnDrift, error) {
+	if len(e.Distributions) == 0 {
+		return nil, errors.New("id3: envelope has no distributions")
+	}
+	var columns []string
+	for c := range e.Distributions {
+		if c == e.ClassColumn {
+			continue
+		}
+		if _, err := indexOf(view, c); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+	current := Distributions(view)
+	if current == nil {
+		return nil, ErrEmptyView
+	}
+	var report []ColumnDrift
+	for _, c := range columns {
+		report = append(report, drift(c, e.Distributions[c], current[c]))
+	}
+	return report, nil
+}
+
+// drift compares the expected and actual probabilities of the values of the
+// column. A value missing from either is given a small probability for the
+// PSI, which would otherwise be infinite.
+//
+func drift(column string, expected, actual map[string]float64) ColumnDrift {
+	const floor = 1e-4
+	d := ColumnDrift{Column: column}
+	var values []string
+	for v := range expected {
+		values = append(values, v)
+	}
+	for v, p := range actual {
+		if _, ok := expected[v]; !ok {
+			values = append(values, v)
+			d.Unseen += p
+		}
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		e, a := expected[v], actual[v]
+		d.TotalVariation += math.Abs(a-e) / 2
+		e, a = math.Max(e, floor), math.Max(a, floor)
+		d.PSI += (a - e) * math.Log(a/e)
+	}
+	d.Drifted = d.PSI > DriftThreshold
+	return d
+}
@@ -0,0 +1,139 @@
+package id3
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PruneAt replaces the subsequent decision of the case at the end of the path
+// with the class held by most of the training rows beneath it. This needs the
+// class frequencies from learning.
+//
+func (d *Decision) PruneAt(path []Condition) error {
+	c, err := d.caseAt(path)
+	if err != nil {
+		return err
+	}
+	counts := c.totals()
+	class := majority(weighed(counts))
+	if class == "" {
+		return fmt.Errorf("id3: no class frequencies at %s", formatPath(path))
+	}
+	c.Class, c.Decide, c.Counts = class, nil, counts
+	return nil
+}
+
+// SetClass makes the case at the end of the path decide the class, replacing
+// any subsequent decision.
+//
+func (d *Decision) SetClass(path []Condition, class string) error {
+	if class == "" {
+		return errors.New("id3: class must not be empty")
+	}
+	c, err := d.caseAt(path)
+	if err != nil {
+		return err
+	}
+	counts := c.totals()
+	if len(counts) == 0 {
+		counts = nil
+	}
+	c.Class, c.Decide, c.Counts = class, nil, counts
+	return nil
+}
+
+// AddCase adds a case for the value deciding the class, to the decision at the
+// end of the path. An empty path is this decision. The value must not already
+// have a case.
+//
+func (d *Decision) AddCase(path []Condition, value, class string) error {
+	if class == "" {
+		return errors.New("id3: class must not be empty")
+	}
+	target := d
+	if len(path) > 0 {
+		c, err := d.caseAt(path)
+		if err != nil {
+			return err
+		}
+		if c.Decide == nil {
+			return fmt.Errorf("id3: %s decides a class, not a column", formatPath(path))
+		}
+		target = c.Decide
+	}
+	if target.caseFor(value) != nil {
+		return fmt.Errorf("id3: %s=%s already has a case", target.Column, value)
+	}
+	target.Cases = append(target.Cases, &Case{Value: value, Class: class})
+	return nil
+}
+
+// caseAt follows the path from this decision and returns the case at its end.
+//
+func (d *Decision) caseAt(path []Condition) (*Case, error) {
+	if len(path) == 0 {
+		return nil, errors.New("id3: empty path")
+	}
+	var c *Case
+	for i, p := range path {
+		if d == nil {
+			return nil, fmt.Errorf("id3: %s decides a class", formatPath(path[:i]))
+		}
+		if d.Column != p.Column {
+			return nil, fmt.Errorf("id3: %s decides '%s', not '%s'", formatPath(path[:i]), d.Column, p.Column)
+		}
+		if c = d.caseFor(p.Value); c == nil {
+			return nil, fmt.Errorf("id3: no case for %s", formatPath(path[:i+1]))
+		}
+		d = c.Decide
+	}
+	return c, nil
+}
+
+// totals returns the class frequencies of the training rows for this case,
+// summing over any subsequent decisions.
+//
+func (c *Case) totals() map[string]int {
+	totals := make(map[string]int)
+	var walk func(c *Case)
+	walk = func(c *Case) {
+		if c.Decide == nil {
+			for class, n := range c.Counts {
+				totals[class] += n
+			}
+			return
+		}
+		for _, k := range c.Decide.Cases {
+			walk(k)
+		}
+	}
+	walk(c)
+	return totals
+}
+
+// majority returns the most frequent class, breaking ties on the class value,
+// or "" if there are no counts. Whole counts are given as by weighed.
+//
+func majority(counts map[string]float64) string {
+	class, max := "", 0.0
+	for k, n := range counts {
+		if n > max || (n == max && n > 0 && k < class) {
+			class, max = k, n
+		}
+	}
+	return class
+}
+
+func formatPath(path []Condition) string {
+	if len(path) == 0 {
+		return "the root"
+	}
+	s := ""
+	for i, p := range path {
+		if i > 0 {
+			s += " AND "
+		}
+		s += p.String()
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package id3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FormatVersion is the current version of the model envelope format. Bare
+// decision trees written before the envelope existed are version 0.
+//
+const FormatVersion = 1
+
+// Envelope wraps a serialized model with the metadata needed to use it safely,
+// and to allow the format to evolve.
+//
+type Envelope struct {
+	FormatVersion int                `json:"formatVersion"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	ClassColumn   string             `json:"classColumn,omitempty"`
+	Columns       []string           `json:"columns,omitempty"`
+	Metrics       map[string]float64 `json:"metrics,omitempty"`
+	Payload       json.RawMessage    `json:"payload"`
+
+	// Distributions has the probability of each value of each column of the
+	// training data but the class column, as returned by Distributions, for
+	// DriftReport.
+	Distributions map[string]map[string]float64 `json:"distributions,omitempty"`
+}
+
+// NewEnvelope wraps the decision, learned for the class column from the view,
+// in an envelope of the current format version, recording the columns of the
+// view and the Distributions of all but the class column. The view and the
+// metrics are optional.
+//
+func NewEnvelope(d *Decision, class string, view View, metrics map[string]float64) (*Envelope, error) {
+	payload, err := d.ToJSON(false)
+	if err != nil {
+		return nil, err
+	}
+	e := &Envelope{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		ClassColumn:   class,
+		Metrics:       metrics,
+		Payload:       payload,
+	}
+	if view != nil {
+		e.Columns = view.Columns()
+		e.Distributions = Distributions(view)
+		delete(e.Distributions, class)
+	}
+	return e, nil
+}
+
+// ToJSON returns this envelope as a JSON formatted bytes slice.
+//
+func (e *Envelope) ToJSON(indent bool) ([]byte, error) {
+	switch indent {
+	case false:
+		return json.Marshal(e)
+	default:
+		return json.MarshalIndent(e, "", "    ")
+	}
+}
+
+// EnvelopeFromJSON translates the given JSON formatted byte slice into an
+// envelope. A bare decision tree is returned as the payload of a version 0
+// envelope with no metadata.
+//
+func EnvelopeFromJSON(b []byte) (*Envelope, error) {
+	probe := struct {
+		FormatVersion *int `json:"formatVersion"`
+	}{}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, err
+	}
+	if probe.FormatVersion == nil {
+		return &Envelope{Payload: json.RawMessage(b)}, nil
+	}
+	if *probe.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("id3: unsupported model format version %d", *probe.FormatVersion)
+	}
+	e := new(Envelope)
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Decision returns the decision tree in the payload.
+//
+func (e *Envelope) Decision() (*Decision, error) {
+	if len(e.Payload) == 0 {
+		return nil, errors.New("id3: envelope has no payload")
+	}
+	d := new(Decision)
+	if err := json.Unmarshal(e.Payload, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
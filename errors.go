@@ -0,0 +1,14 @@
+package id3
+
+import "errors"
+
+// The errors returned by this package, wrapped with the column, row or value
+// concerned, so that callers can test for them with errors.Is.
+//
+var (
+	ErrColumnNotFound     = errors.New("id3: column not found")
+	ErrNoMatchingCase     = errors.New("id3: no matching case")
+	ErrEmptyView          = errors.New("id3: view has no rows")
+	ErrClassColumnMissing = errors.New("id3: class column missing")
+	ErrSchemaMismatch     = errors.New("id3: schema mismatch")
+)
@@ -0,0 +1,132 @@
+package id3
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Undecided is the predicted class counted by Evaluate for a row without a
+// decision.
+//
+const Undecided = "(none)"
+
+// A ConfusionMatrix counts the rows of each actual class by the class
+// predicted for them.
+//
+type ConfusionMatrix struct {
+	Counts map[string]map[string]int // The number of rows by actual class, then by predicted class.
+	Rows   int                       // The number of rows counted.
+}
+
+// Evaluate decides each row of the view and returns the confusion matrix of
+// the named class column against the predicted class, which is Undecided for a
+// row without a decision. Values are matched by the options, such as
+// WithMissingFallback, as DecideContext does. It fails with
+// ErrClassColumnMissing if the view has no such column.
+//
+func Evaluate(d *Decision, view View, class string, opts ...Option) (*ConfusionMatrix, error) {
+	classAt, err := indexOf(view, class)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	m := &ConfusionMatrix{Counts: make(map[string]map[string]int)}
+	columns := view.Columns()
+	index := make(map[string]int)
+	o := newOptions(opts)
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		predicted, ok := d.classify(row, columns, index, o)
+		if !ok {
+			predicted = Undecided
+		}
+		if m.Counts[row[classAt]] == nil {
+			m.Counts[row[classAt]] = make(map[string]int)
+		}
+		m.Counts[row[classAt]][predicted]++
+		m.Rows++
+	}
+	return m, nil
+}
+
+// Classes returns the actual and predicted classes in order, followed by
+// Undecided if any row was.
+//
+func (m *ConfusionMatrix) Classes() []string {
+	seen := make(map[string]bool)
+	for actual, predicted := range m.Counts {
+		seen[actual] = true
+		for p := range predicted {
+			seen[p] = true
+		}
+	}
+	var classes []string
+	for c := range seen {
+		if c != Undecided {
+			classes = append(classes, c)
+		}
+	}
+	sort.Strings(classes)
+	if seen[Undecided] {
+		classes = append(classes, Undecided)
+	}
+	return classes
+}
+
+// Accuracy returns the fraction of the rows whose class was predicted, or zero
+// if there are none.
+//
+func (m *ConfusionMatrix) Accuracy() float64 {
+	if m.Rows == 0 {
+		return 0
+	}
+	correct := 0
+	for actual, predicted := range m.Counts {
+		correct += predicted[actual]
+	}
+	return float64(correct) / float64(m.Rows)
+}
+
+// Support returns the number of rows of the class.
+//
+func (m *ConfusionMatrix) Support(class string) (n int) {
+	for _, k := range m.Counts[class] {
+		n += k
+	}
+	return
+}
+
+// Precision returns the fraction of the rows predicted to be of the class that
+// are, or zero if none were.
+//
+func (m *ConfusionMatrix) Precision(class string) float64 {
+	predicted := 0
+	for _, p := range m.Counts {
+		predicted += p[class]
+	}
+	if predicted == 0 {
+		return 0
+	}
+	return float64(m.Counts[class][class]) / float64(predicted)
+}
+
+// Recall returns the fraction of the rows of the class that were predicted to
+// be, or zero if there are none.
+//
+func (m *ConfusionMatrix) Recall(class string) float64 {
+	n := m.Support(class)
+	if n == 0 {
+		return 0
+	}
+	return float64(m.Counts[class][class]) / float64(n)
+}
+
+// F1 returns the harmonic mean of the precision and recall of the class, or
+// zero if both are.
+//
+func (m *ConfusionMatrix) F1(class string) float64 {
+	p, r := m.Precision(class), m.Recall(class)
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
@@ -0,0 +1,230 @@
+package id3
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// LearnExternal runs the ID3 algorithm on CSV conformant data, with a header
+// row, without holding the data in memory. It learns the tree a level at a
+// time, reading all the data once per level to count the classes of each value
+// of each column at each decision still to be made. Memory is then bounded by
+// the size of those contingency tables rather than the number of rows, so an
+// *os.File much larger than memory can be learned from.
+//
+// The tree is the one Learn makes on the same data. It uses the logging,
+// tracing, statistics, time, criterion, impurity, dialect and column name
+// options, with a span named "id3.LearnExternal", but not WithSetValued,
+// WithSchema, WithParallelism, WithValidation, WithMissing or WithWeights.
+//
+func LearnExternal(r io.ReadSeeker, class string, opts ...Option) (*Decision, error) {
+	l := &learner{options: newOptions(opts), class: class, began: time.Now()}
+	l.span = l.start("id3.LearnExternal")
+	defer l.span.End()
+	if class == "" {
+		return nil, fmt.Errorf("%w: no name given", ErrClassColumnMissing)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	columns, err := l.csvReader(r).Read()
+	if err == io.EOF {
+		return nil, ErrEmptyView
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.headerless() {
+		columns = l.columnNames(columns)
+	}
+	classAt, err := find(columns, class)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	for _, c := range l.only {
+		if _, err := find(columns, c); err != nil {
+			return nil, err
+		}
+	}
+	//
+	// The root is a case to be decided by the first pass, through which every
+	// row passes.
+	//
+	root := &externalNode{available: make([]bool, len(columns))}
+	for i, c := range columns {
+		root.available[i] = i != classAt && c != "" && l.allowed(c)
+	}
+	pending := map[*Case]*externalNode{&root.Case: root}
+	for depth := 0; len(pending) > 0; depth++ {
+		t := time.Now()
+		if err := l.pass(r, pending, &root.Case, columns, classAt); err != nil {
+			return nil, err
+		}
+		if depth == 0 && root.contingency == nil {
+			return nil, ErrEmptyView
+		}
+		if depth == 0 && len(root.classes) == 1 && root.classes[""] == 0 {
+			//
+			// Every row has the same class, so there is nothing to learn.
+			//
+			for i, ok := range root.available {
+				if ok {
+					root.Decide = l.decidesOnly(columns[i], majority(root.classes))
+					break
+				}
+			}
+			if root.Decide != nil {
+				break
+			}
+		}
+		next := make(map[*Case]*externalNode)
+		for c, n := range pending {
+			l.split(c, n, columns, depth, next)
+		}
+		if len(next) > 0 && l.stop() {
+			//
+			// Out of time, so decide the most frequent class of each case.
+			//
+			for c := range next {
+				c.Class = majority(weighed(c.Counts))
+				l.stats.Leaves++
+			}
+			next = nil
+		}
+		pending = next
+		l.spent(depth, time.Since(t))
+	}
+	if root.Decide == nil {
+		return nil, fmt.Errorf("id3: no column to decide '%s' on", class)
+	}
+	l.finish()
+	l.span.SetAttributes(slog.String("class", class), slog.Int("nodes", l.stats.Nodes), slog.Int("depth", l.stats.Depth))
+	l.log(slog.LevelInfo, "id3: learned decision tree", "class", class, "nodes", l.stats.Nodes, "depth", l.stats.Depth, "duration", l.stats.Duration)
+	return root.Decide, nil
+}
+
+// externalNode is a case still to be decided by LearnExternal, with the class
+// frequencies of the rows reaching it, for all of them and by each value of
+// each column.
+//
+type externalNode struct {
+	Case
+	available []bool // Whether each column may be decided on.
+	*contingency
+}
+
+// pass reads all the rows after any header, counting each that reaches a
+// pending case.
+//
+func (l *learner) pass(r io.ReadSeeker, pending map[*Case]*externalNode, root *Case, columns []string, classAt int) error {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	cr := l.csvReader(r)
+	cr.ReuseRecord = true
+	if !l.headerless() {
+		if _, err := cr.Read(); err != nil {
+			return err
+		}
+	}
+	index := make(map[string]int)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			return fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+		}
+		if err != nil {
+			return err
+		}
+		//
+		// Follow the row through the decisions made so far.
+		//
+		c := root
+		for c != nil && c.Decide != nil {
+			d := c.Decide
+			i, ok := index[d.Column]
+			if !ok {
+				i = mustFind(columns, d.Column)
+				index[d.Column] = i
+			}
+			c = d.caseFor(row[i])
+		}
+		if n := pending[c]; n != nil {
+			n.count(row, classAt)
+			l.stats.Rows++
+		}
+	}
+}
+
+func (n *externalNode) count(row []string, classAt int) {
+	if n.contingency == nil {
+		n.contingency = newContingency(len(row))
+	}
+	n.add(row, classAt, n.available, 1)
+}
+
+// split makes the decision for the pending case from its counts, adding the
+// cases still to be decided to next.
+//
+func (l *learner) split(c *Case, n *externalNode, columns []string, depth int, next map[*Case]*externalNode) {
+	//
+	// Choose the column with the most gain, as Learn does, summing in the same
+	// order so that ties are broken the same way.
+	//
+	maxGain := -1.0
+	maxScore := -1.0
+	maxAt := -1
+	for i, ok := range n.available {
+		if !ok || !l.fits(n.counts[i]) {
+			continue
+		}
+		gain := n.gain(i, l.metric)
+		if score := l.score(columns[i], l.worth(gain, n.counts[i])); score > maxScore {
+			maxGain, maxScore = gain, score
+			maxAt = i
+		}
+	}
+	if maxAt < 0 || l.halts(depth, n.classes) {
+		//
+		// No column is left to decide on, or deciding must stop here, so
+		// decide the most frequent class.
+		//
+		c.Class = majority(n.classes)
+		l.stats.Leaves++
+		return
+	}
+	l.node(depth)
+	decision := &Decision{Column: columns[maxAt]}
+	if l.prior {
+		decision.Default = majority(n.classes)
+	}
+	for _, v := range n.likelihood(maxAt) {
+		counts := n.counts[maxAt][v.Value]
+		if len(counts) == 1 {
+			k := &Case{Value: v.Value, Counts: tally(counts)}
+			for class := range counts {
+				k.Class = class
+			}
+			decision.Cases = append(decision.Cases, k)
+			l.stats.Leaves++
+			continue
+		}
+		child := &externalNode{
+			Case:      Case{Value: v.Value, Counts: tally(counts)},
+			available: append([]bool(nil), n.available...),
+		}
+		child.available[maxAt] = false
+		decision.Cases = append(decision.Cases, &child.Case)
+		next[&child.Case] = child
+	}
+	c.Decide = decision
+	l.log(slog.LevelDebug, "id3: split", "column", decision.Column, "gain", maxGain, "cases", len(decision.Cases), "depth", depth)
+	l.span.AddEvent("split", slog.String("column", decision.Column), slog.Float64("gain", maxGain), slog.Int("cases", len(decision.Cases)), slog.Int("depth", depth))
+}
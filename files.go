@@ -0,0 +1,67 @@
+package id3
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFS reads a decision from the named file in the file system, in the format
+// given by the file extension as for LoadFile. This suits models embedded in
+// the program with go:embed.
+//
+func LoadFS(fsys fs.FS, path string) (*Decision, error) {
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFile(path, b)
+}
+
+// MustFromJSON is like FromJSON but panics if the JSON cannot be read. It
+// simplifies initializing package variables from embedded models.
+//
+func MustFromJSON(b []byte) *Decision {
+	d, err := FromJSON(b)
+	if err != nil {
+		panic("id3: MustFromJSON: " + err.Error())
+	}
+	return d
+}
+
+func encodeFile(path string, d *Decision) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return d.ToJSON(true)
+	case ".yaml", ".yml":
+		return d.ToYAML()
+	case ".gob":
+		return d.MarshalBinary()
+	case ".pb":
+		return d.ToProto()
+	case ".dot":
+		return d.ToDOT(true)
+	default:
+		return nil, fmt.Errorf("id3: unknown model file extension '%s'", filepath.Ext(path))
+	}
+}
+
+func decodeFile(path string, b []byte) (*Decision, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FromJSON(b)
+	case ".yaml", ".yml":
+		return FromYAML(b)
+	case ".gob":
+		d := new(Decision)
+		if err := d.UnmarshalBinary(b); err != nil {
+			return nil, err
+		}
+		return d, nil
+	case ".pb":
+		return FromProto(b)
+	default:
+		return nil, fmt.Errorf("id3: cannot load model file extension '%s'", filepath.Ext(path))
+	}
+}
@@ -0,0 +1,108 @@
+//go:build !js
+// +build !js
+
+package id3
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// The functions using the operating system's files are left out of builds for
+// JavaScript and WebAssembly, where LoadFS and FromJSON remain.
+
+// SaveFile writes the decision to the named file, in the format given by the
+// file extension: ".json", ".yaml" or ".yml", ".gob", ".pb" for protocol
+// buffers, or ".dot" for Graphviz. The file is written atomically, by renaming
+// a temporary file in the same directory, so readers never see a partial file.
+//
+func SaveFile(path string, d *Decision) error {
+	b, err := encodeFile(path, d)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b)
+}
+
+// LoadFile reads a decision from the named file, in the format given by the
+// file extension as for SaveFile. Graphviz files cannot be loaded.
+//
+func LoadFile(path string) (*Decision, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeFile(path, b)
+}
+
+// ReadParquetFile reads the named Parquet file as for ReadParquet.
+//
+func ReadParquetFile(path string, opts ...Option) (View, Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ReadParquet(f, info.Size(), opts...)
+}
+
+// SaveFileSigned saves the decision as for SaveFile, then writes its signature
+// to a detached file named with SignatureExt appended.
+//
+func SaveFileSigned(path string, d *Decision, key []byte) error {
+	if err := SaveFile(path, d); err != nil {
+		return err
+	}
+	return writeFileAtomic(path+SignatureExt, []byte(d.Sign(key)+"\n"))
+}
+
+// LoadFileSigned loads a decision as for LoadFile, then verifies it against the
+// detached signature file written by SaveFileSigned. It returns an error rather
+// than a decision if the signature is missing or does not match.
+//
+func LoadFileSigned(path string, key []byte) (*Decision, error) {
+	signature, err := os.ReadFile(path + SignatureExt)
+	if err != nil {
+		return nil, err
+	}
+	d, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Verify(key, string(signature)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	//
+	// Remove the temporary file on any failure; after the rename this fails
+	// harmlessly.
+	//
+	defer os.Remove(tmp)
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
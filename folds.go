@@ -0,0 +1,124 @@
+package id3
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A Fold is a partition of the rows of a view into those to learn from and
+// those held out to test on.
+//
+type Fold struct {
+	Train View // The rows to learn from.
+	Test  View // The rows held out.
+}
+
+// KFold partitions the rows of the view into k folds, each holding out a
+// different k-th of the rows for testing, so that every row is tested once.
+// The rows are dealt out to the folds in turn, in the order of the view, so a
+// view sorted on some column should be shuffled first. With a column to
+// stratify on, such as the class, the rows are dealt out in the order of its
+// values, so that each fold holds out about the same proportion of each value.
+// It fails if k is less than 2 or more than the number of rows, or with
+// ErrColumnNotFound if the view lacks the column to stratify on.
+//
+func KFold(view View, k int, stratifyOn string) ([]Fold, error) {
+	at := -1
+	if stratifyOn != "" {
+		var err error
+		if at, err = indexOf(view, stratifyOn); err != nil {
+			return nil, err
+		}
+	}
+	return kfold(rowsOf(view), k, at)
+}
+
+// kfold partitions the rows of the data, below the header, into k folds,
+// stratified on the column at the index unless it is negative.
+//
+func kfold(data [][]string, k int, at int) ([]Fold, error) {
+	if k < 2 {
+		return nil, errors.New("id3: cross validation needs at least 2 folds")
+	}
+	if len(data)-1 < k {
+		return nil, fmt.Errorf("id3: %d rows cannot make %d folds", len(data)-1, k)
+	}
+	rows := data[1:]
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	if at >= 0 {
+		sort.SliceStable(order, func(i, j int) bool { return rows[order[i]][at] < rows[order[j]][at] })
+	}
+	fold := make([]int, len(rows))
+	for rank, i := range order {
+		fold[i] = rank % k
+	}
+	folds := make([]Fold, k)
+	for f := range folds {
+		train, test := [][]string{data[0]}, [][]string{data[0]}
+		for i, row := range rows {
+			if fold[i] == f {
+				test = append(test, row)
+			} else {
+				train = append(train, row)
+			}
+		}
+		folds[f] = Fold{Train: newBaseView(train), Test: newBaseView(test)}
+	}
+	return folds, nil
+}
+
+// CrossValidate learns a decision for the class column from the training rows
+// of each of k folds of the view, stratified on the class, and returns the
+// mean and standard deviation of the fraction of the held out rows each decides
+// correctly. A held out row without a decision counts as wrong. The options are
+// given to Learn.
+//
+func CrossValidate(view View, class string, k int, opts ...Option) (mean, stddev float64, err error) {
+	classAt, err := indexOf(view, class)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	folds, err := KFold(view, k, class)
+	if err != nil {
+		return 0, 0, err
+	}
+	accuracy := make([]float64, len(folds))
+	for i, f := range folds {
+		correct, rows, err := f.score(class, classAt, opts)
+		if err != nil {
+			return 0, 0, err
+		}
+		accuracy[i] = float64(correct) / float64(rows)
+		mean += accuracy[i] / float64(len(folds))
+	}
+	for _, a := range accuracy {
+		stddev += (a - mean) * (a - mean) / float64(len(folds))
+	}
+	return mean, math.Sqrt(stddev), nil
+}
+
+// score learns from the training rows of the fold and returns how many of its
+// held out rows the decision decides correctly, and how many there are.
+//
+func (f Fold) score(class string, classAt int, opts []Option) (correct, rows int, err error) {
+	d, err := Learn(f.Train, class, opts...)
+	if err != nil {
+		return 0, 0, err
+	}
+	columns := f.Test.Columns()
+	index := make(map[string]int)
+	o := newOptions(opts)
+	f.Test.First()
+	for row := f.Test.Next(); row != nil; row = f.Test.Next() {
+		if c, ok := d.classify(row, columns, index, o); ok && c == row[classAt] {
+			correct++
+		}
+		rows++
+	}
+	return correct, rows, nil
+}
@@ -0,0 +1,125 @@
+package id3
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// A Forest is a committee of decision trees, each learned by LearnForest from a
+// different sample of the rows, that decide by majority vote.
+//
+type Forest struct {
+	Committee
+}
+
+// LearnForest learns n decision trees for the class column of the view, each
+// from a bootstrap sample of its rows and, at each split, from a random subset
+// of the columns it could decide on, as given by WithFeatureSampling or else
+// the square root of their number. The other options are those of Learn. The
+// forest is the same for the same seed.
+//
+func LearnForest(view View, class string, n int, opts ...Option) (*Forest, error) {
+	if n < 1 {
+		return nil, errors.New("id3: a forest needs at least one tree")
+	}
+	o := newOptions(opts)
+	k := o.sample
+	if k <= 0 {
+		eligible := 0
+		for _, c := range view.Columns() {
+			if c != class && c != "" && o.allowed(c) {
+				eligible++
+			}
+		}
+		k = int(math.Max(1, math.Round(math.Sqrt(float64(eligible)))))
+	}
+	r := rand.New(rand.NewSource(o.seed))
+	f := &Forest{Committee{Trees: make([]*Decision, n), Weights: make([]float64, n)}}
+	for i := range f.Trees {
+		tree, err := Learn(Bootstrap(view, r.Int63()), class, append(opts[:len(opts):len(opts)], WithFeatureSampling(k, r.Int63()))...)
+		if err != nil {
+			return nil, err
+		}
+		f.Trees[i], f.Weights[i] = tree, 1
+	}
+	return f, nil
+}
+
+// ForestFromCompact translates the given compact binary format, as from
+// ToCompact, into a forest.
+//
+func ForestFromCompact(b []byte) (*Forest, error) {
+	c, err := CommitteeFromCompact(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Forest{*c}, nil
+}
+
+// ForestFromProto translates the given protocol buffers Committee message, as
+// from ToProto, into a forest.
+//
+func ForestFromProto(b []byte) (*Forest, error) {
+	c, err := CommitteeFromProto(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Forest{*c}, nil
+}
+
+// WithFeatureSampling has Learn consider only k of the columns it could decide
+// on at each split, chosen at random from the seed, as the trees of a random
+// forest do. Zero, the default, considers every column.
+//
+func WithFeatureSampling(k int, seed int64) Option {
+	return func(o *options) { o.sample, o.seed = k, seed }
+}
+
+// considered returns the columns Learn may decide on at a split, or nil for
+// every one.
+//
+func (l *learner) considered(columns []string) map[string]bool {
+	if l.sample <= 0 {
+		return nil
+	}
+	var eligible []string
+	for _, c := range columns {
+		if c != l.class && c != "" && l.allowed(c) {
+			eligible = append(eligible, c)
+		}
+	}
+	if len(eligible) <= l.sample {
+		return nil
+	}
+	chosen := make(map[string]bool, l.sample)
+	for _, i := range l.source().Perm(len(eligible))[:l.sample] {
+		chosen[eligible[i]] = true
+	}
+	return chosen
+}
+
+// source returns the source of random numbers for choosing the columns
+// considered.
+//
+func (l *learner) source() *rand.Rand {
+	if l.random == nil {
+		l.random = rand.New(rand.NewSource(l.seed))
+	}
+	return l.random
+}
+
+// Bootstrap returns a view of as many rows as the view has, drawn from them at
+// random with replacement, so that some rows appear more than once and others
+// not at all. The sample is the same for the same seed.
+//
+func Bootstrap(view View, seed int64) View {
+	data := rowsOf(view)
+	r := rand.New(rand.NewSource(seed))
+	sample := make([][]string, len(data))
+	sample[0] = data[0]
+	for i := 1; i < len(data); i++ {
+		sample[i] = data[1+r.Intn(len(data)-1)]
+	}
+	return newBaseView(sample)
+}
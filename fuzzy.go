@@ -0,0 +1,85 @@
+package id3
+
+import "strings"
+
+// WithFuzzyMatch has DecideContext, Follow and Evaluate match a value without a
+// case to the case whose value is closest to it, before falling back to the
+// default class. The values are compared ignoring case and surrounding or
+// repeated spaces, then by the number of single character edits between them,
+// which must be at most distance. Ties go to the more probable case. This suits
+// inputs with frequent typos of known categories, such as "Sunny " or "suny"
+// for "sunny", at the risk of deciding a value that is genuinely new as a known
+// one. Decisions with a test are not affected.
+//
+func WithFuzzyMatch(distance int) Option {
+	return func(o *options) {
+		o.fuzzy = true
+		o.edits = distance
+	}
+}
+
+// closest returns the case whose value is closest to the given value, within
+// the distance, or nil if there is none.
+//
+func (d *Decision) closest(value string, distance int) *Case {
+	if d.Test != nil {
+		return nil
+	}
+	value = normalize(value)
+	var best *Case
+	min := distance + 1
+	for _, c := range d.Cases {
+		if n := editDistance(value, normalize(c.Value), min); n < min {
+			best, min = c, n
+		}
+	}
+	return best
+}
+
+// normalize returns the value in lower case, with spaces trimmed and any run of
+// them replaced by a single space.
+//
+func normalize(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(value)), " ")
+}
+
+// editDistance returns the Levenshtein distance between the strings, counting
+// characters rather than bytes, or limit if it is at least that.
+//
+func editDistance(a, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if n := len(s) - len(t); n >= limit || -n >= limit {
+		return limit
+	}
+	prev := make([]int, len(t)+1)
+	next := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range s {
+		next[0] = i + 1
+		least := next[0]
+		for j := range t {
+			cost := 1
+			if s[i] == t[j] {
+				cost = 0
+			}
+			next[j+1] = minOf(prev[j]+cost, prev[j+1]+1, next[j]+1)
+			least = minOf(least, next[j+1])
+		}
+		if least >= limit {
+			return limit
+		}
+		prev, next = next, prev
+	}
+	return minOf(prev[len(t)], limit)
+}
+
+func minOf(n int, others ...int) int {
+	for _, m := range others {
+		if m < n {
+			n = m
+		}
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package id3
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An AttributeGain is the worth of deciding on a column at the root of a tree.
+//
+type AttributeGain struct {
+	Column string
+	Gain   float64 // The information gain for the class, in bits.
+	Ratio  float64 // The gain relative to the split information, as for GainRatioOf.
+}
+
+// GainReport returns the information gain and gain ratio of each column the
+// view shows other than the class, as Learn weighs them for the root decision,
+// in decreasing gain and then by column. This helps choose the columns to
+// learn from before learning a whole tree. It fails with
+// ErrClassColumnMissing if the view has no such column, or ErrEmptyView if it
+// has no rows.
+//
+func GainReport(view View, class string) ([]AttributeGain, error) {
+	if _, err := indexOf(view, class); class == "" || err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	view.First()
+	if view.Next() == nil {
+		return nil, ErrEmptyView
+	}
+	total := TotalEntropy(view, class)
+	var report []AttributeGain
+	for _, c := range view.Columns() {
+		if c == "" || c == class {
+			continue
+		}
+		g := AttributeGain{Column: c, Gain: total - AverageEntropy(view, c, class)}
+		if split := TotalEntropy(view, c); split > 0 {
+			g.Ratio = g.Gain / split
+		}
+		report = append(report, g)
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Gain != report[j].Gain {
+			return report[i].Gain > report[j].Gain
+		}
+		return report[i].Column < report[j].Column
+	})
+	return report, nil
+}
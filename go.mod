@@ -1,3 +1,3 @@
 module github.com/gbkr-com/id3
 
-go 1.17
+go 1.21
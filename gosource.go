@@ -0,0 +1,112 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"math"
+	"strconv"
+)
+
+// ToGo returns this decision as the Go source of a file in the named package,
+// with a function of the given name:
+//
+//	func name(row map[string]string) (string, bool)
+//
+// The function returns the decided class for a row, keyed by column name, or
+// false if there is no rule for a value. Compiling the decision in this way
+// avoids loading a model at run time.
+//
+func (d *Decision) ToGo(pkg, name string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("id3: '%s' is not a valid package name", pkg)
+	}
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("id3: '%s' is not a valid function name", name)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by id3. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if d.hasThreshold() {
+		buf.WriteString("import \"strconv\"\n\n")
+	}
+	fmt.Fprintf(&buf, "// %s returns the decided class for the row, keyed by column name, or false\n", name)
+	buf.WriteString("// if there is no rule for a value.\n")
+	fmt.Fprintf(&buf, "func %s(row map[string]string) (string, bool) {\n", name)
+	if err := d.writeGo(&buf); err != nil {
+		return nil, err
+	}
+	buf.WriteString("return \"\", false\n}\n")
+	return format.Source(buf.Bytes())
+}
+
+func (d *Decision) writeGo(buf *bytes.Buffer) error {
+	if d.Test != nil {
+		return d.writeThresholdGo(buf)
+	}
+	fmt.Fprintf(buf, "switch row[%s] {\n", strconv.Quote(d.Column))
+	for _, c := range d.Cases {
+		fmt.Fprintf(buf, "case %s:\n", strconv.Quote(c.Value))
+		if err := c.writeGo(buf, d); err != nil {
+			return err
+		}
+	}
+	if d.Default != "" {
+		fmt.Fprintf(buf, "default:\nreturn %s, true\n", strconv.Quote(d.Default))
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// writeThresholdGo writes a decision with a "<=" test as an if statement,
+// parsing the value as the test does. Either case may be the default, which
+// the least number and a missing value match.
+//
+func (d *Decision) writeThresholdGo(buf *bytes.Buffer) error {
+	if d.Test.Op != opLessOrEqual || math.IsNaN(d.Test.threshold()) {
+		return fmt.Errorf("id3: Go source cannot express the test on '%s'", d.Column)
+	}
+	fmt.Fprintf(buf, "if v, err := strconv.ParseFloat(row[%s], 64); err == nil && v <= %s {\n", strconv.Quote(d.Column), strconv.FormatFloat(d.Test.threshold(), 'g', -1, 64))
+	if c := d.match("-inf"); c != nil {
+		if err := c.writeGo(buf, d); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("} else {\n")
+	if c := d.match(""); c != nil {
+		if err := c.writeGo(buf, d); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// writeGo writes the statements for the case of the decision.
+//
+func (c *Case) writeGo(buf *bytes.Buffer, d *Decision) error {
+	switch {
+	case c.Decide != nil:
+		return c.Decide.writeGo(buf)
+	case c.Class != "":
+		fmt.Fprintf(buf, "return %s, true\n", strconv.Quote(c.Class))
+		return nil
+	}
+	return fmt.Errorf("id3: case '%s' of '%s' has no class or decision", c.Value, d.Column)
+}
+
+// hasThreshold reports whether this decision, or any below it, has a "<="
+// test.
+//
+func (d *Decision) hasThreshold() bool {
+	if d.Test != nil && d.Test.Op == opLessOrEqual {
+		return true
+	}
+	for _, c := range d.Cases {
+		if c.Decide != nil && c.Decide.hasThreshold() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+)
+
+// ToHTML returns this decision as a standalone HTML page, where each decision
+// can be collapsed or expanded. Where the tree carries class frequencies from
+// learning, those are shown against each leaf.
+//
+func (d *Decision) ToHTML() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(htmlHead)
+	d.writeHTML(&buf)
+	buf.WriteString(htmlTail)
+	return buf.Bytes(), nil
+}
+
+func (d *Decision) writeHTML(buf *bytes.Buffer) {
+	buf.WriteString("<ul>\n")
+	for _, c := range d.rendered() {
+		label := html.EscapeString(d.condition(c))
+		if c.Decide != nil {
+			fmt.Fprintf(buf, "<li><details open><summary>%s</summary>\n", label)
+			c.Decide.writeHTML(buf)
+			buf.WriteString("</details></li>\n")
+			continue
+		}
+		fmt.Fprintf(buf, "<li>%s: <span class=\"class\">%s</span>", label, html.EscapeString(c.Class))
+		if len(c.Counts) > 0 {
+			fmt.Fprintf(buf, " <span class=\"counts\">%s</span>", html.EscapeString(formatCounts(c.Counts)))
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString("</ul>\n")
+}
+
+const htmlHead = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Decision tree</title>
+<style>
+body { font-family: sans-serif; }
+ul { list-style: none; padding-left: 1.5em; }
+summary { cursor: pointer; }
+.class { font-weight: bold; }
+.counts { color: #666; font-size: smaller; }
+</style>
+</head>
+<body>
+<button onclick="toggle(true)">Expand all</button>
+<button onclick="toggle(false)">Collapse all</button>
+`
+
+const htmlTail = `<script>
+function toggle(open) {
+	document.querySelectorAll("details").forEach(function (d) { d.open = open; });
+}
+</script>
+</body>
+</html>
+`
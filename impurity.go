@@ -0,0 +1,82 @@
+package id3
+
+// An Impurity measures how mixed the classes of some rows are, from the
+// probability of each class: zero where every row has the same class, and
+// greatest where each class is as likely as the others.
+//
+type Impurity interface {
+	Measure(probabilities []float64) float64
+}
+
+// The impurities.
+//
+var (
+	Shannon           Impurity = shannon{}           // The entropy in bits, as TotalEntropy measures it.
+	GiniIndex         Impurity = gini{}              // The chance of misclassifying a row labelled at random by the probabilities, as Gini measures it.
+	Misclassification Impurity = misclassification{} // The fraction of rows not of the most frequent class.
+)
+
+type shannon struct{}
+
+func (shannon) Measure(probabilities []float64) (h float64) {
+	for _, p := range probabilities {
+		h += Entropy(p)
+	}
+	return
+}
+
+type gini struct{}
+
+func (gini) Measure(probabilities []float64) float64 {
+	g := 1.0
+	for _, p := range probabilities {
+		g -= p * p
+	}
+	return g
+}
+
+type misclassification struct{}
+
+func (misclassification) Measure(probabilities []float64) float64 {
+	most := 0.0
+	for _, p := range probabilities {
+		if p > most {
+			most = p
+		}
+	}
+	return 1 - most
+}
+
+// WithImpurity has Learn choose each split by the decrease in the impurity of
+// the class, rather than in its entropy, which is the information gain. With
+// GiniIndex the tree is grown as by CART, though a decision on a column still
+// has a case for each value unless it is set-valued or numeric.
+//
+func WithImpurity(m Impurity) Option {
+	return func(o *options) { o.metric = m }
+}
+
+// Gini returns the Gini impurity of the class column in the view, the chance
+// that a row is misclassified if labelled at random by the class probabilities.
+//
+func Gini(view View, class string) float64 {
+	var p []float64
+	for _, v := range Likelihood(view, class) {
+		p = append(p, v.Probability)
+	}
+	return GiniIndex.Measure(p)
+}
+
+// impurityOf returns the impurity of the class frequencies by the measure, or
+// their entropy if it is nil, taking the classes in the order of Likelihood.
+//
+func impurityOf(m Impurity, classes map[string]float64) float64 {
+	if m == nil {
+		return likelihoodEntropy(classes)
+	}
+	var p []float64
+	for _, v := range likelihoodFrom(classes) {
+		p = append(p, v.Probability)
+	}
+	return m.Measure(p)
+}
@@ -0,0 +1,510 @@
+// Package parquet reads Apache Parquet files of flat tables, supporting just
+// enough of the format for the views of this module: the PLAIN and dictionary
+// encodings, without compression or with snappy or gzip.
+//
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// Type is the physical type of a column.
+//
+type Type int
+
+// The physical types.
+//
+const (
+	Boolean Type = iota
+	Int32
+	Int64
+	Int96
+	Float
+	Double
+	ByteArray
+	FixedLenByteArray
+)
+
+// The converted types, giving the meaning of a physical type, that change how
+// values are written as text.
+//
+const (
+	Decimal         = 5
+	Date            = 6
+	TimestampMillis = 9
+	TimestampMicros = 10
+	Uint8           = 11
+	Uint16          = 12
+	Uint32          = 13
+	Uint64          = 14
+)
+
+// A Column is a column of a file.
+//
+type Column struct {
+	Name      string
+	Type      Type
+	Converted int  // The converted type, or -1 if there is none.
+	Scale     int  // The digits after the point of a Decimal.
+	Length    int  // The length of a FixedLenByteArray.
+	Optional  bool // Whether values may be null.
+}
+
+// ErrFormat is returned for a file that is not Parquet, or uses a part of the
+// format that is not supported.
+//
+var ErrFormat = errors.New("id3: unsupported Parquet file")
+
+var magic = []byte("PAR1")
+
+// Read returns the columns of the file of the given size and its rows, with
+// each value written as text and each null as "". A Date is written as
+// "2006-01-02" and a timestamp in RFC 3339 format, in UTC.
+//
+func Read(r io.ReaderAt, size int64) ([]Column, [][]string, error) {
+	if size < 12 {
+		return nil, nil, ErrFormat
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, nil, err
+	}
+	n := int64(binary.LittleEndian.Uint32(tail))
+	if !bytes.Equal(tail[4:], magic) || n > size-12 {
+		return nil, nil, ErrFormat
+	}
+	footer := make([]byte, n)
+	if _, err := r.ReadAt(footer, size-8-n); err != nil {
+		return nil, nil, err
+	}
+	meta, _, err := decode(footer)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, err := schema(meta.list(2))
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows [][]string
+	for _, g := range meta.list(4) {
+		group, _ := g.(structure)
+		chunks := group.list(1)
+		if len(chunks) != len(columns) {
+			return nil, nil, fmt.Errorf("%w: row group has %d columns for %d", ErrFormat, len(chunks), len(columns))
+		}
+		count := int(group.int(3))
+		values := make([][]string, len(columns))
+		for i, c := range chunks {
+			chunk, _ := c.(structure)
+			if values[i], err = readChunk(r, size, chunk, columns[i]); err != nil {
+				return nil, nil, err
+			}
+			if len(values[i]) != count {
+				return nil, nil, fmt.Errorf("%w: column '%s' has %d values for %d rows", ErrFormat, columns[i].Name, len(values[i]), count)
+			}
+		}
+		for j := 0; j < count; j++ {
+			row := make([]string, len(columns))
+			for i := range columns {
+				row[i] = values[i][j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return columns, rows, nil
+}
+
+// schema returns the columns of the schema elements, the first of which is
+// the root.
+//
+func schema(elements []any) ([]Column, error) {
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("%w: no schema", ErrFormat)
+	}
+	var columns []Column
+	for _, e := range elements[1:] {
+		s, _ := e.(structure)
+		c := Column{Name: s.str(4), Type: Type(s.int(1)), Converted: -1, Scale: int(s.int(7)), Length: int(s.int(2))}
+		if s.int(5) > 0 {
+			return nil, fmt.Errorf("%w: column '%s' is nested", ErrFormat, c.Name)
+		}
+		switch s.int(3) {
+		case 1:
+			c.Optional = true
+		case 2:
+			return nil, fmt.Errorf("%w: column '%s' is repeated", ErrFormat, c.Name)
+		}
+		if s.has(6) {
+			c.Converted = int(s.int(6))
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// Page types.
+//
+const (
+	dataPage       = 0
+	dictionaryPage = 2
+	dataPageV2     = 3
+)
+
+// readChunk returns the values of the column in a row group.
+//
+func readChunk(r io.ReaderAt, size int64, chunk structure, c Column) ([]string, error) {
+	if chunk.has(1) {
+		return nil, fmt.Errorf("%w: column '%s' is in another file", ErrFormat, c.Name)
+	}
+	meta := chunk.sub(3)
+	start := meta.int(9)
+	if offset := meta.int(11); offset > 0 && offset < start {
+		start = offset
+	}
+	length := meta.int(7)
+	if start < 0 || length < 0 || start+length > size {
+		return nil, fmt.Errorf("%w: column '%s' is outside the file", ErrFormat, c.Name)
+	}
+	b := make([]byte, length)
+	if _, err := r.ReadAt(b, start); err != nil {
+		return nil, err
+	}
+	codec := meta.int(4)
+	var dictionary, values []string
+	for want := int(meta.int(5)); len(values) < want; {
+		header, n, err := decode(b)
+		if err != nil {
+			return nil, err
+		}
+		b = b[n:]
+		compressed := int(header.int(3))
+		if compressed < 0 || compressed > len(b) {
+			return nil, fmt.Errorf("%w: page of column '%s' is truncated", ErrFormat, c.Name)
+		}
+		body := b[:compressed]
+		b = b[compressed:]
+		switch header.int(1) {
+		case dictionaryPage:
+			data, err := decompress(codec, body)
+			if err != nil {
+				return nil, err
+			}
+			count := int(header.sub(7).int(1))
+			if count < 0 || count > len(data) {
+				return nil, fmt.Errorf("%w: dictionary of column '%s' is truncated", ErrFormat, c.Name)
+			}
+			if dictionary, err = plain(data, c, count); err != nil {
+				return nil, err
+			}
+		case dataPage:
+			data, err := decompress(codec, body)
+			if err != nil {
+				return nil, err
+			}
+			h := header.sub(5)
+			count := int(h.int(1))
+			if count < 0 || count > want-len(values) {
+				return nil, fmt.Errorf("%w: column '%s' has too many values", ErrFormat, c.Name)
+			}
+			var defined []int
+			if c.Optional {
+				if len(data) < 4 {
+					return nil, fmt.Errorf("%w: page of column '%s' is truncated", ErrFormat, c.Name)
+				}
+				n := int(binary.LittleEndian.Uint32(data))
+				if n > len(data)-4 {
+					return nil, fmt.Errorf("%w: page of column '%s' is truncated", ErrFormat, c.Name)
+				}
+				if defined, err = hybrid(data[4:4+n], 1, count); err != nil {
+					return nil, err
+				}
+				data = data[4+n:]
+			}
+			if values, err = appendPage(values, data, int(h.int(2)), count, defined, dictionary, c); err != nil {
+				return nil, err
+			}
+		case dataPageV2:
+			h := header.sub(8)
+			count := int(h.int(1))
+			if count < 0 || count > want-len(values) {
+				return nil, fmt.Errorf("%w: column '%s' has too many values", ErrFormat, c.Name)
+			}
+			levels, repeats := int(h.int(5)), int(h.int(6))
+			if levels < 0 || repeats < 0 || levels+repeats > len(body) {
+				return nil, fmt.Errorf("%w: page of column '%s' is truncated", ErrFormat, c.Name)
+			}
+			var defined []int
+			if c.Optional {
+				if defined, err = hybrid(body[repeats:repeats+levels], 1, count); err != nil {
+					return nil, err
+				}
+			}
+			data := body[repeats+levels:]
+			if !h.has(7) || h.bool(7) {
+				if data, err = decompress(codec, data); err != nil {
+					return nil, err
+				}
+			}
+			if values, err = appendPage(values, data, int(h.int(4)), count, defined, dictionary, c); err != nil {
+				return nil, err
+			}
+		}
+		if len(b) == 0 && len(values) < want {
+			return nil, fmt.Errorf("%w: column '%s' is truncated", ErrFormat, c.Name)
+		}
+	}
+	return values, nil
+}
+
+// Encodings.
+//
+const (
+	plainEncoding         = 0
+	plainDictionary       = 2
+	rleDictionaryEncoding = 8
+)
+
+// Compression codecs.
+//
+const (
+	uncompressed = 0
+	snappy       = 1
+	gzipped      = 2
+)
+
+// appendPage appends the count values of a data page to values, with "" for each
+// null, by the definition level of each value. Without definition levels, all
+// the values are defined.
+//
+func appendPage(values []string, data []byte, encoding, count int, defined []int, dictionary []string, c Column) ([]string, error) {
+	if defined != nil {
+		count = 0
+		for _, d := range defined {
+			count += d
+		}
+	}
+	var decoded []string
+	switch encoding {
+	case plainEncoding:
+		var err error
+		if decoded, err = plain(data, c, count); err != nil {
+			return nil, err
+		}
+	case plainDictionary, rleDictionaryEncoding:
+		if len(data) == 0 {
+			return nil, fmt.Errorf("%w: page of column '%s' is truncated", ErrFormat, c.Name)
+		}
+		indices, err := hybrid(data[1:], int(data[0]), count)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indices {
+			if i >= len(dictionary) {
+				return nil, fmt.Errorf("%w: column '%s' has no dictionary entry %d", ErrFormat, c.Name, i)
+			}
+			decoded = append(decoded, dictionary[i])
+		}
+	default:
+		return nil, fmt.Errorf("%w: column '%s' has encoding %d", ErrFormat, c.Name, encoding)
+	}
+	if defined == nil {
+		return append(values, decoded...), nil
+	}
+	for _, d := range defined {
+		switch d {
+		case 0:
+			values = append(values, "")
+		default:
+			values = append(values, decoded[0])
+			decoded = decoded[1:]
+		}
+	}
+	return values, nil
+}
+
+func decompress(codec int64, b []byte) ([]byte, error) {
+	switch codec {
+	case uncompressed:
+		return b, nil
+	case snappy:
+		return unsnappy(b)
+	case gzipped:
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+	return nil, fmt.Errorf("%w: compression codec %d", ErrFormat, codec)
+}
+
+// hybrid returns count values of the bit width from the run length encoding
+// and bit packing hybrid.
+//
+func hybrid(b []byte, width, count int) ([]int, error) {
+	if width > 32 {
+		return nil, fmt.Errorf("%w: bit width %d", ErrFormat, width)
+	}
+	values := make([]int, 0, capacity(count, b))
+	for len(values) < count {
+		header, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("%w: run length encoding is truncated", ErrFormat)
+		}
+		b = b[n:]
+		if header&1 == 0 {
+			//
+			// A run of the same value, in as few bytes as hold it.
+			//
+			bytes := (width + 7) / 8
+			if len(b) < bytes {
+				return nil, fmt.Errorf("%w: run length encoding is truncated", ErrFormat)
+			}
+			v := 0
+			for i := 0; i < bytes; i++ {
+				v |= int(b[i]) << (8 * i)
+			}
+			b = b[bytes:]
+			for run := header >> 1; run > 0 && len(values) < count; run-- {
+				values = append(values, v)
+			}
+			continue
+		}
+		//
+		// Groups of eight values, packed from the least significant bit.
+		//
+		packed := int(header>>1) * 8
+		bit := 0
+		for i := 0; i < packed && len(values) < count; i++ {
+			v := 0
+			for j := 0; j < width; j, bit = j+1, bit+1 {
+				if bit/8 >= len(b) {
+					return nil, fmt.Errorf("%w: bit packed encoding is truncated", ErrFormat)
+				}
+				v |= int(b[bit/8]>>(bit%8)&1) << j
+			}
+			values = append(values, v)
+		}
+		if skip := int(header>>1) * width; skip < len(b) {
+			b = b[skip:]
+		} else {
+			b = nil
+		}
+	}
+	return values, nil
+}
+
+// plain returns count values of the column in the PLAIN encoding.
+//
+func plain(b []byte, c Column, count int) ([]string, error) {
+	truncated := fmt.Errorf("%w: values of column '%s' are truncated", ErrFormat, c.Name)
+	values := make([]string, 0, capacity(count, b))
+	fixed := map[Type]int{Int32: 4, Int64: 8, Int96: 12, Float: 4, Double: 8, FixedLenByteArray: c.Length}
+	for i := 0; i < count; i++ {
+		if c.Type == Boolean {
+			if i/8 >= len(b) {
+				return nil, truncated
+			}
+			values = append(values, strconv.FormatBool(b[i/8]>>(i%8)&1 == 1))
+			continue
+		}
+		n, ok := fixed[c.Type]
+		if !ok {
+			if len(b) < 4 {
+				return nil, truncated
+			}
+			n = int(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		}
+		if n < 0 || len(b) < n {
+			return nil, truncated
+		}
+		v, err := c.format(b[:n])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values, nil
+}
+
+// capacity returns the count of values to allocate for, but no more than the
+// bits of the bytes they are decoded from, so that a corrupt count does not
+// exhaust memory before the values are found to be truncated.
+//
+func capacity(count int, b []byte) int {
+	if count > 8*len(b) {
+		return 8 * len(b)
+	}
+	return count
+}
+
+// julianEpoch is the Julian day of 1970-01-01.
+//
+const julianEpoch = 2440588
+
+// format returns a value of the column as text.
+//
+func (c Column) format(b []byte) (string, error) {
+	var v int64
+	switch c.Type {
+	case Int32:
+		v = int64(int32(binary.LittleEndian.Uint32(b)))
+	case Int64:
+		v = int64(binary.LittleEndian.Uint64(b))
+	case Int96:
+		nanos := int64(binary.LittleEndian.Uint64(b))
+		days := int64(binary.LittleEndian.Uint32(b[8:])) - julianEpoch
+		return time.Unix(days*86400, nanos).UTC().Format(time.RFC3339Nano), nil
+	case Float:
+		return strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), 'g', -1, 32), nil
+	case Double:
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)), 'g', -1, 64), nil
+	default:
+		if c.Converted == Decimal {
+			//
+			// A big endian two's complement integer.
+			//
+			n := new(big.Int).SetBytes(b)
+			if len(b) > 0 && b[0]&0x80 != 0 {
+				n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+			}
+			return decimal(n, c.Scale), nil
+		}
+		return string(b), nil
+	}
+	switch c.Converted {
+	case Decimal:
+		return decimal(big.NewInt(v), c.Scale), nil
+	case Date:
+		return time.Unix(v*86400, 0).UTC().Format("2006-01-02"), nil
+	case TimestampMillis:
+		return time.UnixMilli(v).UTC().Format(time.RFC3339Nano), nil
+	case TimestampMicros:
+		return time.UnixMicro(v).UTC().Format(time.RFC3339Nano), nil
+	case Uint8, Uint16, Uint32:
+		return strconv.FormatUint(uint64(uint32(v)), 10), nil
+	case Uint64:
+		return strconv.FormatUint(uint64(v), 10), nil
+	}
+	return strconv.FormatInt(v, 10), nil
+}
+
+// decimal returns the integer with the point scale digits from the right.
+//
+func decimal(n *big.Int, scale int) string {
+	if scale <= 0 {
+		return n.String()
+	}
+	return new(big.Rat).SetFrac(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)).FloatString(scale)
+}
@@ -0,0 +1,93 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errSnappy = errors.New("id3: corrupt snappy data in Parquet file")
+
+// maxExpansion bounds the bytes of output for each byte of a snappy block, as
+// the most a copy writes is 64 bytes for a 3 byte element.
+//
+const maxExpansion = 22
+
+// unsnappy decompresses a snappy block. A block whose declared length is more
+// than it could expand to is corrupt, rather than allocated for.
+//
+func unsnappy(b []byte) ([]byte, error) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 {
+		return nil, errSnappy
+	}
+	b = b[k:]
+	if n > uint64(len(b))*maxExpansion {
+		return nil, errSnappy
+	}
+	out := make([]byte, 0, n)
+	for len(b) > 0 {
+		tag := b[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			//
+			// A literal, whose length less one is in the tag or, from 60,
+			// in the 1 to 4 bytes after it.
+			//
+			length = int(tag>>2) + 1
+			b = b[1:]
+			if length > 60 {
+				extra := length - 60
+				if len(b) < extra {
+					return nil, errSnappy
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(b[i])
+				}
+				length++
+				b = b[extra:]
+			}
+			if length <= 0 || len(b) < length {
+				return nil, errSnappy
+			}
+			out = append(out, b[:length]...)
+			b = b[length:]
+			continue
+		case 1:
+			if len(b) < 2 {
+				return nil, errSnappy
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag>>5)<<8 | int(b[1])
+			b = b[2:]
+		case 2:
+			if len(b) < 3 {
+				return nil, errSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(b[1:]))
+			b = b[3:]
+		case 3:
+			if len(b) < 5 {
+				return nil, errSnappy
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(b[1:]))
+			b = b[5:]
+		}
+		//
+		// A copy of earlier output, which may overlap what it writes.
+		//
+		if offset <= 0 || offset > len(out) {
+			return nil, errSnappy
+		}
+		for i := 0; i < length; i++ {
+			out = append(out, out[len(out)-offset])
+		}
+	}
+	if uint64(len(out)) != n {
+		return nil, errSnappy
+	}
+	return out, nil
+}
@@ -0,0 +1,201 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// errTruncated is returned when the metadata ends part way through a value.
+//
+var errTruncated = errors.New("id3: truncated Parquet metadata")
+
+// A structure is a Thrift struct decoded from the compact protocol, holding
+// the value of each field by its id: an int64 for the integer types, a bool,
+// a float64, a []byte, a []any or a structure. Maps are skipped.
+//
+type structure map[int16]any
+
+func (s structure) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s structure) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s structure) bool(id int16) bool {
+	v, _ := s[id].(bool)
+	return v
+}
+
+func (s structure) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s structure) list(id int16) []any {
+	v, _ := s[id].([]any)
+	return v
+}
+
+func (s structure) sub(id int16) structure {
+	v, _ := s[id].(structure)
+	return v
+}
+
+// Thrift compact protocol types.
+//
+const (
+	typeTrue   = 1
+	typeFalse  = 2
+	typeByte   = 3
+	typeI16    = 4
+	typeI32    = 5
+	typeI64    = 6
+	typeDouble = 7
+	typeBinary = 8
+	typeList   = 9
+	typeSet    = 10
+	typeMap    = 11
+	typeStruct = 12
+)
+
+// decoder reads the Thrift compact protocol, noting the first error.
+//
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// decode returns the structure at the start of b and the number of bytes it
+// takes.
+//
+func decode(b []byte) (structure, int, error) {
+	d := &decoder{b: b}
+	s := d.structure()
+	if d.err != nil {
+		return nil, 0, d.err
+	}
+	return s, len(b) - len(d.b), nil
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.b = nil
+}
+
+func (d *decoder) byte() byte {
+	if len(d.b) == 0 {
+		d.fail(errTruncated)
+		return 0
+	}
+	c := d.b[0]
+	d.b = d.b[1:]
+	return c
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.fail(errTruncated)
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// size returns the number of elements of a list or map, which must each take
+// at least one byte.
+//
+func (d *decoder) size(n uint64) int {
+	if n > uint64(len(d.b)) {
+		d.fail(errTruncated)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) structure() structure {
+	s := make(structure)
+	var id int16
+	for d.err == nil {
+		header := d.byte()
+		if header == 0 {
+			break
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(d.varint())
+		}
+		switch t := header & 0x0f; t {
+		case typeTrue, typeFalse:
+			s[id] = t == typeTrue
+		default:
+			s[id] = d.value(t)
+		}
+	}
+	return s
+}
+
+func (d *decoder) value(t byte) any {
+	switch t {
+	case typeTrue, typeFalse:
+		//
+		// Only list elements, each a byte of 1 for true.
+		//
+		return d.byte() == typeTrue
+	case typeByte:
+		return int64(int8(d.byte()))
+	case typeI16, typeI32, typeI64:
+		return d.varint()
+	case typeDouble:
+		if len(d.b) < 8 {
+			d.fail(errTruncated)
+			return nil
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.b))
+		d.b = d.b[8:]
+		return v
+	case typeBinary:
+		n := d.size(d.uvarint())
+		v := d.b[:n:n]
+		d.b = d.b[n:]
+		return v
+	case typeList, typeSet:
+		header := d.byte()
+		n := uint64(header >> 4)
+		if n == 15 {
+			n = d.uvarint()
+		}
+		v := make([]any, d.size(n))
+		for i := range v {
+			v[i] = d.value(header & 0x0f)
+		}
+		return v
+	case typeMap:
+		n := d.size(d.uvarint())
+		if n > 0 {
+			types := d.byte()
+			for i := 0; i < n && d.err == nil; i++ {
+				d.value(types >> 4)
+				d.value(types & 0x0f)
+			}
+		}
+		return nil
+	case typeStruct:
+		return d.structure()
+	}
+	d.fail(errors.New("id3: unknown Thrift type in Parquet metadata"))
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package wire reads and writes the protocol buffers wire format, supporting
+// just enough of it for the model encodings and services of this module.
+//
+package wire
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Writer appends fields in the protocol buffers wire format.
+//
+type Writer struct {
+	b []byte
+}
+
+// Encoded returns the fields written so far.
+//
+func (w *Writer) Encoded() []byte { return w.b }
+
+// Protocol buffers wire types.
+//
+const (
+	TypeVarint  = 0
+	TypeFixed64 = 1
+	TypeBytes   = 2
+	TypeFixed32 = 5
+)
+
+func (w *Writer) tag(field, wire int) {
+	w.b = appendUvarint(w.b, uint64(field)<<3|uint64(wire))
+}
+
+// Varint writes an integer field of a varint type such as int64 or bool.
+//
+func (w *Writer) Varint(field int, v int64) {
+	w.tag(field, TypeVarint)
+	w.b = appendUvarint(w.b, uint64(v))
+}
+
+// Bytes writes a length delimited field.
+//
+func (w *Writer) Bytes(field int, b []byte) {
+	w.tag(field, TypeBytes)
+	w.b = appendUvarint(w.b, uint64(len(b)))
+	w.b = append(w.b, b...)
+}
+
+// String writes a string field.
+//
+func (w *Writer) String(field int, s string) {
+	w.Bytes(field, []byte(s))
+}
+
+// Message writes an embedded message field.
+//
+func (w *Writer) Message(field int, m *Writer) {
+	w.Bytes(field, m.b)
+}
+
+// Float writes a float field.
+//
+func (w *Writer) Float(field int, f float32) {
+	w.tag(field, TypeFixed32)
+	w.b = appendFixed32(w.b, math.Float32bits(f))
+}
+
+// Double writes a double field.
+//
+func (w *Writer) Double(field int, f float64) {
+	w.tag(field, TypeFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+	w.b = append(w.b, buf[:]...)
+}
+
+// PackedVarints writes a packed repeated field of a varint type.
+//
+func (w *Writer) PackedVarints(field int, v []int64) {
+	var p []byte
+	for _, x := range v {
+		p = appendUvarint(p, uint64(x))
+	}
+	w.Bytes(field, p)
+}
+
+// PackedFloats writes a packed repeated float field.
+//
+func (w *Writer) PackedFloats(field int, v []float32) {
+	var p []byte
+	for _, x := range v {
+		p = appendFixed32(p, math.Float32bits(x))
+	}
+	w.Bytes(field, p)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendFixed32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// Reader reads fields in the protocol buffers wire format.
+//
+type Reader struct {
+	b []byte
+}
+
+// NewReader returns a reader of the fields in b.
+//
+func NewReader(b []byte) *Reader { return &Reader{b: b} }
+
+// ErrTruncated is returned when the input ends part way through a field.
+//
+var ErrTruncated = errors.New("id3: truncated protocol buffer")
+
+// Next returns the next field number and wire type, and either the varint or
+// fixed value, or the bytes for length delimited fields.
+//
+func (r *Reader) Next() (field, wire int, v uint64, b []byte, err error) {
+	key, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		return 0, 0, 0, nil, ErrTruncated
+	}
+	r.b = r.b[n:]
+	field, wire = int(key>>3), int(key&7)
+	switch wire {
+	case TypeVarint:
+		v, n = binary.Uvarint(r.b)
+		if n <= 0 {
+			return 0, 0, 0, nil, ErrTruncated
+		}
+		r.b = r.b[n:]
+	case TypeFixed64:
+		if len(r.b) < 8 {
+			return 0, 0, 0, nil, ErrTruncated
+		}
+		v = binary.LittleEndian.Uint64(r.b)
+		r.b = r.b[8:]
+	case TypeFixed32:
+		if len(r.b) < 4 {
+			return 0, 0, 0, nil, ErrTruncated
+		}
+		v = uint64(binary.LittleEndian.Uint32(r.b))
+		r.b = r.b[4:]
+	case TypeBytes:
+		v, n = binary.Uvarint(r.b)
+		if n <= 0 || uint64(len(r.b)-n) < v {
+			return 0, 0, 0, nil, ErrTruncated
+		}
+		b = r.b[n : n+int(v)]
+		r.b = r.b[n+int(v):]
+	default:
+		return 0, 0, 0, nil, fmt.Errorf("id3: unsupported protocol buffer wire type %d", wire)
+	}
+	return field, wire, v, b, nil
+}
+
+// More returns whether there are more fields to read.
+//
+func (r *Reader) More() bool { return len(r.b) > 0 }
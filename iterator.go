@@ -0,0 +1,60 @@
+package id3
+
+// An Iterator reads the rows of a view with a cursor of its own, rather than
+// the one of First and Next that the view shares with everything reading it.
+// Several iterators can then read the same view at once, such as in different
+// goroutines, without disturbing each other or a reader using First and Next.
+//
+type Iterator struct {
+	next func() []string
+}
+
+// Rows returns an iterator over the rows of the view. The views of this
+// package are read by the iterator as it goes. Other views are read at once
+// with First and Next, and the iterator reads the rows remembered.
+//
+func Rows(view View) *Iterator {
+	return &Iterator{next: rowsIn(view)}
+}
+
+// Next returns the next row, or nil if there are no more rows.
+//
+func (it *Iterator) Next() []string {
+	row := it.next()
+	if row == nil {
+		it.next = func() []string { return nil }
+	}
+	return row
+}
+
+// iterable is implemented by the views of this package, which can return each
+// of their rows in turn without using their cursor. The function returned must
+// not change the view, since several may be reading it at once.
+//
+type iterable interface {
+	rows() func() []string
+}
+
+// rowsIn returns a function returning each row of the view in turn, and then
+// nil.
+//
+func rowsIn(view View) func() []string {
+	if v, ok := view.(iterable); ok {
+		return v.rows()
+	}
+	data := rowsOf(view)
+	return (&baseView{data: data}).rows()
+}
+
+// filter returns a function returning each row from next that is kept.
+//
+func filter(next func() []string, keep func(row []string) bool) func() []string {
+	return func() []string {
+		for {
+			row := next()
+			if row == nil || keep(row) {
+				return row
+			}
+		}
+	}
+}
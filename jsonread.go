@@ -0,0 +1,84 @@
+package id3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReadJSON reads a JSON array of records from the given reader and returns a
+// view on them, as for ReadJSONLines.
+//
+func ReadJSON(reader io.Reader, opts ...Option) (View, error) {
+	o := newOptions(opts)
+	span := o.start("id3.ReadJSON")
+	defer span.End()
+	d := json.NewDecoder(reader)
+	d.UseNumber()
+	var records []map[string]any
+	if err := d.Decode(&records); err != nil {
+		return nil, err
+	}
+	return readRecords(records, o)
+}
+
+// ReadJSONLines reads JSON records, one to a line, from the given reader and
+// returns a view on them. Each record is an object whose fields are strings,
+// numbers, booleans or null, and the columns are the names of all the fields,
+// in sorted order. A number is written as in the JSON, and a field that is null
+// or not in a record is a missing value. With WithMissingMarkers, the markers
+// are read as missing values. It fails with ErrSchemaMismatch if a field has
+// any other kind of value.
+//
+func ReadJSONLines(reader io.Reader, opts ...Option) (View, error) {
+	o := newOptions(opts)
+	span := o.start("id3.ReadJSONLines")
+	defer span.End()
+	d := json.NewDecoder(reader)
+	d.UseNumber()
+	var records []map[string]any
+	for {
+		var r map[string]any
+		err := d.Decode(&r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return readRecords(records, o)
+}
+
+// readRecords returns the view on the records decoded from JSON.
+//
+func readRecords(records []map[string]any, o *options) (View, error) {
+	values := make([]map[string]string, len(records))
+	for i, r := range records {
+		values[i] = make(map[string]string, len(r))
+		for c, v := range r {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				values[i][c] = v
+			case json.Number:
+				values[i][c] = v.String()
+			case bool:
+				values[i][c] = fmt.Sprint(v)
+			default:
+				return nil, fmt.Errorf("%w: field '%s' of record %d is not a string, number or boolean", ErrSchemaMismatch, c, i+1)
+			}
+		}
+	}
+	view, err := NewViewFromMaps(values, nil)
+	if err != nil {
+		return nil, err
+	}
+	o.unmark(view.(*baseView).data)
+	if o.logger != nil {
+		warn(view, o)
+	}
+	return view, nil
+}
@@ -1,8 +1,13 @@
 package id3
 
 import (
+	"fmt"
+	"log/slog"
 	"math"
+	"math/rand"
 	"sort"
+	"sync"
+	"time"
 )
 
 // Distinct is a distinct column value and its associated probability.
@@ -13,44 +18,56 @@ type Distinct struct {
 }
 
 // Likelihood returns the probability of each distinct value in the named column
-// of the view. The slice is sorted in decreasing probability.
+// of the view. The slice is sorted in decreasing probability, then by value.
 //
 func Likelihood(view View, column string) []Distinct {
 	//
 	// Find the distinct values and count the frequency.
 	//
-	i := find(view.Columns(), column)
-	distinct := make(map[string]float64)
+	distinct := Frequency(view, column)
 	total := 0.0
-	view.First()
-	for {
-		row := view.Next()
-		if row == nil {
-			break
-		}
-		v := row[i]
-		if _, ok := distinct[v]; !ok {
-			distinct[v] = 0
-		}
-		distinct[v]++
-		total++
+	for _, v := range distinct {
+		total += float64(v)
 	}
 	//
 	// Convert the map to a slice, then sort.
 	//
 	var sorted []Distinct
 	for k, v := range distinct {
-		sorted = append(sorted, Distinct{Value: k, Probability: v / total})
+		sorted = append(sorted, Distinct{Value: k, Probability: float64(v) / total})
 	}
 	sort.Slice(
 		sorted,
 		func(i, j int) bool {
+			//
+			// Break ties on the value, so the order is deterministic.
+			//
+			if sorted[i].Probability == sorted[j].Probability {
+				return sorted[i].Value < sorted[j].Value
+			}
 			return sorted[i].Probability > sorted[j].Probability
 		},
 	)
 	return sorted
 }
 
+// Frequency returns the number of rows in the view having each distinct value
+// in the named column.
+//
+func Frequency(view View, column string) map[string]int {
+	i := mustIndexOf(view, column)
+	distinct := make(map[string]int)
+	view.First()
+	for {
+		row := view.Next()
+		if row == nil {
+			break
+		}
+		distinct[row[i]]++
+	}
+	return distinct
+}
+
 // Entropy returns the Shannon entropy for the given probability. It converts
 // the edge cases of probability zero and one to a zero entropy value.
 //
@@ -77,9 +94,9 @@ func TotalEntropy(view View, class string) (h float64) {
 //
 func AverageEntropy(view View, attribute, class string) (h float64) {
 	//
-	// Use find to confirm the class column exists.
+	// Confirm the class column exists.
 	//
-	find(view.Columns(), class)
+	mustIndexOf(view, class)
 	//
 	// Calculate the probability weighted class entropy for each of the
 	// distinct values.
@@ -91,57 +108,521 @@ func AverageEntropy(view View, attribute, class string) (h float64) {
 }
 
 // Learn runs the ID3 algorithm on the given view using the named class column.
+// Where rows that agree on every column have different classes, it decides the
+// most frequent of them. Where every row has the same class, as a single row
+// does, the decision has no cases and decides that class by default, with a
+// warning to any logger. It fails with ErrClassColumnMissing if the view has no
+// such column, or ErrEmptyView if it has no rows.
+//
+func Learn(view View, class string, opts ...Option) (*Decision, error) {
+	l := &learner{options: newOptions(opts), class: class, began: time.Now()}
+	l.span = l.start("id3.Learn")
+	defer l.span.End()
+	if class == "" {
+		return nil, fmt.Errorf("%w: no name given", ErrClassColumnMissing)
+	}
+	if _, err := indexOf(view, class); err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	view, err := l.weigh(view)
+	if err != nil {
+		return nil, err
+	}
+	view = l.excludeMissing(l.number(view))
+	view.First()
+	if view.Next() == nil {
+		return nil, ErrEmptyView
+	}
+	for _, c := range l.only {
+		if _, err := indexOf(view, c); err != nil {
+			return nil, err
+		}
+	}
+	if l.valid != nil {
+		for _, c := range view.Columns() {
+			if _, err := indexOf(l.valid, c); c != "" && err != nil {
+				return nil, fmt.Errorf("%w: validation view has no column '%s'", ErrSchemaMismatch, c)
+			}
+		}
+	}
+	valid := l.valid
+	if valid != nil {
+		valid = l.excludeMissing(valid)
+	}
+	d := l.trivial(view)
+	if d == nil {
+		d = l.learn(view, valid, 0)
+	}
+	if d == nil {
+		return nil, fmt.Errorf("id3: no column to decide '%s' on", class)
+	}
+	l.finish()
+	l.save(d)
+	l.span.SetAttributes(slog.String("class", class), slog.Int("nodes", l.stats.Nodes), slog.Int("depth", l.stats.Depth))
+	l.log(slog.LevelInfo, "id3: learned decision tree", "class", class, "nodes", l.stats.Nodes, "depth", l.stats.Depth, "duration", l.stats.Duration)
+	return d, nil
+}
+
+// trivial returns the decision for a view whose rows all have the same class,
+// or nil if they do not or there is no column to decide on. It decides that
+// class by default for every value of the first column that could be decided
+// on.
+//
+func (l *learner) trivial(view View) *Decision {
+	at := mustIndexOf(view, l.class)
+	class := ""
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		if class != "" && row[at] != class {
+			return nil
+		}
+		class = row[at]
+	}
+	if class == "" {
+		return nil
+	}
+	for _, c := range view.Columns() {
+		if c != l.class && c != "" && l.allowed(c) {
+			return l.decidesOnly(c, class)
+		}
+	}
+	return nil
+}
+
+// decidesOnly returns the decision on the column deciding the class for every
+// value, noting that there was nothing to learn.
+//
+func (l *learner) decidesOnly(column, class string) *Decision {
+	l.stats.Leaves++
+	l.log(slog.LevelWarn, "id3: every row has the same class, so the decision is trivial", "class", class)
+	l.span.AddEvent("trivial", slog.String("class", class))
+	return &Decision{Column: column, Default: class}
+}
+
+// learner holds the state of a single run of Learn.
 //
-func Learn(view View, class string) *Decision {
+type learner struct {
+	*options
+	class    string
+	span     Span
+	began    time.Time
+	stats    Stats
+	frontier map[*Case][]int // The rows reaching each case left unfinished, for a checkpoint.
+	weightAt int             // The index of the hidden column of the weight of each row, or -1.
+	random   *rand.Rand      // For choosing the columns considered at each split.
+	tokens   chan struct{}   // One for each goroutine learning a case, beyond the first.
+}
+
+// learn returns the decision for the rows of the view, or nil to decide their
+// most frequent class instead. Any valid view has the validation rows taking
+// the same path.
+//
+func (l *learner) learn(view, valid View, depth int) *Decision {
+	class := l.class
+	t := time.Now()
 	//
-	// Calculate the total entropy of this view and the information gain from
-	// each column (ignoring the class column).
+	// Count the classes by each value of each column (ignoring the class
+	// column) in a single pass, and from that find the information gain from
+	// each column, with its missing values treated as given and adjusted for
+	// any cost.
 	//
-	h := TotalEntropy(view, class)
 	cols := view.Columns()
-	gain := make([]float64, len(cols))
+	table := l.table(view, mustIndexOf(view, class))
+	if l.halts(depth, table.classes) {
+		l.spent(depth, time.Since(t))
+		return nil
+	}
 	maxGain := -1.0
+	maxScore := -1.0
 	maxColumn := ""
+	maxAt := -1
+	chosen := l.considered(cols)
+	for i, v := range cols {
+		if !l.candidate(v) || chosen != nil && !chosen[v] || !l.fits(table.split(i, l.missing[v])) {
+			continue
+		}
+		gain := table.gain(i, l.metric)
+		if l.spreads(v) {
+			g, ok := table.gainMissing(i, l.missing[v], l.metric)
+			if !ok {
+				continue
+			}
+			gain = g
+		}
+		if score := l.score(v, l.worth(gain, table.split(i, l.missing[v]))); score > maxScore {
+			maxGain, maxScore = gain, score
+			maxColumn = v
+			maxAt = i
+		}
+	}
+	//
+	// A set-valued column is split on whether it contains a value, and a
+	// numeric column on whether it is at most a threshold, if that has more
+	// gain. Without any gain, it is split like any other column only when
+	// there is no other.
+	//
+	var test *Test
 	for i, v := range cols {
-		if v == class || v == "" {
+		if v == class || v == "" || !l.allowed(v) || chosen != nil && !chosen[v] {
 			continue
 		}
-		gain[i] = h - AverageEntropy(view, v, class)
-		if gain[i] > maxGain {
-			maxGain = gain[i]
-			maxColumn = cols[i]
+		var best *Test
+		var g float64
+		sep, set := l.sets[v]
+		switch {
+		case set:
+			best, g = bestTest(view, v, sep, class, l.weightAt, l.metric)
+		case l.numeric(v):
+			best, g = bestThreshold(view, v, class, l.weightAt, l.metric, l.locale)
+		default:
+			continue
 		}
+		score := -1.0
+		if best != nil {
+			if counts := outcomeCounts(view, v, best, class, l.weightAt, l.locale); l.fits(counts) {
+				score = l.score(v, l.worth(g, counts))
+			}
+		}
+		if score > maxScore {
+			test, maxGain, maxScore, maxColumn = best, g, score, v
+		} else if maxColumn == "" && test == nil {
+			if split := l.table(view, mustIndexOf(view, class), i); l.fits(split.counts[i]) {
+				maxGain, maxColumn, maxAt = 0, v, i
+				table = split
+			}
+		}
+	}
+	if maxColumn == "" {
+		//
+		// There is no column left to decide on.
+		//
+		l.spent(depth, time.Since(t))
+		return nil
 	}
 	//
-	// The column with the maximum gain is the basis for the decision.
+	// The column with the maximum gain is the basis for the decision, unless
+	// it decides no more of the validation rows correctly than the most
+	// frequent class would.
 	//
-	decision := &Decision{Column: maxColumn}
+	decision := &Decision{Column: maxColumn, Test: test}
+	if l.prior {
+		decision.Default = majority(table.classes)
+	}
+	branches := branches(view, decision, table, maxAt, class, l.weightAt, l.missing[maxColumn], l.locale)
+	if valid != nil && depth > 0 && !l.improves(valid, decision, branches, table.classes) {
+		l.spent(depth, time.Since(t))
+		return nil
+	}
+	l.node(depth)
 	//
-	// For each distinct value in the maximum gain column, in decreasing
-	// probability, check if the value is terminal or whether to recurse.
+	// For each distinct value in the maximum gain column, or each outcome of
+	// the test, in decreasing probability, check if the value is terminal or
+	// whether to recurse.
 	//
-	for _, v := range Likelihood(view, maxColumn) {
-		c := &Case{Value: v.Value}
+	var recursed []*Case
+	var forks []*learner
+	var wg sync.WaitGroup
+	heaviest := make(map[*Case]string, len(branches))
+	for _, b := range branches {
+		c := &Case{Value: b.Value, Counts: tally(b.counts)}
 		decision.Cases = append(decision.Cases, c)
+		heaviest[c] = majority(b.counts)
 		//
 		// The case is terminal if there is a single class for all rows, in
 		// which case the total entropy would be zero.
 		//
-		subview := view.Select(maxColumn, v.Value)
-		subh := TotalEntropy(subview, class)
-		if subh == 0.0 {
+		if len(b.counts) == 1 {
+			c.Class = heaviest[c]
+			l.stats.Leaves++
+			continue
+		}
+		l.spent(depth, time.Since(t))
+		if l.stop() {
+			//
+			// Out of time, so decide the most frequent class.
+			//
+			c.Class = heaviest[c]
+			l.stats.Leaves++
+			l.pause(c, b.view)
+			t = time.Now()
+			continue
+		}
+		var next, subvalid View
+		if test != nil {
 			//
-			// Get the first class value in this subview.
+			// Recurse on this view, where the column may be tested again.
 			//
-			subview.First()
-			row := subview.Next()
-			c.Class = row[find(subview.Columns(), class)]
+			next = b.view
+			if valid != nil {
+				subvalid = SelectTest(valid, maxColumn, test, b.Value, WithLocale(l.locale))
+			}
 		} else {
 			//
 			// Recurse on this view dropping the just decided column.
 			//
-			c.Decide = Learn(subview.Drop(maxColumn), class)
+			next = b.view.Drop(maxColumn)
+			if valid != nil {
+				subvalid = valid.Select(maxColumn, b.Value).Drop(maxColumn)
+			}
 		}
+		recursed = append(recursed, c)
+		var seed int64
+		if l.sample > 0 {
+			seed = l.source().Int63()
+		}
+		if f := l.fork(); f != nil {
+			//
+			// Learn the case in another goroutine, from its own copy of the
+			// rows, while this one goes on with the others.
+			//
+			next, subvalid = isolate(next), isolate(subvalid)
+			forks = append(forks, f)
+			wg.Add(1)
+			go func(c *Case) {
+				defer wg.Done()
+				defer f.release()
+				c.Decide = f.descend(next, subvalid, depth+1, seed)
+			}(c)
+		} else {
+			c.Decide = l.descend(next, subvalid, depth+1, seed)
+		}
+		t = time.Now()
+	}
+	wg.Wait()
+	for _, f := range forks {
+		l.join(f)
+	}
+	for _, c := range recursed {
+		if c.Decide == nil {
+			//
+			// Rows that agree on every column have different classes, or
+			// the validation rows gain nothing from deciding further, so
+			// decide the most frequent.
+			//
+			c.Class = heaviest[c]
+			l.stats.Leaves++
+		}
+	}
+	if test == nil && l.spreads(maxColumn) && decision.Cases[0].Value != "" {
+		//
+		// A missing value decides as the most frequent case does.
+		//
+		c := decision.Cases[0].clone()
+		c.Value = ""
+		decision.Cases = append(decision.Cases, c)
 	}
+	l.spent(depth, time.Since(t))
+	l.log(slog.LevelDebug, "id3: split", "column", maxColumn, "gain", maxGain, "cases", len(decision.Cases), "depth", depth)
+	l.span.AddEvent("split", slog.String("column", maxColumn), slog.Float64("gain", maxGain), slog.Int("cases", len(decision.Cases)), slog.Int("depth", depth))
 	return decision
 }
+
+// improves reports whether the decision, with each case deciding the most
+// frequent class of its branch, decides more of the validation rows correctly
+// than the most frequent of the classes would.
+//
+func (l *learner) improves(valid View, d *Decision, branches []branch, classes map[string]float64) bool {
+	leaf := majority(classes)
+	decides := make(map[string]string, len(branches))
+	for _, b := range branches {
+		decides[b.Value] = majority(b.counts)
+	}
+	if d.Test == nil && l.spreads(d.Column) {
+		decides[""] = decides[branches[0].Value]
+	}
+	at := mustIndexOf(valid, d.Column)
+	classAt := mustIndexOf(valid, l.class)
+	before, after := 0, 0
+	valid.First()
+	for row := valid.Next(); row != nil; row = valid.Next() {
+		if row[classAt] == leaf {
+			before++
+		}
+		if c, ok := decides[d.key(row[at], l.locale)]; ok && c == row[classAt] {
+			after++
+		}
+	}
+	return after > before
+}
+
+// branch is a case value of a decision being learned, the view of the rows
+// taking it and their class frequencies.
+//
+type branch struct {
+	Distinct
+	view   View
+	counts map[string]float64
+}
+
+// branches returns the branches of the decision in decreasing probability,
+// then by value. The table has the counts for the column of a decision without
+// a test, at index at, whose missing values are treated as given. Rows weigh
+// as given in the hidden column at weightAt, if not negative. A test reads
+// numbers as written in the locale.
+//
+func branches(view View, d *Decision, table *contingency, at int, class string, weightAt int, m Missing, locale Locale) []branch {
+	var b []branch
+	if d.Test == nil {
+		split := table.split(at, m)
+		known := valueLikelihood(table.known(at))
+		for i, v := range valueLikelihood(split) {
+			subview := view.Select(d.Column, v.Value)
+			switch {
+			case m == MissingDistribute:
+				subview = &orMissingView{parent: view, col: at, val: v.Value, weightAt: weightAt, fraction: fractionOf(known, v.Value)}
+			case m == MissingImpute && i == 0:
+				subview = &orMissingView{parent: view, col: at, val: v.Value, weightAt: -1}
+			}
+			b = append(b, branch{v, subview, split[v.Value]})
+		}
+		return b
+	}
+	for _, outcome := range []string{"false", "true"} {
+		subview := SelectTest(view, d.Column, d.Test, outcome, WithLocale(locale))
+		counts := make(map[string]float64)
+		n := 0.0
+		classAt := mustIndexOf(subview, class)
+		subview.First()
+		for row := subview.Next(); row != nil; row = subview.Next() {
+			if w := weightOf(row, weightAt); w > 0 {
+				counts[row[classAt]] += w
+				n += w
+			}
+		}
+		b = append(b, branch{Distinct{Value: outcome, Probability: n}, subview, counts})
+	}
+	if b[1].Probability > b[0].Probability {
+		b[0], b[1] = b[1], b[0]
+	}
+	total := b[0].Probability + b[1].Probability
+	for i := range b {
+		b[i].Probability /= total
+	}
+	return b
+}
+
+// candidate reports whether the column may be split on like any other, being
+// neither set-valued nor numeric.
+//
+func (l *learner) candidate(column string) bool {
+	_, set := l.sets[column]
+	return !set && !l.numeric(column) && column != l.class && column != "" && l.allowed(column)
+}
+
+// table returns the contingency table of the view for the candidate columns,
+// or else for the given columns. With more than one worker the columns are
+// shared between them, each reading its own view of a copy of the rows.
+//
+func (l *learner) table(view View, classAt int, columns ...int) *contingency {
+	cols := view.Columns()
+	counted := make([]bool, len(cols))
+	for i, v := range cols {
+		counted[i] = len(columns) == 0 && l.candidate(v)
+	}
+	for _, i := range columns {
+		counted[i] = true
+	}
+	table := newContingency(len(cols))
+	if l.workers <= 1 {
+		view.First()
+		for row := view.Next(); row != nil; row = view.Next() {
+			table.add(row, classAt, counted, weightOf(row, l.weightAt))
+			l.stats.Rows++
+		}
+		return table
+	}
+	data := [][]string{cols}
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		data = append(data, row)
+	}
+	l.stats.Rows += int64(len(data) - 1)
+	//
+	// Deal the columns out to the workers, each counting into its own table,
+	// then gather the counts.
+	//
+	parts := make([]*contingency, l.workers)
+	var wg sync.WaitGroup
+	for w := range parts {
+		share := make([]bool, len(cols))
+		n := 0
+		for i, ok := range counted {
+			if ok && n%l.workers == w {
+				share[i] = true
+			}
+			if ok {
+				n++
+			}
+		}
+		parts[w] = newContingency(len(cols))
+		wg.Add(1)
+		go func(part *contingency, share []bool) {
+			defer wg.Done()
+			v := newBaseView(data)
+			for row := v.Next(); row != nil; row = v.Next() {
+				part.add(row, classAt, share, weightOf(row, l.weightAt))
+			}
+		}(parts[w], share)
+	}
+	wg.Wait()
+	table.classes = parts[0].classes
+	for _, part := range parts {
+		for i, counts := range part.counts {
+			if counts != nil {
+				table.counts[i] = counts
+			}
+		}
+	}
+	return table
+}
+
+// fork returns a learner for learning a case in another goroutine, sharing the
+// options and span of this one, or nil if there are no more goroutines to be
+// had. Its statistics and unfinished cases must be joined back into this one.
+//
+func (l *learner) fork() *learner {
+	if l.workers <= 1 {
+		return nil
+	}
+	if l.tokens == nil {
+		l.tokens = make(chan struct{}, l.workers-1)
+	}
+	select {
+	case l.tokens <- struct{}{}:
+	default:
+		return nil
+	}
+	f := &learner{options: l.options, class: l.class, span: l.span, began: l.began, tokens: l.tokens, weightAt: l.weightAt}
+	f.stats.Stopped = l.stats.Stopped
+	if l.frontier != nil {
+		f.frontier = make(map[*Case][]int)
+	}
+	return f
+}
+
+// descend learns a case below a decision as learn does, choosing the columns
+// considered from the seed when sampling them, so that the tree does not
+// depend on which cases are learned in other goroutines.
+//
+func (l *learner) descend(view, valid View, depth int, seed int64) *Decision {
+	if l.sample > 0 {
+		saved := l.random
+		defer func() { l.random = saved }()
+		l.random = rand.New(rand.NewSource(seed))
+	}
+	return l.learn(view, valid, depth)
+}
+
+// release gives back the goroutine of a forked learner.
+//
+func (l *learner) release() { <-l.tokens }
+
+// isolate returns a view of a copy of the references to the rows of the view,
+// if not nil, so that it can be read in another goroutine.
+//
+func isolate(view View) View {
+	if view == nil {
+		return nil
+	}
+	return newBaseView(rowsOf(view))
+}
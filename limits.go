@@ -0,0 +1,60 @@
+package id3
+
+// WithMaxDepth stops Learn and LearnExternal deciding further than the depth,
+// counting the root decision as depth 1, making each case still to be decided
+// there a leaf deciding the most frequent class of its rows. Zero, the
+// default, is no limit.
+//
+func WithMaxDepth(depth int) Option {
+	return func(o *options) { o.deepest = depth }
+}
+
+// WithMinSamplesSplit stops Learn and LearnExternal deciding on fewer than n
+// rows below the root, making the case a leaf deciding the most frequent
+// class of its rows instead. Rows learned WithWeights count by their weight.
+//
+func WithMinSamplesSplit(n int) Option {
+	return func(o *options) { o.split = n }
+}
+
+// WithMinSamplesLeaf has Learn and LearnExternal decide on a column only if
+// every case of the decision has at least n rows, counted by their weight
+// WithWeights. A case of noisy data held by a few rows is then merged with the
+// others into a leaf above it.
+//
+func WithMinSamplesLeaf(n int) Option {
+	return func(o *options) { o.leaf = n }
+}
+
+// halts reports whether a case at the depth, below the root, with the class
+// frequencies of its rows, must be a leaf.
+//
+func (o *options) halts(depth int, classes map[string]float64) bool {
+	if depth == 0 {
+		return false
+	}
+	rows := 0.0
+	for _, n := range classes {
+		rows += n
+	}
+	return o.deepest > 0 && depth >= o.deepest || rows < float64(o.split)
+}
+
+// fits reports whether every case of a decision, with the class frequencies
+// by each case, has enough rows for a leaf.
+//
+func (o *options) fits(counts map[string]map[string]float64) bool {
+	if o.leaf <= 1 {
+		return true
+	}
+	for _, classes := range counts {
+		rows := 0.0
+		for _, n := range classes {
+			rows += n
+		}
+		if rows < float64(o.leaf) {
+			return false
+		}
+	}
+	return true
+}
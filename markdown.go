@@ -0,0 +1,61 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ToMarkdown returns the rules of this decision as a Markdown table, headed with
+// the name of the class column. Where the tree carries class frequencies from
+// learning, the table adds the coverage of each rule, as the number and share
+// of training rows, and its accuracy on those rows.
+//
+func (d *Decision) ToMarkdown(class string) ([]byte, error) {
+	rules := d.ToRules()
+	stats := true
+	total := 0
+	for _, r := range rules {
+		if len(r.Counts) == 0 {
+			stats = false
+		}
+		for _, n := range r.Counts {
+			total += n
+		}
+	}
+	var buf bytes.Buffer
+	if stats {
+		fmt.Fprintf(&buf, "| Rule | %s | Coverage | Accuracy |\n", markdownEscape(class))
+		buf.WriteString("|---|---|---:|---:|\n")
+	} else {
+		fmt.Fprintf(&buf, "| Rule | %s |\n", markdownEscape(class))
+		buf.WriteString("|---|---|\n")
+	}
+	for _, r := range rules {
+		conditions := make([]string, len(r.Conditions))
+		for i, c := range r.Conditions {
+			conditions[i] = c.String()
+		}
+		fmt.Fprintf(&buf, "| %s | %s |", markdownEscape(strings.Join(conditions, " AND ")), markdownEscape(r.Class))
+		if stats {
+			n := 0
+			for _, v := range r.Counts {
+				n += v
+			}
+			coverage, accuracy := 0.0, 0.0
+			if total > 0 {
+				coverage = 100 * float64(n) / float64(total)
+			}
+			if n > 0 {
+				accuracy = 100 * float64(r.Counts[r.Class]) / float64(n)
+			}
+			fmt.Fprintf(&buf, " %d (%.1f%%) | %.1f%% |", n, coverage, accuracy)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`|`, `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,45 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ToMermaid returns this decision as a Mermaid flowchart, which renders in
+// Markdown on GitHub and GitLab among others. Decisions are drawn as boxes
+// labelled with the column name, cases as edges labelled with the value and
+// decided classes as rounded boxes.
+//
+func (d *Decision) ToMermaid() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("flowchart TD\n")
+	next := 0
+	var walk func(d *Decision) int
+	walk = func(d *Decision) int {
+		id := next
+		next++
+		fmt.Fprintf(&buf, "    n%d[\"%s\"]\n", id, mermaidEscape(d.Column))
+		for _, c := range d.rendered() {
+			var child int
+			if c.Decide != nil {
+				child = walk(c.Decide)
+			} else {
+				child = next
+				next++
+				fmt.Fprintf(&buf, "    n%d([\"%s\"])\n", child, mermaidEscape(c.Class))
+			}
+			fmt.Fprintf(&buf, "    n%d -->|\"%s\"| n%d\n", id, mermaidEscape(d.label(c)), child)
+		}
+		return id
+	}
+	walk(d)
+	return buf.Bytes(), nil
+}
+
+// mermaidEscape makes the string safe to use within a quoted Mermaid label,
+// using its entity codes.
+//
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", "<br>").Replace(s)
+}
@@ -0,0 +1,351 @@
+package id3
+
+import "strconv"
+
+// Missing is how Learn treats the rows missing a value, that is with an empty
+// value, in a column.
+//
+type Missing int
+
+// The treatments of missing values.
+//
+const (
+	MissingAsValue Missing = iota
+	MissingImpute
+	MissingDistribute
+	MissingExclude
+)
+
+var missingNames = []string{"value", "impute", "distribute", "exclude"}
+
+func (m Missing) String() string {
+	if m < 0 || int(m) >= len(missingNames) {
+		return "Missing(" + strconv.Itoa(int(m)) + ")"
+	}
+	return missingNames[m]
+}
+
+// WithMissing has Learn treat the missing values of the named columns as
+// given, so that each column can be treated as its data needs:
+//
+//   - MissingAsValue, the default, decides on a missing value as on any other,
+//     with a case of its own.
+//   - MissingImpute takes a missing value to be the most frequent value of the
+//     rows reaching a decision on the column.
+//   - MissingDistribute chooses a decision on the column from the gain among
+//     the rows with a value, weighted by the fraction of rows having one, as
+//     C4.5 does. The rows missing a value then take every case of the
+//     decision, each with the fraction of their weight that the rows with a
+//     value give the case, so that they count once in all.
+//   - MissingExclude leaves the rows missing a value out of learning.
+//
+// A decision on an imputed or distributed column has a case for the missing
+// value that decides as its most frequent case does, so that prediction treats
+// a missing value as learning did. Set-valued columns are not affected.
+//
+func WithMissing(m Missing, columns ...string) Option {
+	return func(o *options) {
+		if o.missing == nil {
+			o.missing = make(map[string]Missing)
+		}
+		for _, c := range columns {
+			o.missing[c] = m
+		}
+	}
+}
+
+// WithMissingMarkers has Read, DecideContext, Follow and Evaluate take the
+// markers, such as "?" or "NA", to be missing values like the empty value,
+// which Read gives instead.
+//
+func WithMissingMarkers(markers ...string) Option {
+	return func(o *options) { o.markers = append(o.markers, markers...) }
+}
+
+// MissingFallback is how a decision decides on a row missing the value of its
+// column when it has no case for the missing value. A default class comes
+// first, so a decision with one decides it whatever the fallback.
+//
+type MissingFallback int
+
+// The fallbacks for missing values.
+//
+const (
+	FallbackMostFrequent MissingFallback = iota // Take the case that the most training rows took.
+	FallbackNone                                // Have no rule for the row.
+)
+
+// WithMissingFallback has DecideContext, Follow, Evaluate and the scoring of
+// KFold and CrossValidate decide on a missing value without a case as given.
+// FallbackMostFrequent, the default, is what Decide and the other functions
+// without options do.
+//
+func WithMissingFallback(f MissingFallback) Option {
+	return func(o *options) { o.absent = f }
+}
+
+// unmark replaces each value of the data below the header that is a marker
+// with the empty value.
+//
+func (o *options) unmark(data [][]string) {
+	if len(o.markers) == 0 || len(data) == 0 {
+		return
+	}
+	for _, row := range data[1:] {
+		for i, v := range row {
+			if contains(o.markers, v) {
+				row[i] = ""
+			}
+		}
+	}
+}
+
+// mostFrequent returns the case that the most training rows took, by their
+// class frequencies, or the first case if none has any, or nil if there are
+// no cases.
+//
+func (d *Decision) mostFrequent() *Case {
+	var most *Case
+	rows := 0
+	for _, c := range d.Cases {
+		n := 0
+		for _, k := range c.Counts {
+			n += k
+		}
+		if most == nil || n > rows {
+			most, rows = c, n
+		}
+	}
+	return most
+}
+
+// spreads reports whether the missing values of the column are imputed or
+// distributed between the cases of a decision on it.
+//
+func (o *options) spreads(column string) bool {
+	m := o.missing[column]
+	return m == MissingImpute || m == MissingDistribute
+}
+
+// excludeMissing returns a view without the rows missing a value in any of the
+// columns whose missing values are excluded, or the view itself if there are
+// none.
+//
+func (o *options) excludeMissing(view View) View {
+	var cols []int
+	for i, c := range view.Columns() {
+		if o.missing[c] == MissingExclude && c != "" {
+			cols = append(cols, i)
+		}
+	}
+	if len(cols) == 0 {
+		return view
+	}
+	return &presentView{parent: view, cols: cols}
+}
+
+// split returns the class frequencies by each value of the column with the
+// missing values treated as given: imputed as the most frequent value, or
+// distributed to every value by the fraction of the other rows having it.
+// Otherwise it returns them as counted.
+//
+func (t *contingency) split(i int, m Missing) map[string]map[string]float64 {
+	missing := t.counts[i][""]
+	if missing == nil || m != MissingImpute && m != MissingDistribute {
+		return t.counts[i]
+	}
+	known := t.known(i)
+	likely := valueLikelihood(known)
+	split := make(map[string]map[string]float64, len(known))
+	for j, v := range likely {
+		classes := make(map[string]float64, len(known[v.Value]))
+		for c, n := range known[v.Value] {
+			classes[c] = n
+		}
+		switch {
+		case m == MissingDistribute:
+			for c, n := range missing {
+				classes[c] += n * v.Probability
+			}
+		case j == 0:
+			for c, n := range missing {
+				classes[c] += n
+			}
+		}
+		split[v.Value] = classes
+	}
+	return split
+}
+
+// known returns the class frequencies by each value of the column other than
+// the missing value.
+//
+func (t *contingency) known(i int) map[string]map[string]float64 {
+	known := make(map[string]map[string]float64, len(t.counts[i]))
+	for v, classes := range t.counts[i] {
+		if v != "" {
+			known[v] = classes
+		}
+	}
+	return known
+}
+
+// gainMissing returns the decrease in impurity from the column with its
+// missing values treated as given, and false if no row has a value in the
+// column.
+//
+func (t *contingency) gainMissing(i int, m Missing, measure Impurity) (float64, bool) {
+	known := t.known(i)
+	if len(known) == 0 {
+		return 0, false
+	}
+	if m == MissingImpute {
+		return gainFrom(t.classes, t.split(i, m), measure), true
+	}
+	classes := make(map[string]float64)
+	n, total := 0.0, 0.0
+	for _, counts := range known {
+		for c, k := range counts {
+			classes[c] += k
+			n += k
+		}
+	}
+	for _, k := range t.classes {
+		total += k
+	}
+	return n / total * gainFrom(classes, known, measure), true
+}
+
+// fractionOf returns the probability of the value among those given, or zero
+// if it is not one of them.
+//
+func fractionOf(likely []Distinct, value string) float64 {
+	for _, v := range likely {
+		if v.Value == value {
+			return v.Probability
+		}
+	}
+	return 0
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// presentView shows only the rows with a value in each of the columns.
+//
+type presentView struct {
+	parent View  // Inherit from the parent view.
+	cols   []int // Column indices of the columns that must have a value.
+}
+
+func (p *presentView) Columns() []string { return p.parent.Columns() }
+
+func (p *presentView) lookup(column string) (int, bool) { return lookupIn(p.parent, column) }
+
+func (p *presentView) First() { p.parent.First() }
+
+func (p *presentView) Next() []string {
+	for {
+		row := p.parent.Next()
+		if row == nil {
+			return nil
+		}
+		present := true
+		for _, i := range p.cols {
+			present = present && row[i] != ""
+		}
+		if present {
+			return row
+		}
+	}
+}
+
+func (p *presentView) rows() func() []string {
+	return filter(rowsIn(p.parent), func(row []string) bool {
+		for _, i := range p.cols {
+			if row[i] == "" {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (p *presentView) Select(column, value string) View {
+	return &selectView{
+		parent: p,
+		col:    mustIndexOf(p, column),
+		val:    value,
+	}
+}
+
+func (p *presentView) Drop(column string) View {
+	return newDropView(p, mustIndexOf(p, column))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// orMissingView shows only the rows with the value, or with no value, in the
+// column. The rows with no value have their weight scaled by the fraction, if
+// there is a hidden column of weights.
+//
+type orMissingView struct {
+	parent   View    // Inherit from the parent view.
+	col      int     // Column index of the column to select on.
+	val      string  // The value to select.
+	weightAt int     // Column index of the hidden weights, or -1.
+	fraction float64 // The fraction of the weight of a row with no value.
+}
+
+func (o *orMissingView) Columns() []string { return o.parent.Columns() }
+
+func (o *orMissingView) lookup(column string) (int, bool) { return lookupIn(o.parent, column) }
+
+func (o *orMissingView) First() { o.parent.First() }
+
+func (o *orMissingView) Next() []string {
+	for {
+		row := o.parent.Next()
+		if row == nil {
+			return nil
+		}
+		if row[o.col] == o.val {
+			return row
+		}
+		if row[o.col] == "" {
+			return o.share(row)
+		}
+	}
+}
+
+func (o *orMissingView) rows() func() []string {
+	next := filter(rowsIn(o.parent), func(row []string) bool { return row[o.col] == o.val || row[o.col] == "" })
+	return func() []string {
+		row := next()
+		if row != nil && row[o.col] == "" {
+			return o.share(row)
+		}
+		return row
+	}
+}
+
+// share returns the row with no value in the column, with its share of its
+// weight.
+//
+func (o *orMissingView) share(row []string) []string {
+	if o.weightAt < 0 {
+		return row
+	}
+	return reweigh(row, o.weightAt, o.fraction)
+}
+
+func (o *orMissingView) Select(column, value string) View {
+	return &selectView{
+		parent: o,
+		col:    mustIndexOf(o, column),
+		val:    value,
+	}
+}
+
+func (o *orMissingView) Drop(column string) View {
+	return newDropView(o, mustIndexOf(o, column))
+}
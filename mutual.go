@@ -0,0 +1,35 @@
+package id3
+
+// MutualInformation returns the mutual information, in bits, between columns a
+// and b of the view. This is the reduction in the entropy of b given knowledge
+// of a, and is symmetric in a and b.
+//
+func MutualInformation(view View, a, b string) float64 {
+	mi := TotalEntropy(view, b) - AverageEntropy(view, a, b)
+	//
+	// Guard against a tiny negative result from floating point rounding.
+	//
+	if mi < 0 {
+		return 0
+	}
+	return mi
+}
+
+// MIMatrix returns the pairwise mutual information between the named columns of
+// the view. The matrix is symmetric and the diagonal holds the entropy of each
+// column.
+//
+func MIMatrix(view View, columns []string) [][]float64 {
+	m := make([][]float64, len(columns))
+	for i := range m {
+		m[i] = make([]float64, len(columns))
+	}
+	for i, a := range columns {
+		m[i][i] = TotalEntropy(view, a)
+		for j := i + 1; j < len(columns); j++ {
+			m[i][j] = MutualInformation(view, a, columns[j])
+			m[j][i] = m[i][j]
+		}
+	}
+	return m
+}
@@ -0,0 +1,369 @@
+package id3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gbkr-com/id3/internal/wire"
+)
+
+// ONNX constants, from onnx.proto and the ai.onnx.ml operator set.
+//
+const (
+	onnxIRVersion       = 8
+	onnxOpsetVersion    = 13
+	onnxMLOpsetVersion  = 1
+	onnxAttrString      = 3
+	onnxAttrFloats      = 6
+	onnxAttrInts        = 7
+	onnxAttrStrings     = 8
+	onnxTensorFloat     = 1
+	onnxTensorString    = 8
+	onnxCategoriesKey   = "id3.categories"
+	onnxColumnsKey      = "id3.columns"
+	onnxTreeEnsembleOp  = "TreeEnsembleClassifier"
+	onnxTreeEnsembleDom = "ai.onnx.ml"
+)
+
+// ToONNX returns this decision as a serialized ONNX model containing a single
+// ai.onnx.ml TreeEnsembleClassifier, so that it can be run by standard ONNX
+// inference engines.
+//
+// ONNX trees only compare numbers, so the model input "X" is a float tensor of
+// shape [N, len(columns)] holding a category code for each of the given
+// columns. The code for a value is its index in the sorted distinct values the
+// tree uses for that column. The codes are recorded as JSON in the model
+// metadata under "id3.categories", and the column order under "id3.columns".
+// Any other code, such as -1 for an unseen value, decides the default class of
+// a decision or, without one, follows its most likely case. The outputs are the
+// class label "label" and the class probabilities "probabilities".
+//
+func (d *Decision) ToONNX(columns []string) ([]byte, error) {
+	return toONNX([]*Decision{d}, []float64{1}, columns)
+}
+
+// onnxEnsemble accumulates the TreeEnsembleClassifier attributes for a set of
+// trees.
+//
+type onnxEnsemble struct {
+	features map[string]int            // Column name to feature index.
+	codes    map[string]map[string]int // Column name to value to code.
+	classes  map[string]int            // Class value to class index.
+	//
+	// Node attributes.
+	//
+	treeIDs, nodeIDs, featureIDs, trueIDs, falseIDs []int64
+	values                                          []float32
+	modes                                           []string
+	//
+	// Leaf attributes.
+	//
+	leafTreeIDs, leafNodeIDs, leafClassIDs []int64
+	leafWeights                            []float32
+}
+
+// toONNX serializes the trees as one ensemble, where the probabilities from
+// each tree are scaled by the corresponding weight and summed.
+//
+func toONNX(trees []*Decision, weights []float64, columns []string) ([]byte, error) {
+	e := &onnxEnsemble{
+		features: make(map[string]int),
+		codes:    make(map[string]map[string]int),
+		classes:  make(map[string]int),
+	}
+	for i, c := range columns {
+		e.features[c] = i
+	}
+	//
+	// Collect the category codes and class labels across all the trees.
+	//
+	var classes []string
+	categories := make(map[string][]string)
+	for _, t := range trees {
+		if err := e.collect(t, categories, &classes); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(classes)
+	for i, c := range classes {
+		e.classes[c] = i
+	}
+	for column, values := range categories {
+		sort.Strings(values)
+		e.codes[column] = make(map[string]int)
+		for i, v := range values {
+			e.codes[column][v] = i
+		}
+	}
+	//
+	// Emit the nodes of each tree.
+	//
+	for i, t := range trees {
+		next := int64(0)
+		if _, err := e.decision(int64(i), t, weights[i], &next); err != nil {
+			return nil, err
+		}
+	}
+	return e.model(columns, classes, categories)
+}
+
+func (e *onnxEnsemble) collect(d *Decision, categories map[string][]string, classes *[]string) error {
+	if len(d.Cases) == 0 && d.Default == "" {
+		return fmt.Errorf("id3: decision on '%s' has no cases", d.Column)
+	}
+	if d.Test != nil {
+		return fmt.Errorf("id3: ONNX cannot express the test on '%s'", d.Column)
+	}
+	if d.Default != "" && !contains(*classes, d.Default) {
+		*classes = append(*classes, d.Default)
+	}
+	if _, ok := e.features[d.Column]; !ok {
+		return fmt.Errorf("id3: column '%s' not in ONNX input columns", d.Column)
+	}
+	for _, c := range d.Cases {
+		if !contains(categories[d.Column], c.Value) {
+			categories[d.Column] = append(categories[d.Column], c.Value)
+		}
+		switch {
+		case c.Decide != nil:
+			if err := e.collect(c.Decide, categories, classes); err != nil {
+				return err
+			}
+		case c.Class != "":
+			if !contains(*classes, c.Class) {
+				*classes = append(*classes, c.Class)
+			}
+		default:
+			return fmt.Errorf("id3: case '%s' of '%s' has no class or decision", c.Value, d.Column)
+		}
+	}
+	return nil
+}
+
+// decision emits a chain of BRANCH_EQ nodes testing each case in turn, with
+// the default class as the fallback at the end of the chain. Without a default
+// the first case is the fallback instead. It returns the node identifier for
+// the start of the chain.
+//
+func (e *onnxEnsemble) decision(tree int64, d *Decision, weight float64, next *int64) (int64, error) {
+	tested, fallback := d.Cases, &Case{Class: d.Default}
+	if d.Default == "" {
+		tested, fallback = d.Cases[1:], d.Cases[0]
+	}
+	if len(tested) == 0 {
+		return e.branch(tree, fallback, weight, next)
+	}
+	first := int64(-1)
+	previous := -1
+	for _, c := range tested {
+		at := len(e.nodeIDs)
+		id := e.node(tree, next)
+		e.featureIDs[at] = int64(e.features[d.Column])
+		e.values[at] = float32(e.codes[d.Column][c.Value])
+		e.modes[at] = "BRANCH_EQ"
+		if previous >= 0 {
+			e.falseIDs[previous] = id
+		} else {
+			first = id
+		}
+		child, err := e.branch(tree, c, weight, next)
+		if err != nil {
+			return 0, err
+		}
+		e.trueIDs[at] = child
+		previous = at
+	}
+	id, err := e.branch(tree, fallback, weight, next)
+	if err != nil {
+		return 0, err
+	}
+	e.falseIDs[previous] = id
+	return first, nil
+}
+
+func (e *onnxEnsemble) branch(tree int64, c *Case, weight float64, next *int64) (int64, error) {
+	if c.Decide != nil {
+		return e.decision(tree, c.Decide, weight, next)
+	}
+	at := len(e.nodeIDs)
+	id := e.node(tree, next)
+	e.modes[at] = "LEAF"
+	//
+	// Use the class frequencies when known, otherwise all the weight is on the
+	// decided class.
+	//
+	total := 0
+	for _, n := range c.Counts {
+		total += n
+	}
+	if total == 0 {
+		e.leaf(tree, id, e.classes[c.Class], weight)
+		return id, nil
+	}
+	var keys []string
+	for k, n := range c.Counts {
+		if _, ok := e.classes[k]; ok && n > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.leaf(tree, id, e.classes[k], weight*float64(c.Counts[k])/float64(total))
+	}
+	return id, nil
+}
+
+func (e *onnxEnsemble) node(tree int64, next *int64) int64 {
+	id := *next
+	*next++
+	e.treeIDs = append(e.treeIDs, tree)
+	e.nodeIDs = append(e.nodeIDs, id)
+	e.featureIDs = append(e.featureIDs, 0)
+	e.values = append(e.values, 0)
+	e.modes = append(e.modes, "")
+	e.trueIDs = append(e.trueIDs, 0)
+	e.falseIDs = append(e.falseIDs, 0)
+	return id
+}
+
+func (e *onnxEnsemble) leaf(tree, id int64, class int, weight float64) {
+	e.leafTreeIDs = append(e.leafTreeIDs, tree)
+	e.leafNodeIDs = append(e.leafNodeIDs, id)
+	e.leafClassIDs = append(e.leafClassIDs, int64(class))
+	e.leafWeights = append(e.leafWeights, float32(weight))
+}
+
+// model wraps the ensemble as a ModelProto.
+//
+func (e *onnxEnsemble) model(columns, classes []string, categories map[string][]string) ([]byte, error) {
+	if len(classes) == 0 {
+		return nil, errors.New("id3: no classes to export")
+	}
+	node := new(wire.Writer)
+	node.String(1, "X")
+	node.String(2, "label")
+	node.String(2, "probabilities")
+	node.String(3, "id3")
+	node.String(4, onnxTreeEnsembleOp)
+	node.Message(5, onnxInts("nodes_treeids", e.treeIDs))
+	node.Message(5, onnxInts("nodes_nodeids", e.nodeIDs))
+	node.Message(5, onnxInts("nodes_featureids", e.featureIDs))
+	node.Message(5, onnxFloats("nodes_values", e.values))
+	node.Message(5, onnxStrings("nodes_modes", e.modes))
+	node.Message(5, onnxInts("nodes_truenodeids", e.trueIDs))
+	node.Message(5, onnxInts("nodes_falsenodeids", e.falseIDs))
+	node.Message(5, onnxInts("class_treeids", e.leafTreeIDs))
+	node.Message(5, onnxInts("class_nodeids", e.leafNodeIDs))
+	node.Message(5, onnxInts("class_ids", e.leafClassIDs))
+	node.Message(5, onnxFloats("class_weights", e.leafWeights))
+	node.Message(5, onnxStrings("classlabels_strings", classes))
+	node.Message(5, onnxString("post_transform", "NONE"))
+	node.String(7, onnxTreeEnsembleDom)
+
+	graph := new(wire.Writer)
+	graph.Message(1, node)
+	graph.String(2, "id3")
+	graph.Message(11, onnxValueInfo("X", onnxTensorFloat, -1, int64(len(columns))))
+	graph.Message(12, onnxValueInfo("label", onnxTensorString, -1))
+	graph.Message(12, onnxValueInfo("probabilities", onnxTensorFloat, -1, int64(len(classes))))
+
+	model := new(wire.Writer)
+	model.Varint(1, onnxIRVersion)
+	model.String(2, "id3")
+	model.Message(7, graph)
+	opset := new(wire.Writer)
+	opset.String(1, "")
+	opset.Varint(2, onnxOpsetVersion)
+	model.Message(8, opset)
+	opset = new(wire.Writer)
+	opset.String(1, onnxTreeEnsembleDom)
+	opset.Varint(2, onnxMLOpsetVersion)
+	model.Message(8, opset)
+	for _, kv := range []struct {
+		key   string
+		value interface{}
+	}{
+		{onnxColumnsKey, columns},
+		{onnxCategoriesKey, categories},
+	} {
+		b, err := json.Marshal(kv.value)
+		if err != nil {
+			return nil, err
+		}
+		entry := new(wire.Writer)
+		entry.String(1, kv.key)
+		entry.Bytes(2, b)
+		model.Message(14, entry)
+	}
+	return model.Encoded(), nil
+}
+
+func onnxInts(name string, v []int64) *wire.Writer {
+	a := new(wire.Writer)
+	a.String(1, name)
+	a.PackedVarints(8, v)
+	a.Varint(20, onnxAttrInts)
+	return a
+}
+
+func onnxFloats(name string, v []float32) *wire.Writer {
+	a := new(wire.Writer)
+	a.String(1, name)
+	a.PackedFloats(7, v)
+	a.Varint(20, onnxAttrFloats)
+	return a
+}
+
+func onnxStrings(name string, v []string) *wire.Writer {
+	a := new(wire.Writer)
+	a.String(1, name)
+	for _, s := range v {
+		a.String(9, s)
+	}
+	a.Varint(20, onnxAttrStrings)
+	return a
+}
+
+func onnxString(name string, v string) *wire.Writer {
+	a := new(wire.Writer)
+	a.String(1, name)
+	a.String(4, v)
+	a.Varint(20, onnxAttrString)
+	return a
+}
+
+// onnxValueInfo describes a tensor, where a negative dimension is the symbolic
+// batch size "N".
+//
+func onnxValueInfo(name string, elem int64, dims ...int64) *wire.Writer {
+	shape := new(wire.Writer)
+	for _, d := range dims {
+		dim := new(wire.Writer)
+		if d < 0 {
+			dim.String(2, "N")
+		} else {
+			dim.Varint(1, d)
+		}
+		shape.Message(1, dim)
+	}
+	tensor := new(wire.Writer)
+	tensor.Varint(1, elem)
+	tensor.Message(2, shape)
+	typ := new(wire.Writer)
+	typ.Message(1, tensor)
+	info := new(wire.Writer)
+	info.String(1, name)
+	info.Message(2, typ)
+	return info
+}
+
+func contains(slice []string, x string) bool {
+	for _, s := range slice {
+		if s == x {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package id3
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// An Option configures Learn, Read or another function taking options. Each
+// uses the options that apply to it and ignores the others.
+//
+type Option func(*options)
+
+type options struct {
+	logger  *slog.Logger
+	tracer  Tracer
+	ctx     context.Context
+	sets    map[string]string  // The separator of each set-valued column.
+	locale  Locale             // For parsing typed values.
+	workers int                // The most columns Learn evaluates at once.
+	stats   *Stats             // Filled in by Learn, if not nil.
+	budget  time.Duration      // The longest Learn may expand the tree, if not zero.
+	valid   View               // The validation rows for stopping early, if not nil.
+	costs   map[string]float64 // The cost of each column, if not nil.
+	only    []string           // The columns Learn may decide on, if not nil.
+	exclude []string           // The columns Learn must not decide on.
+	missing map[string]Missing // The treatment of missing values in each column.
+	weights string             // The column of the weight of each row, if not "".
+	boosted []float64          // The weight of each row, in order, if not nil.
+	fuzzy   bool               // Whether decisions match values to the closest case.
+	edits   int                // The most edits for a value to match a case.
+	resume  *Checkpoint        // Filled in by Learn and Resume, if not nil.
+	schema  Schema             // The kind of each column, to split numbers on a threshold.
+	measure Criterion          // How Learn measures the worth of a split.
+	metric  Impurity           // How mixed the classes are, or nil for entropy.
+	markers []string           // The values taken as missing, besides the empty value.
+	absent  MissingFallback    // How to decide on a missing value without a case.
+	prior   bool               // Whether Learn gives each decision a default class.
+	deepest int                // The most decisions from the root to a leaf, if not zero.
+	split   int                // The fewest rows to decide on below the root.
+	leaf    int                // The fewest rows for a case.
+	sample  int                // The columns Learn considers at each split, if not zero.
+	seed    int64              // For choosing the columns considered.
+	dialect Dialect            // How CSV data is written.
+	names   []string           // The columns of CSV data without a header, if not nil.
+}
+
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithLogger logs what Learn and Read do to the logger: each split at debug
+// level, and warnings about the data such as duplicate column names or columns
+// that look like row identifiers. Without it they are silent.
+//
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// log writes to the logger, if any.
+//
+func (o *options) log(level slog.Level, msg string, args ...any) {
+	if o.logger != nil {
+		o.logger.Log(o.context(), level, msg, args...)
+	}
+}
+
+// WithLocale parses numbers and times as written in the locale, for the
+// functions that parse typed values such as InferSchema, SelectCompare and
+// FitBins, for Learn splitting a numeric column on a threshold, and for
+// DecideContext, Follow and Evaluate testing one.
+//
+func WithLocale(l Locale) Option {
+	return func(o *options) { o.locale = l }
+}
+
+// WithParallelism lets Learn count the classes by the values of the columns in
+// up to n groups at once, which pays on wide data where that dominates the time
+// taken, and learn the cases of each decision in up to n goroutines at once.
+// Each decision then holds a copy of the references to its rows. The tree
+// learned is the same however many groups there are, and any Tracer must be
+// safe for concurrent use.
+//
+func WithParallelism(n int) Option {
+	return func(o *options) { o.workers = n }
+}
+
+// WithMaxDuration stops Learn and LearnExternal expanding the tree once the
+// duration has passed, making each case still to be decided a leaf deciding the
+// most frequent class of its rows. This bounds the time taken by scheduled
+// retraining, at the cost of a less accurate tree. The context given by
+// WithContext, once done, stops them in the same way.
+//
+func WithMaxDuration(d time.Duration) Option {
+	return func(o *options) { o.budget = d }
+}
+
+// WithValidation has Learn stop growing a branch of the tree when deciding
+// further would not decide more of the validation rows reaching it correctly,
+// making the branch a leaf for the most frequent class of its rows instead.
+// This regularizes the tree without a separate pruning pass. The validation
+// view must have the columns of the view learned from, and should not share its
+// rows.
+//
+func WithValidation(view View) Option {
+	return func(o *options) { o.valid = view }
+}
+
+// WithAttributes has Learn decide on only the named columns, ignoring the
+// others besides the class. Learn fails with ErrColumnNotFound if the view
+// lacks one of them.
+//
+func WithAttributes(columns ...string) Option {
+	return func(o *options) { o.only = append(o.only, columns...) }
+}
+
+// WithExcluded has Learn ignore the named columns, such as row identifiers or
+// fields only known after the outcome, without a chain of Drop views. Columns
+// the view lacks are ignored too.
+//
+func WithExcluded(columns ...string) Option {
+	return func(o *options) { o.exclude = append(o.exclude, columns...) }
+}
+
+// allowed reports whether Learn may decide on the column.
+//
+func (o *options) allowed(column string) bool {
+	return (o.only == nil || contains(o.only, column)) && !contains(o.exclude, column)
+}
+
+// WithDefaults has Learn and LearnExternal give each decision the most frequent
+// class of its rows as its default class, so that a value not seen in learning,
+// such as a new "foggy" outlook, decides the most likely class rather than
+// failing with ErrNoMatchingCase.
+//
+func WithDefaults() Option {
+	return func(o *options) { o.prior = true }
+}
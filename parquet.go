@@ -0,0 +1,64 @@
+package id3
+
+import (
+	"io"
+
+	"github.com/gbkr-com/id3/internal/parquet"
+)
+
+// ReadParquet reads the Parquet file of the given size and returns a view on
+// its rows, and the schema of its columns from their Parquet types, which can
+// be given to Learn WithSchema to split the numeric columns on thresholds.
+// Only flat files are supported, in the PLAIN or dictionary encodings and
+// without compression or with snappy or gzip. A null is a missing value, and
+// with WithMissingMarkers the markers are read as missing values too. Dates and
+// timestamps are written as TimeLayouts parses them, in UTC.
+//
+func ReadParquet(r io.ReaderAt, size int64, opts ...Option) (View, Schema, error) {
+	o := newOptions(opts)
+	span := o.start("id3.ReadParquet")
+	defer span.End()
+	columns, rows, err := parquet.Read(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := make([]string, len(columns))
+	schema := make(Schema)
+	for i, c := range columns {
+		header[i] = c.Name
+		if k := kindOf(c); k != String {
+			schema[c.Name] = k
+		}
+	}
+	view, err := NewView(append([][]string{header}, rows...))
+	if err != nil {
+		return nil, nil, err
+	}
+	o.unmark(view.(*baseView).data)
+	if o.logger != nil {
+		warn(view, o)
+	}
+	return view, schema, nil
+}
+
+// kindOf returns the kind of the values of the Parquet column.
+//
+func kindOf(c parquet.Column) Kind {
+	switch c.Converted {
+	case parquet.Decimal:
+		return Float
+	case parquet.Date, parquet.TimestampMillis, parquet.TimestampMicros:
+		return Time
+	}
+	switch c.Type {
+	case parquet.Boolean:
+		return Bool
+	case parquet.Int32, parquet.Int64:
+		return Int
+	case parquet.Int96:
+		return Time
+	case parquet.Float, parquet.Double:
+		return Float
+	}
+	return String
+}
@@ -0,0 +1,311 @@
+package id3
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// A Pipeline records the transformations of the data a decision was learned
+// from, together with that decision, so that the same transformations are
+// made to the data it decides. Written and read as one, they cannot drift
+// apart between training and serving.
+//
+type Pipeline struct {
+	Steps    []Step    // The transformations, in the order made.
+	Class    string    `json:",omitempty"` // The class column, once learned.
+	Decision *Decision `json:",omitempty"` // The decision learned from the transformed data.
+}
+
+// A Step is a transformation of a column of a Pipeline. Make one with
+// RenameStep, ImputeStep, BinStep, EncodeStep or TokenStep, or fit one to
+// training data with FitImpute or FitBins.
+//
+type Step struct {
+	Op     string            // One of "rename", "impute", "bin", "encode" or "tokens".
+	Column string            // The column transformed.
+	To     string            `json:",omitempty"` // The new name of the column, to "rename".
+	Value  string            `json:",omitempty"` // The value of a missing value, to "impute".
+	Bounds []float64         `json:",omitempty"` // The upper bound of each bin but the last, to "bin".
+	Labels []string          `json:",omitempty"` // The label of each bin, to "bin", or the tokens, to "tokens".
+	Map    map[string]string `json:",omitempty"` // The new value of each value changed, to "encode".
+	Locale *Locale           `json:",omitempty"` // How the numbers are written, to "bin", or nil as Go writes them.
+}
+
+// RenameStep renames the column.
+//
+func RenameStep(column, to string) Step {
+	return Step{Op: "rename", Column: column, To: to}
+}
+
+// ImputeStep replaces the missing values of the column with the value.
+//
+func ImputeStep(column, value string) Step {
+	return Step{Op: "impute", Column: column, Value: value}
+}
+
+// FitImpute returns the step replacing the missing values of the column with
+// its most frequent value in the view.
+//
+func FitImpute(view View, column string) Step {
+	value := ""
+	for _, v := range Likelihood(view, column) {
+		if v.Value != "" {
+			value = v.Value
+			break
+		}
+	}
+	return ImputeStep(column, value)
+}
+
+// BinStep replaces the numbers in the column with the label of the bin they
+// fall in: the first bin whose upper bound they do not exceed, or else the
+// last. The bounds must be in increasing order. Without labels, each bin is
+// labelled as an interval such as "(20,30]". A value that is not a number
+// becomes missing.
+//
+func BinStep(column string, bounds []float64, labels []string) Step {
+	if labels == nil {
+		lower := "-inf"
+		for _, b := range bounds {
+			upper := strconv.FormatFloat(b, 'g', -1, 64)
+			labels = append(labels, "("+lower+","+upper+"]")
+			lower = upper
+		}
+		labels = append(labels, "("+lower+",+inf)")
+	}
+	return Step{Op: "bin", Column: column, Bounds: bounds, Labels: labels}
+}
+
+// FitBins returns the step binning the numbers in the column into n bins of
+// about the same number of rows of the view, with bounds at the quantiles.
+// Numbers are read as written in the locale WithLocale gives, which the step
+// keeps to read them again.
+//
+func FitBins(view View, column string, n int, opts ...Option) Step {
+	l := newOptions(opts).locale
+	var numbers []float64
+	for v, k := range Frequency(view, column) {
+		if f, err := strconv.ParseFloat(l.number(v), 64); err == nil {
+			for ; k > 0; k-- {
+				numbers = append(numbers, f)
+			}
+		}
+	}
+	sort.Float64s(numbers)
+	var bounds []float64
+	for i := 1; i < n && len(numbers) > 0; i++ {
+		b := numbers[(len(numbers)-1)*i/n]
+		if len(bounds) == 0 || b > bounds[len(bounds)-1] {
+			bounds = append(bounds, b)
+		}
+	}
+	s := BinStep(column, bounds, nil)
+	if l.Decimal != "" || l.Thousands != "" {
+		s.Locale = &l
+	}
+	return s
+}
+
+// EncodeStep replaces each value of the column in the mapping with the value
+// it maps to, such as "NY" with "New York". Other values are unchanged.
+//
+func EncodeStep(column string, mapping map[string]string) Step {
+	return Step{Op: "encode", Column: column, Map: mapping}
+}
+
+// TokenStep replaces the free text column with the presence columns of the k
+// tokens most informative about the class, as Tokenize does.
+//
+func TokenStep(view View, column, class string, k int) Step {
+	return Step{Op: "tokens", Column: column, Labels: rankTokens(view, column, class, k)}
+}
+
+// Apply returns the view with the steps of the pipeline made in turn. It fails
+// with ErrColumnNotFound if the view lacks a column transformed.
+//
+func (p *Pipeline) Apply(view View) (View, error) {
+	return p.apply(view, false)
+}
+
+// apply makes the steps, skipping those on a column the view lacks if lenient.
+//
+func (p *Pipeline) apply(view View, lenient bool) (View, error) {
+	for _, s := range p.Steps {
+		i, err := indexOf(view, s.Column)
+		if err != nil && lenient {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch s.Op {
+		case "rename":
+			columns := append([]string(nil), view.Columns()...)
+			columns[i] = s.To
+			view = newStepView(view, columns, i, nil)
+		case "impute":
+			value := s.Value
+			view = newStepView(view, view.Columns(), i, func(v string) string {
+				if v == "" {
+					return value
+				}
+				return v
+			})
+		case "bin":
+			view = newStepView(view, view.Columns(), i, s.bin)
+		case "encode":
+			m := s.Map
+			view = newStepView(view, view.Columns(), i, func(v string) string {
+				if to, ok := m[v]; ok {
+					return to
+				}
+				return v
+			})
+		case "tokens":
+			view = tokenize(view, s.Column, s.Labels)
+		default:
+			return nil, fmt.Errorf("id3: unknown pipeline step '%s'", s.Op)
+		}
+	}
+	return view, nil
+}
+
+// bin returns the label of the bin of the value, or "" if it is not a number.
+//
+func (s Step) bin(value string) string {
+	var l Locale
+	if s.Locale != nil {
+		l = *s.Locale
+	}
+	f, err := strconv.ParseFloat(l.number(value), 64)
+	if err != nil || math.IsNaN(f) {
+		return ""
+	}
+	i := sort.SearchFloat64s(s.Bounds, f)
+	if i >= len(s.Labels) {
+		return ""
+	}
+	return s.Labels[i]
+}
+
+// Learn makes the steps of the pipeline to the view, then learns the decision
+// for the class column from the result, as Learn does. The pipeline keeps the
+// class and the decision.
+//
+func (p *Pipeline) Learn(view View, class string, opts ...Option) (*Decision, error) {
+	applied, err := p.Apply(view)
+	if err != nil {
+		return nil, err
+	}
+	d, err := Learn(applied, class, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Class, p.Decision = class, d
+	return d, nil
+}
+
+// Decide makes the steps of the pipeline to the given CSV conformant data, with
+// a header row, then decides on the result as Decision.Decide does. Steps on a
+// column the data lacks, such as the class, are skipped.
+//
+func (p *Pipeline) Decide(data [][]string) ([]string, error) {
+	if p.Decision == nil {
+		return nil, fmt.Errorf("id3: pipeline has no decision")
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	view, err := p.apply(newBaseView(data), true)
+	if err != nil {
+		return nil, err
+	}
+	applied := [][]string{view.Columns()}
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		applied = append(applied, row)
+	}
+	return p.Decision.Decide(applied)
+}
+
+// ToJSON returns this pipeline as a JSON formatted bytes slice.
+//
+func (p *Pipeline) ToJSON(indent bool) ([]byte, error) {
+	switch indent {
+	case false:
+		return json.Marshal(p)
+	default:
+		return json.MarshalIndent(p, "", "    ")
+	}
+}
+
+// PipelineFromJSON translates the given JSON formatted byte slice into a
+// pipeline.
+//
+func PipelineFromJSON(b []byte) (*Pipeline, error) {
+	p := new(Pipeline)
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// newStepView returns a view of the parent with the given column names, making
+// the change to the column at the index if not nil. Its columns are indexed now
+// so that the view can be shared.
+//
+func newStepView(parent View, columns []string, col int, change func(string) string) *stepView {
+	return &stepView{parent: parent, columns: columns, col: col, change: change, index: newIndex(columns)}
+}
+
+type stepView struct {
+	parent  View                // Inherit from the parent view.
+	columns []string            // The column names, which a step may rename.
+	col     int                 // Column index of the column changed.
+	change  func(string) string // The change to the value of that column, or nil.
+	index   map[string]int      // The index of each column.
+}
+
+func (s *stepView) Columns() []string { return s.columns }
+
+func (s *stepView) lookup(column string) (int, bool) {
+	i, ok := s.index[column]
+	return i, ok
+}
+
+func (s *stepView) First() { s.parent.First() }
+
+func (s *stepView) Next() []string { return s.apply(s.parent.Next()) }
+
+func (s *stepView) rows() func() []string {
+	next := rowsIn(s.parent)
+	return func() []string { return s.apply(next()) }
+}
+
+// apply returns the row of the parent as changed by the step.
+//
+func (s *stepView) apply(row []string) []string {
+	if row == nil || s.change == nil {
+		return row
+	}
+	out := append([]string(nil), row...)
+	out[s.col] = s.change(out[s.col])
+	return out
+}
+
+func (s *stepView) Select(column, value string) View {
+	return &selectView{
+		parent: s,
+		col:    mustIndexOf(s, column),
+		val:    value,
+	}
+}
+
+func (s *stepView) Drop(column string) View {
+	return newDropView(s, mustIndexOf(s, column))
+}
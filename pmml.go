@@ -0,0 +1,370 @@
+package id3
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pmmlNamespace and pmmlVersion are those of the PMML written by ToPMML.
+//
+const (
+	pmmlNamespace = "http://www.dmg.org/PMML-4_4"
+	pmmlVersion   = "4.4"
+)
+
+// The subset of PMML needed to represent a TreeModel of categorical and
+// threshold splits.
+//
+type pmmlDocument struct {
+	XMLName        xml.Name            `xml:"PMML"`
+	Namespace      string              `xml:"xmlns,attr,omitempty"`
+	Version        string              `xml:"version,attr,omitempty"`
+	Header         *pmmlHeader         `xml:"Header"`
+	DataDictionary *pmmlDataDictionary `xml:"DataDictionary"`
+	TreeModel      *pmmlTreeModel      `xml:"TreeModel"`
+}
+
+type pmmlHeader struct {
+	Application pmmlApplication `xml:"Application"`
+}
+
+type pmmlApplication struct {
+	Name string `xml:"name,attr"`
+}
+
+type pmmlDataDictionary struct {
+	NumberOfFields int             `xml:"numberOfFields,attr"`
+	DataFields     []pmmlDataField `xml:"DataField"`
+}
+
+type pmmlDataField struct {
+	Name     string      `xml:"name,attr"`
+	Optype   string      `xml:"optype,attr"`
+	DataType string      `xml:"dataType,attr"`
+	Values   []pmmlValue `xml:"Value"`
+}
+
+type pmmlValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type pmmlTreeModel struct {
+	FunctionName string            `xml:"functionName,attr"`
+	MiningSchema *pmmlMiningSchema `xml:"MiningSchema"`
+	Node         pmmlNode          `xml:"Node"`
+}
+
+type pmmlMiningSchema struct {
+	MiningFields []pmmlMiningField `xml:"MiningField"`
+}
+
+type pmmlMiningField struct {
+	Name      string `xml:"name,attr"`
+	UsageType string `xml:"usageType,attr,omitempty"`
+}
+
+type pmmlNode struct {
+	Score              string                  `xml:"score,attr,omitempty"`
+	RecordCount        float64                 `xml:"recordCount,attr,omitempty"`
+	True               *struct{}               `xml:"True"`
+	SimplePredicate    *pmmlSimplePredicate    `xml:"SimplePredicate"`
+	SimpleSetPredicate *pmmlSimpleSetPredicate `xml:"SimpleSetPredicate"`
+	ScoreDistributions []pmmlScoreDistribution `xml:"ScoreDistribution"`
+	Nodes              []pmmlNode              `xml:"Node"`
+}
+
+type pmmlSimplePredicate struct {
+	Field    string `xml:"field,attr"`
+	Operator string `xml:"operator,attr"`
+	Value    string `xml:"value,attr,omitempty"`
+}
+
+type pmmlSimpleSetPredicate struct {
+	Field           string    `xml:"field,attr"`
+	BooleanOperator string    `xml:"booleanOperator,attr"`
+	Array           pmmlArray `xml:"Array"`
+}
+
+type pmmlArray struct {
+	N     int    `xml:"n,attr,omitempty"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type pmmlScoreDistribution struct {
+	Value       string  `xml:"value,attr"`
+	RecordCount float64 `xml:"recordCount,attr"`
+}
+
+// FromPMML translates the TreeModel in the given PMML document into a decision.
+// Each child of a node must test the same field with either a SimplePredicate
+// using the "equal" or "isMissing" operator or a SimpleSetPredicate using
+// "isIn", except that a leaf with a True predicate becomes the default class.
+// Alternatively the children may test a number against one threshold with the
+// "lessOrEqual" and "greaterThan" operators, as ToPMML writes, which become a
+// "<=" Test. Record counts in ScoreDistribution elements become the class
+// frequencies of the leaves.
+//
+func FromPMML(b []byte) (*Decision, error) {
+	doc := new(pmmlDocument)
+	if err := xml.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	if doc.TreeModel == nil {
+		return nil, errors.New("id3: PMML has no TreeModel")
+	}
+	if len(doc.TreeModel.Node.Nodes) == 0 {
+		return nil, errors.New("id3: PMML TreeModel has no splits")
+	}
+	return fromPMMLNode(&doc.TreeModel.Node)
+}
+
+func fromPMMLNode(n *pmmlNode) (*Decision, error) {
+	d := new(Decision)
+	for i := range n.Nodes {
+		child := &n.Nodes[i]
+		field, values, test, err := child.predicate()
+		if err != nil {
+			return nil, err
+		}
+		if field == "" {
+			//
+			// A True predicate, where supported, is the default class.
+			//
+			if len(child.Nodes) > 0 || child.Score == "" {
+				return nil, errors.New("id3: unsupported PMML True predicate without a score")
+			}
+			d.Default = child.Score
+			continue
+		}
+		if d.Column == "" {
+			d.Column = field
+		}
+		if field != d.Column {
+			return nil, fmt.Errorf("id3: PMML node splits on both '%s' and '%s'", d.Column, field)
+		}
+		if test != nil && len(d.Cases) == 0 {
+			d.Test = test
+		}
+		if (test == nil) != (d.Test == nil) || (test != nil && *test != *d.Test) {
+			return nil, fmt.Errorf("id3: PMML node splits on '%s' by different predicates", field)
+		}
+		var sub *Decision
+		if len(child.Nodes) > 0 {
+			sub, err = fromPMMLNode(child)
+			if err != nil {
+				return nil, err
+			}
+		} else if child.Score == "" {
+			return nil, fmt.Errorf("id3: PMML leaf for '%s' has no score", field)
+		}
+		for i, v := range values {
+			c := &Case{Value: v, Decide: sub}
+			switch {
+			case sub == nil:
+				c.Class = child.Score
+				c.Counts = child.counts()
+			case i > 0:
+				//
+				// Each value of a set has a decision of its own, to be edited
+				// apart from the others.
+				//
+				c.Decide = sub.clone()
+			}
+			d.Cases = append(d.Cases, c)
+		}
+	}
+	return d, nil
+}
+
+// predicate returns the field and the values selected by this node's
+// predicate, or no field for a True predicate. For a threshold the value is
+// the outcome of the test returned.
+//
+func (n *pmmlNode) predicate() (string, []string, *Test, error) {
+	switch {
+	case n.SimplePredicate != nil:
+		p := n.SimplePredicate
+		switch p.Operator {
+		case "equal":
+			return p.Field, []string{p.Value}, nil, nil
+		case "isMissing":
+			return p.Field, []string{""}, nil, nil
+		case "lessOrEqual", "greaterThan":
+			if _, err := strconv.ParseFloat(p.Value, 64); err != nil {
+				return "", nil, nil, fmt.Errorf("id3: PMML threshold '%s' is not a number", p.Value)
+			}
+			outcome := "true"
+			if p.Operator == "greaterThan" {
+				outcome = "false"
+			}
+			return p.Field, []string{outcome}, &Test{Op: opLessOrEqual, Operand: p.Value}, nil
+		}
+		return "", nil, nil, fmt.Errorf("id3: unsupported PMML operator '%s'", p.Operator)
+	case n.True != nil:
+		return "", nil, nil, nil
+	case n.SimpleSetPredicate != nil:
+		p := n.SimpleSetPredicate
+		if p.BooleanOperator != "isIn" {
+			return "", nil, nil, fmt.Errorf("id3: unsupported PMML set operator '%s'", p.BooleanOperator)
+		}
+		values, err := splitPMMLArray(p.Array.Value)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return p.Field, values, nil, nil
+	default:
+		return "", nil, nil, errors.New("id3: unsupported PMML predicate")
+	}
+}
+
+func (n *pmmlNode) counts() map[string]int {
+	if len(n.ScoreDistributions) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, s := range n.ScoreDistributions {
+		counts[s.Value] = int(s.RecordCount)
+	}
+	return counts
+}
+
+// splitPMMLArray splits the content of a PMML string Array, which is space
+// separated with double quotes around values containing spaces.
+//
+func splitPMMLArray(s string) ([]string, error) {
+	var values []string
+	s = strings.TrimSpace(s)
+	for s != "" {
+		if s[0] == '"' {
+			v, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("id3: malformed PMML array: %v", err)
+			}
+			s = s[len(v):]
+			values = append(values, strings.ReplaceAll(v[1:len(v)-1], `\"`, `"`))
+		} else {
+			i := strings.IndexAny(s, " \t\r\n")
+			if i < 0 {
+				i = len(s)
+			}
+			values = append(values, s[:i])
+			s = s[i:]
+		}
+		s = strings.TrimSpace(s)
+	}
+	return values, nil
+}
+
+// ToPMML returns this decision as a PMML 4.4 document holding a classification
+// TreeModel for the named class column, so that standard scoring engines can
+// use it. Each case is a node whose SimplePredicate tests its value, or
+// "isMissing" for the missing value, and a default class is a last node with a
+// True predicate. A threshold learned WithSchema is a "lessOrEqual" or
+// "greaterThan" test of a continuous field. Each leaf has a score, and the
+// class frequencies from learning, if any, as its ScoreDistribution. The
+// "contains" test cannot be expressed.
+//
+func (d *Decision) ToPMML(class string) ([]byte, error) {
+	values := make(map[string][]string)
+	continuous := make(map[string]bool)
+	root, err := d.toPMMLNode(values, continuous)
+	if err != nil {
+		return nil, err
+	}
+	doc := &pmmlDocument{
+		Namespace:      pmmlNamespace,
+		Version:        pmmlVersion,
+		Header:         &pmmlHeader{Application: pmmlApplication{Name: "id3"}},
+		DataDictionary: new(pmmlDataDictionary),
+		TreeModel:      &pmmlTreeModel{FunctionName: "classification", MiningSchema: new(pmmlMiningSchema), Node: *root},
+	}
+	for _, c := range d.Columns() {
+		field := pmmlDataField{Name: c, Optype: "categorical", DataType: "string"}
+		if continuous[c] {
+			field.Optype, field.DataType = "continuous", "double"
+		}
+		for _, v := range values[c] {
+			field.Values = append(field.Values, pmmlValue{v})
+		}
+		doc.DataDictionary.DataFields = append(doc.DataDictionary.DataFields, field)
+		doc.TreeModel.MiningSchema.MiningFields = append(doc.TreeModel.MiningSchema.MiningFields, pmmlMiningField{Name: c})
+	}
+	target := pmmlDataField{Name: class, Optype: "categorical", DataType: "string"}
+	for _, c := range d.Classes() {
+		target.Values = append(target.Values, pmmlValue{c})
+	}
+	doc.DataDictionary.DataFields = append(doc.DataDictionary.DataFields, target)
+	doc.DataDictionary.NumberOfFields = len(doc.DataDictionary.DataFields)
+	doc.TreeModel.MiningSchema.MiningFields = append(doc.TreeModel.MiningSchema.MiningFields, pmmlMiningField{Name: class, UsageType: "target"})
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+// toPMMLNode returns the node with a True predicate whose children are the
+// cases of this decision, adding the values of each column tested to values,
+// and noting the columns tested on a threshold.
+//
+func (d *Decision) toPMMLNode(values map[string][]string, continuous map[string]bool) (*pmmlNode, error) {
+	if d.Test != nil && (d.Test.Op != opLessOrEqual || math.IsNaN(d.Test.threshold())) {
+		return nil, fmt.Errorf("id3: PMML cannot express the test on '%s'", d.Column)
+	}
+	n := &pmmlNode{True: &struct{}{}}
+	for _, c := range d.Cases {
+		p := &pmmlSimplePredicate{Field: d.Column, Operator: "equal", Value: c.Value}
+		switch {
+		case d.Test != nil:
+			continuous[d.Column] = true
+			p.Operator, p.Value = "lessOrEqual", strconv.FormatFloat(d.Test.threshold(), 'g', -1, 64)
+			if c.Value != "true" {
+				p.Operator = "greaterThan"
+			}
+		case c.Value == "":
+			p.Operator = "isMissing"
+		case !contains(values[d.Column], c.Value):
+			values[d.Column] = append(values[d.Column], c.Value)
+		}
+		child := pmmlNode{SimplePredicate: p}
+		switch {
+		case c.Decide != nil:
+			sub, err := c.Decide.toPMMLNode(values, continuous)
+			if err != nil {
+				return nil, err
+			}
+			child.Nodes = sub.Nodes
+		case c.Class != "":
+			child.Score = c.Class
+			child.scoreDistributions(c.Counts)
+		default:
+			return nil, fmt.Errorf("id3: case '%s' of '%s' has no class or decision", c.Value, d.Column)
+		}
+		n.Nodes = append(n.Nodes, child)
+	}
+	if d.Default != "" {
+		n.Nodes = append(n.Nodes, pmmlNode{Score: d.Default, True: &struct{}{}})
+	}
+	return n, nil
+}
+
+// scoreDistributions sets the record count and score distributions of this
+// leaf from the class frequencies, in class order.
+//
+func (n *pmmlNode) scoreDistributions(counts map[string]int) {
+	classes := make([]string, 0, len(counts))
+	for c := range counts {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	for _, c := range classes {
+		n.RecordCount += float64(counts[c])
+		n.ScoreDistributions = append(n.ScoreDistributions, pmmlScoreDistribution{Value: c, RecordCount: float64(counts[c])})
+	}
+}
@@ -0,0 +1,83 @@
+package id3
+
+// Project returns a view showing only the named columns of the view, hiding
+// the others as Drop does, so that a decision can be learned from a few
+// columns of wide data without dropping the rest one at a time. It fails with
+// ErrColumnNotFound if the view does not show one of them.
+//
+func Project(view View, columns ...string) (View, error) {
+	if err := CheckColumns(view, columns...); err != nil {
+		return nil, err
+	}
+	shown := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		shown[c] = true
+	}
+	var hide []string
+	for _, c := range view.Columns() {
+		if c != "" && !shown[c] {
+			hide = append(hide, c)
+		}
+	}
+	return hideColumns(view, hide), nil
+}
+
+// Exclude returns a view hiding the named columns of the view, as Drop does
+// for one, and showing the others. It fails with ErrColumnNotFound if the view
+// does not show one of them.
+//
+func Exclude(view View, columns ...string) (View, error) {
+	if err := CheckColumns(view, columns...); err != nil {
+		return nil, err
+	}
+	return hideColumns(view, columns), nil
+}
+
+// hideColumns returns a view hiding the columns, which it must show.
+//
+func hideColumns(view View, columns []string) View {
+	if len(columns) == 0 {
+		return view
+	}
+	h := &hideView{parent: view, hide: make(map[int]bool, len(columns))}
+	h.columns = append([]string(nil), view.Columns()...)
+	for _, c := range columns {
+		i := mustIndexOf(view, c)
+		h.hide[i] = true
+		h.columns[i] = ""
+	}
+	return h
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type hideView struct {
+	parent  View         // Inherit from the parent.
+	hide    map[int]bool // Column indices of the columns hidden from the view.
+	columns []string     // The column names, with those hidden as "".
+}
+
+func (h *hideView) Columns() []string { return h.columns }
+
+func (h *hideView) lookup(column string) (int, bool) {
+	i, ok := lookupIn(h.parent, column)
+	return i, ok && !h.hide[i]
+}
+
+func (h *hideView) First() { h.parent.First() }
+
+func (h *hideView) Next() []string { return h.parent.Next() }
+
+func (h *hideView) rows() func() []string { return rowsIn(h.parent) }
+
+func (h *hideView) Select(column, value string) View {
+	return &selectView{
+		parent: h,
+		col:    mustIndexOf(h, column),
+		val:    value,
+	}
+}
+
+func (h *hideView) Drop(column string) View {
+	return newDropView(h, mustIndexOf(h, column))
+}
@@ -0,0 +1,211 @@
+package id3
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/gbkr-com/id3/internal/wire"
+)
+
+// ToProto returns this decision in the protocol buffers wire format, as the
+// Decision message defined in id3.proto.
+//
+func (d *Decision) ToProto() ([]byte, error) {
+	return d.toProto().Encoded(), nil
+}
+
+func (d *Decision) toProto() *wire.Writer {
+	w := new(wire.Writer)
+	if d.Column != "" {
+		w.String(1, d.Column)
+	}
+	for _, c := range d.Cases {
+		m := new(wire.Writer)
+		if c.Value != "" {
+			m.String(1, c.Value)
+		}
+		if c.Class != "" {
+			m.String(2, c.Class)
+		}
+		if c.Decide != nil {
+			m.Message(3, c.Decide.toProto())
+		}
+		keys := make([]string, 0, len(c.Counts))
+		for k := range c.Counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entry := new(wire.Writer)
+			entry.String(1, k)
+			entry.Varint(2, int64(c.Counts[k]))
+			m.Message(4, entry)
+		}
+		w.Message(2, m)
+	}
+	if d.Default != "" {
+		w.String(3, d.Default)
+	}
+	if d.Test != nil {
+		t := new(wire.Writer)
+		t.String(1, d.Test.Op)
+		t.String(2, d.Test.Operand)
+		if d.Test.Separator != "" {
+			t.String(3, d.Test.Separator)
+		}
+		w.Message(4, t)
+	}
+	return w
+}
+
+// FromProto translates the given protocol buffers Decision message, as defined
+// in id3.proto, into a decision. Unknown fields are ignored.
+//
+func FromProto(b []byte) (*Decision, error) {
+	d := new(Decision)
+	r := wire.NewReader(b)
+	for r.More() {
+		field, typ, _, v, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && typ == wire.TypeBytes:
+			d.Column = string(v)
+		case field == 2 && typ == wire.TypeBytes:
+			c, err := caseFromProto(v)
+			if err != nil {
+				return nil, err
+			}
+			d.Cases = append(d.Cases, c)
+		case field == 3 && typ == wire.TypeBytes:
+			d.Default = string(v)
+		case field == 4 && typ == wire.TypeBytes:
+			if d.Test, err = testFromProto(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return d, nil
+}
+
+// ToProto returns this committee in the protocol buffers wire format, as the
+// Committee message defined in id3.proto.
+//
+func (c *Committee) ToProto() ([]byte, error) {
+	w := new(wire.Writer)
+	for _, t := range c.Trees {
+		w.Message(1, t.toProto())
+	}
+	for _, weight := range c.Weights {
+		w.Double(2, weight)
+	}
+	return w.Encoded(), nil
+}
+
+// CommitteeFromProto translates the given protocol buffers Committee message,
+// as defined in id3.proto, into a committee. The weights may be packed or not.
+// Unknown fields are ignored.
+//
+func CommitteeFromProto(b []byte) (*Committee, error) {
+	var trees []*Decision
+	var weights []float64
+	r := wire.NewReader(b)
+	for r.More() {
+		field, typ, n, v, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && typ == wire.TypeBytes:
+			t, err := FromProto(v)
+			if err != nil {
+				return nil, err
+			}
+			trees = append(trees, t)
+		case field == 2 && typ == wire.TypeFixed64:
+			weights = append(weights, math.Float64frombits(n))
+		case field == 2 && typ == wire.TypeBytes:
+			if len(v)%8 != 0 {
+				return nil, errors.New("id3: corrupt packed weights")
+			}
+			for ; len(v) > 0; v = v[8:] {
+				weights = append(weights, math.Float64frombits(binary.LittleEndian.Uint64(v)))
+			}
+		}
+	}
+	return BuildCommittee(trees, weights)
+}
+
+func testFromProto(b []byte) (*Test, error) {
+	t := new(Test)
+	r := wire.NewReader(b)
+	for r.More() {
+		field, typ, _, v, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && typ == wire.TypeBytes:
+			t.Op = string(v)
+		case field == 2 && typ == wire.TypeBytes:
+			t.Operand = string(v)
+		case field == 3 && typ == wire.TypeBytes:
+			t.Separator = string(v)
+		}
+	}
+	return t, nil
+}
+
+func caseFromProto(b []byte) (*Case, error) {
+	c := new(Case)
+	r := wire.NewReader(b)
+	for r.More() {
+		field, typ, _, v, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && typ == wire.TypeBytes:
+			c.Value = string(v)
+		case field == 2 && typ == wire.TypeBytes:
+			c.Class = string(v)
+		case field == 3 && typ == wire.TypeBytes:
+			c.Decide, err = FromProto(v)
+			if err != nil {
+				return nil, err
+			}
+		case field == 4 && typ == wire.TypeBytes:
+			key, count, err := countFromProto(v)
+			if err != nil {
+				return nil, err
+			}
+			if c.Counts == nil {
+				c.Counts = make(map[string]int)
+			}
+			c.Counts[key] = count
+		}
+	}
+	return c, nil
+}
+
+func countFromProto(b []byte) (string, int, error) {
+	var key string
+	var count int
+	r := wire.NewReader(b)
+	for r.More() {
+		field, typ, n, v, err := r.Next()
+		if err != nil {
+			return "", 0, err
+		}
+		switch {
+		case field == 1 && typ == wire.TypeBytes:
+			key = string(v)
+		case field == 2 && typ == wire.TypeVarint:
+			count = int(int64(n))
+		}
+	}
+	return key, count, nil
+}
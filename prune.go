@@ -0,0 +1,94 @@
+package id3
+
+import "fmt"
+
+// Prune returns a copy of the decision pruned by reduced error against the
+// rows of the validation view, using the named class column. Working up from
+// the leaves, each subsequent decision is replaced by a leaf deciding the
+// class held by most of the training rows beneath it, or without class
+// frequencies by most of the validation rows reaching it, wherever that decides
+// no fewer of the validation rows correctly. A decision no validation row
+// reaches is pruned too. The validation rows should not be those learned from.
+// It fails with ErrClassColumnMissing if the view has no such class column, or
+// ErrColumnNotFound if it lacks a column the decision decides on.
+//
+func Prune(d *Decision, validation View, class string) (*Decision, error) {
+	classAt, err := indexOf(validation, class)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	index := make(map[string]int)
+	for _, c := range d.Columns() {
+		if index[c], err = indexOf(validation, c); err != nil {
+			return nil, err
+		}
+	}
+	p := d.clone()
+	var rows [][]string
+	validation.First()
+	for row := validation.Next(); row != nil; row = validation.Next() {
+		rows = append(rows, row)
+	}
+	p.prune(rows, index, classAt)
+	return p, nil
+}
+
+// prune prunes beneath this decision, given the validation rows reaching it
+// and the index of each column, and returns how many of them it then decides
+// wrongly.
+//
+func (d *Decision) prune(rows [][]string, index map[string]int, classAt int) int {
+	i := index[d.Column]
+	reaching := make(map[*Case][][]string, len(d.Cases))
+	wrong := 0
+	for _, row := range rows {
+		if c := d.caseOf(row[i], nil); c != nil {
+			reaching[c] = append(reaching[c], row)
+		} else if row[classAt] != d.Default {
+			wrong++
+		}
+	}
+	for _, c := range d.Cases {
+		rows := reaching[c]
+		if c.Decide == nil {
+			wrong += misses(rows, classAt, c.Class)
+			continue
+		}
+		below := c.Decide.prune(rows, index, classAt)
+		counts := c.totals()
+		leaf := majority(weighed(counts))
+		if leaf == "" {
+			leaf = majority(weighed(classesOf(rows, classAt)))
+		}
+		if n := misses(rows, classAt, leaf); leaf != "" && n <= below {
+			if len(counts) == 0 {
+				counts = nil
+			}
+			c.Class, c.Decide, c.Counts = leaf, nil, counts
+			below = n
+		}
+		wrong += below
+	}
+	return wrong
+}
+
+// misses returns how many of the rows are not of the class.
+//
+func misses(rows [][]string, classAt int, class string) (n int) {
+	for _, row := range rows {
+		if row[classAt] != class {
+			n++
+		}
+	}
+	return
+}
+
+// classesOf returns the class frequencies of the rows.
+//
+func classesOf(rows [][]string, classAt int) map[string]int {
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row[classAt]]++
+	}
+	return counts
+}
@@ -0,0 +1,67 @@
+package id3
+
+import "fmt"
+
+// Refit returns a copy of the decision with the same structure, but with the
+// class and class frequencies of every leaf recomputed from the rows of the
+// view, using the named class column. Each leaf decides the class held by most
+// of the rows reaching it, as does each default class. Leaves and defaults that
+// no row reaches keep their class, and leaves lose their frequencies. It fails
+// with ErrClassColumnMissing if the view has no such class column, or
+// ErrColumnNotFound if it lacks a column the decision decides on.
+//
+func Refit(d *Decision, view View, class string) (*Decision, error) {
+	classAt, err := indexOf(view, class)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	if err := CheckColumns(view, d.Columns()...); err != nil {
+		return nil, err
+	}
+	r := d.clone()
+	//
+	// Tally the classes of the rows at each leaf and each default, following
+	// the rows as a prediction would before the frequencies are replaced.
+	//
+	columns := view.Columns()
+	index := make(map[string]int)
+	leaves := make(map[*Case]map[string]int)
+	defaults := make(map[*Decision]map[string]int)
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		at, c, err := r.walk(row, columns, index, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case c != nil:
+			if leaves[c] == nil {
+				leaves[c] = make(map[string]int)
+			}
+			leaves[c][row[classAt]]++
+		case at.Default != "":
+			if defaults[at] == nil {
+				defaults[at] = make(map[string]int)
+			}
+			defaults[at][row[classAt]]++
+		}
+	}
+	var relabel func(d *Decision)
+	relabel = func(d *Decision) {
+		if counts, ok := defaults[d]; ok {
+			d.Default = majority(weighed(counts))
+		}
+		for _, c := range d.Cases {
+			if c.Decide != nil {
+				relabel(c.Decide)
+				continue
+			}
+			c.Counts = leaves[c]
+			if c.Counts != nil {
+				c.Class = majority(weighed(c.Counts))
+			}
+		}
+	}
+	relabel(r)
+	return r, nil
+}
@@ -0,0 +1,336 @@
+package id3
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A Condition tests whether a column has a value or, where there is a Test,
+// whether the outcome of the test on the column is the value.
+//
+type Condition struct {
+	Column string
+	Value  string
+	Test   *Test `json:",omitempty"`
+}
+
+// String returns the condition as text, for example "outlook=sunny", "tags
+// contains urgent" or "temperature > 20.5".
+//
+func (c Condition) String() string {
+	switch {
+	case c.Test == nil:
+		return c.Column + "=" + c.Value
+	case c.Value == "true":
+		return c.Column + " " + c.Test.String()
+	}
+	return c.Column + " " + c.Test.negation()
+}
+
+// A Rule is the conjunction of conditions on the path from the root of a
+// decision tree to a decided class.
+//
+type Rule struct {
+	Conditions []Condition
+	Class      string
+	Counts     map[string]int // The class frequencies of the training rows, if known.
+}
+
+// ToRules returns a rule for each decided class in this decision, in the order
+// of the cases. Default classes cannot be expressed as rules, so are omitted.
+//
+func (d *Decision) ToRules() []Rule {
+	var rules []Rule
+	var walk func(d *Decision, path []Condition)
+	walk = func(d *Decision, path []Condition) {
+		for _, c := range d.Cases {
+			conditions := append(path[:len(path):len(path)], Condition{Column: d.Column, Value: c.Value, Test: d.Test})
+			if c.Decide != nil {
+				walk(c.Decide, conditions)
+				continue
+			}
+			rules = append(rules, Rule{Conditions: conditions, Class: c.Class, Counts: c.Counts})
+		}
+	}
+	walk(d, nil)
+	return rules
+}
+
+// WriteRules writes the rules of this decision, in the order of ToRules, to
+// the writer as formatted by Rule.Format, one per line, such as for review by
+// those who do not read trees. ParseRules reads them back.
+//
+func (d *Decision) WriteRules(w io.Writer, class string) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range d.ToRules() {
+		bw.WriteString(r.Format(class) + "\n")
+	}
+	return bw.Flush()
+}
+
+// Format returns the rule as text, using the name of the class column, for
+// example "IF outlook=sunny AND humidity=high THEN play=no". Names and values
+// are double quoted where necessary. A condition on a test is written as, for
+// example, "tags contains urgent" or "NOT tags contains urgent", or for a
+// threshold "temperature <= 20.5" or "temperature > 20.5", which ParseRules
+// does not read.
+//
+func (r Rule) Format(class string) string {
+	var b strings.Builder
+	b.WriteString("IF ")
+	for i, c := range r.Conditions {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		switch {
+		case c.Test == nil:
+			b.WriteString(ruleQuote(c.Column) + "=" + ruleQuote(c.Value))
+		case c.Value == "true":
+			b.WriteString(ruleQuote(c.Column) + " " + c.Test.Op + " " + ruleQuote(c.Test.Operand))
+		case c.Test.Op == opLessOrEqual:
+			b.WriteString(ruleQuote(c.Column) + " > " + ruleQuote(c.Test.Operand))
+		default:
+			b.WriteString("NOT " + ruleQuote(c.Column) + " " + c.Test.Op + " " + ruleQuote(c.Test.Operand))
+		}
+	}
+	b.WriteString(" THEN " + ruleQuote(class) + "=" + ruleQuote(r.Class))
+	return b.String()
+}
+
+// ParseRules reads rules in the text format written by Rule.Format, one per
+// line, and returns them with the name of the class column. Blank lines and
+// lines starting with '#' are ignored. All the rules must decide the same class
+// column.
+//
+func ParseRules(text string) ([]Rule, string, error) {
+	var rules []Rule
+	class := ""
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r, c, err := parseRule(line)
+		if err != nil {
+			return nil, "", fmt.Errorf("id3: rule line %d: %v", n, err)
+		}
+		if class != "" && c != class {
+			return nil, "", fmt.Errorf("id3: rule line %d: class column '%s' differs from '%s'", n, c, class)
+		}
+		class = c
+		rules = append(rules, r)
+	}
+	return rules, class, scanner.Err()
+}
+
+// FromRules builds a decision from the given rules. The rules must describe a
+// tree: rules that agree on their first conditions must test the same column
+// next, and no rule may be a prefix of another.
+//
+func FromRules(rules []Rule) (*Decision, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("id3: no rules")
+	}
+	root := new(Decision)
+	for _, r := range rules {
+		if len(r.Conditions) == 0 {
+			return nil, fmt.Errorf("id3: rule for '%s' has no conditions", r.Class)
+		}
+		d := root
+		for i, cond := range r.Conditions {
+			if d.Column == "" {
+				d.Column = cond.Column
+				d.Test = cond.Test
+			}
+			if d.Column != cond.Column {
+				return nil, fmt.Errorf("id3: rules test both '%s' and '%s' at the same point", d.Column, cond.Column)
+			}
+			if !sameTest(d.Test, cond.Test) {
+				return nil, fmt.Errorf("id3: rules test '%s' in different ways at the same point", d.Column)
+			}
+			var c *Case
+			for _, k := range d.Cases {
+				if k.Value == cond.Value {
+					c = k
+				}
+			}
+			last := i == len(r.Conditions)-1
+			if c == nil {
+				c = &Case{Value: cond.Value}
+				if !last {
+					c.Decide = new(Decision)
+				}
+				d.Cases = append(d.Cases, c)
+			} else if last || c.Decide == nil {
+				return nil, fmt.Errorf("id3: rules conflict at %v", cond)
+			}
+			if last {
+				c.Class = r.Class
+				c.Counts = r.Counts
+			}
+			d = c.Decide
+		}
+	}
+	return root, nil
+}
+
+// sameTest reports whether the tests, either of which may be nil, are equal.
+//
+func sameTest(a, b *Test) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func parseRule(line string) (Rule, string, error) {
+	var r Rule
+	s := line
+	if !strings.HasPrefix(s, "IF ") {
+		return r, "", fmt.Errorf("expected 'IF'")
+	}
+	s = s[len("IF "):]
+	for {
+		column, value, rest, err := parseRuleTerm(s)
+		if err != nil {
+			return r, "", err
+		}
+		s = strings.TrimLeft(rest, " ")
+		switch {
+		case strings.HasPrefix(s, "AND "):
+			r.Conditions = append(r.Conditions, Condition{Column: column, Value: value})
+			s = s[len("AND "):]
+		case strings.HasPrefix(s, "THEN "):
+			r.Conditions = append(r.Conditions, Condition{Column: column, Value: value})
+			class, value, rest, err := parseRuleTerm(s[len("THEN "):])
+			if err != nil {
+				return r, "", err
+			}
+			if strings.TrimSpace(rest) != "" {
+				return r, "", fmt.Errorf("unexpected '%s'", strings.TrimSpace(rest))
+			}
+			r.Class = value
+			return r, class, nil
+		default:
+			return r, "", fmt.Errorf("expected 'AND' or 'THEN'")
+		}
+	}
+}
+
+// parseRuleTerm parses "name=value" at the start of s, returning the remainder.
+//
+func parseRuleTerm(s string) (string, string, string, error) {
+	name, s, err := parseRuleWord(strings.TrimLeft(s, " "), "=")
+	if err != nil {
+		return "", "", "", err
+	}
+	if !strings.HasPrefix(s, "=") {
+		return "", "", "", fmt.Errorf("expected '=' after '%s'", name)
+	}
+	value, s, err := parseRuleWord(s[1:], " ")
+	return name, value, s, err
+}
+
+func parseRuleWord(s, stop string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) {
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", err
+		}
+		v, err := strconv.Unquote(q)
+		return v, s[len(q):], err
+	}
+	i := strings.IndexAny(s, stop)
+	if i < 0 {
+		i = len(s)
+	}
+	if i == 0 {
+		return "", "", fmt.Errorf("expected a name or value")
+	}
+	return s[:i], s[i:], nil
+}
+
+func ruleQuote(s string) string {
+	if s == "" || strings.ContainsAny(s, ` ="`) || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// RuleStats is a rule with its coverage and accuracy on some data.
+//
+type RuleStats struct {
+	Rule
+	Covered  int     // The number of rows meeting the conditions.
+	Correct  int     // The number of those rows having the class of the rule.
+	Accuracy float64 // Correct as a fraction of Covered, or zero.
+}
+
+// Rules returns the rules of this decision, as for ToRules, with the number of
+// rows in the view each covers and its accuracy on them, using the named class
+// column. It fails with ErrClassColumnMissing if the view has no such column.
+//
+func (d *Decision) Rules(view View, class string) ([]RuleStats, error) {
+	classAt, err := indexOf(view, class)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrClassColumnMissing, class)
+	}
+	rules := d.ToRules()
+	stats := make([]RuleStats, len(rules))
+	leaves := make(map[*Case]int)
+	var walk func(d *Decision)
+	walk = func(d *Decision) {
+		for _, c := range d.Cases {
+			if c.Decide != nil {
+				walk(c.Decide)
+				continue
+			}
+			leaves[c] = len(leaves)
+		}
+	}
+	walk(d)
+	for i, r := range rules {
+		stats[i].Rule = r
+	}
+	//
+	// Follow each row to its leaf, in a single pass.
+	//
+	columns := view.Columns()
+	index := make(map[string]int)
+	view.First()
+	for {
+		row := view.Next()
+		if row == nil {
+			break
+		}
+		c := d.leaf(row, columns, index)
+		if c == nil {
+			continue
+		}
+		if i, ok := leaves[c]; ok {
+			stats[i].Covered++
+			if row[classAt] == c.Class {
+				stats[i].Correct++
+			}
+		}
+	}
+	for i := range stats {
+		if stats[i].Covered > 0 {
+			stats[i].Accuracy = float64(stats[i].Correct) / float64(stats[i].Covered)
+		}
+	}
+	return stats, nil
+}
+
+// leaf returns the leaf case this decision reaches for the row, as Decide
+// would, or nil if there is no case for a value or no column. The column
+// indices are cached in index.
+//
+func (d *Decision) leaf(row []string, columns []string, index map[string]int) *Case {
+	_, c, _ := d.walk(row, columns, index, nil)
+	return c
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbkr-com/id3/internal/wire"
+)
+
+// The gRPC status codes used by the scoring service.
+//
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcDeadlineExceeded  = 4
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+)
+
+// grpcError is a failed call with its gRPC status code.
+//
+type grpcError struct {
+	code    int
+	message string
+}
+
+func (e *grpcError) Error() string { return e.message }
+
+// isGRPC returns whether the request is a gRPC call, which needs HTTP/2.
+//
+func isGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// serveGRPC answers a unary call to the Scoring service defined in
+// scoring.proto. Only the identity encoding is supported, and the deadline in
+// the grpc-timeout header is honoured.
+//
+func (h *Handler) serveGRPC(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.withTimeout(r.Context())
+	defer cancel()
+	if timeout, ok := grpcTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	var reply []byte
+	release, err := h.admit(ctx)
+	switch {
+	case err == errSaturated:
+		h.log(slog.LevelWarn, "id3 server: request rejected", "reason", err)
+		err = &grpcError{grpcResourceExhausted, err.Error()}
+	case err != nil:
+		err = &grpcError{grpcDeadlineExceeded, err.Error()}
+	default:
+		reply, err = h.call(ctx, r)
+		release()
+	}
+	if err == nil && ctx.Err() != nil {
+		err = &grpcError{grpcDeadlineExceeded, ctx.Err().Error()}
+	}
+	w.WriteHeader(http.StatusOK)
+	if err != nil {
+		var ge *grpcError
+		if !errors.As(err, &ge) {
+			ge = &grpcError{grpcInternal, err.Error()}
+		}
+		w.Header().Set("Grpc-Status", strconv.Itoa(ge.code))
+		w.Header().Set("Grpc-Message", url.PathEscape(ge.message))
+		return
+	}
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(reply)))
+	w.Write(prefix[:])
+	w.Write(reply)
+	w.Header().Set("Grpc-Status", strconv.Itoa(grpcOK))
+}
+
+// call reads the request message and returns the reply message.
+//
+func (h *Handler) call(ctx context.Context, r *http.Request) ([]byte, error) {
+	if enc := r.Header.Get("Grpc-Encoding"); enc != "" && enc != "identity" {
+		return nil, &grpcError{grpcUnimplemented, "unsupported encoding " + enc}
+	}
+	limit := h.MaxBodyBytes
+	if limit == 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	var prefix [5]byte
+	if _, err := io.ReadFull(r.Body, prefix[:]); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "no request message"}
+	}
+	if prefix[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if int64(n) > limit {
+		return nil, &grpcError{grpcInvalidArgument, "request message too large"}
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r.Body, msg); err != nil {
+		return nil, &grpcError{grpcInvalidArgument, "truncated request message"}
+	}
+	m := h.current()
+	switch r.URL.Path {
+	case "/id3.server.Scoring/Predict":
+		record, err := decodeRecord(msg)
+		if err != nil {
+			return nil, &grpcError{grpcInvalidArgument, err.Error()}
+		}
+		if err := m.validate([]map[string]string{record}); err != nil {
+			return nil, &grpcError{grpcInvalidArgument, err.Error()}
+		}
+		p := m.predict(record, h.Options)
+		h.observe(p)
+		return encodePrediction(p).Encoded(), nil
+	case "/id3.server.Scoring/PredictBatch":
+		var records []map[string]string
+		rd := wire.NewReader(msg)
+		for rd.More() {
+			field, typ, _, b, err := rd.Next()
+			if err != nil {
+				return nil, &grpcError{grpcInvalidArgument, err.Error()}
+			}
+			if field == 1 && typ == wire.TypeBytes {
+				record, err := decodeRecord(b)
+				if err != nil {
+					return nil, &grpcError{grpcInvalidArgument, err.Error()}
+				}
+				records = append(records, record)
+			}
+		}
+		if err := m.validate(records); err != nil {
+			return nil, &grpcError{grpcInvalidArgument, err.Error()}
+		}
+		out := new(wire.Writer)
+		for _, record := range records {
+			if err := ctx.Err(); err != nil {
+				return nil, &grpcError{grpcDeadlineExceeded, err.Error()}
+			}
+			p := m.predict(record, h.Options)
+			h.observe(p)
+			out.Message(1, encodePrediction(p))
+		}
+		return out.Encoded(), nil
+	case "/id3.server.Scoring/GetModelInfo":
+		out := new(wire.Writer)
+		for _, c := range m.columns {
+			out.String(1, c)
+		}
+		for _, c := range m.decision.Classes() {
+			out.String(2, c)
+		}
+		out.String(3, m.decision.Hash())
+		return out.Encoded(), nil
+	}
+	return nil, &grpcError{grpcUnimplemented, "unknown method " + r.URL.Path}
+}
+
+// decodeRecord reads a Record message.
+//
+func decodeRecord(b []byte) (map[string]string, error) {
+	record := make(map[string]string)
+	r := wire.NewReader(b)
+	for r.More() {
+		field, typ, _, entry, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if field != 1 || typ != wire.TypeBytes {
+			continue
+		}
+		var k, v string
+		er := wire.NewReader(entry)
+		for er.More() {
+			field, typ, _, b, err := er.Next()
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case field == 1 && typ == wire.TypeBytes:
+				k = string(b)
+			case field == 2 && typ == wire.TypeBytes:
+				v = string(b)
+			}
+		}
+		record[k] = v
+	}
+	return record, nil
+}
+
+// encodePrediction writes a Prediction message.
+//
+func encodePrediction(p Prediction) *wire.Writer {
+	w := new(wire.Writer)
+	if p.Class != "" {
+		w.String(1, p.Class)
+	}
+	classes := make([]string, 0, len(p.Probabilities))
+	for c := range p.Probabilities {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	for _, c := range classes {
+		entry := new(wire.Writer)
+		entry.String(1, c)
+		entry.Double(2, p.Probabilities[c])
+		w.Message(2, entry)
+	}
+	if p.Error != "" {
+		w.String(3, p.Error)
+	}
+	return w
+}
+
+// grpcTimeout parses the grpc-timeout header, an integer and a unit.
+//
+func grpcTimeout(s string) (time.Duration, bool) {
+	if len(s) < 2 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	unit, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/int64(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
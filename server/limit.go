@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// errSaturated is returned when a request is turned away because every worker
+// is busy and the queue is full.
+//
+var errSaturated = errors.New("server is saturated, try again later")
+
+// admit waits for a worker to become free for a request, and returns the
+// function to release it. It fails at once if the queue of waiting requests
+// is full, or when the context is done.
+//
+func (h *Handler) admit(ctx context.Context) (func(), error) {
+	if h.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+	h.once.Do(func() {
+		h.workers = make(chan struct{}, h.MaxConcurrent)
+	})
+	select {
+	case h.workers <- struct{}{}:
+		return h.release, nil
+	default:
+	}
+	if atomic.AddInt64(&h.queued, 1) > int64(h.MaxQueue) {
+		atomic.AddInt64(&h.queued, -1)
+		return nil, errSaturated
+	}
+	defer atomic.AddInt64(&h.queued, -1)
+	select {
+	case h.workers <- struct{}{}:
+		return h.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (h *Handler) release() { <-h.workers }
+
+// withTimeout applies the handler's Timeout, if any, to the context.
+//
+func (h *Handler) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, h.Timeout)
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the request latency
+// histogram.
+//
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// metrics counts requests and predictions, for exposition in the Prometheus
+// text format. The zero value is ready to use.
+//
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[[2]string]int     // By path and status code.
+	latency   map[string]*histogram // By path.
+	classes   map[string]int        // Predictions by class.
+	fallbacks int                   // Predictions of a default class.
+	unknown   map[string]int        // Records without a rule, by column.
+}
+
+type histogram struct {
+	counts []int // For each bucket, not cumulative, and then +Inf.
+	sum    float64
+}
+
+// request records a request. Paths other than those served are counted
+// together, so that the number of series is bounded.
+//
+func (m *metrics) request(path string, status int, d time.Duration) {
+	switch path {
+	case "/predict", "/metrics", "/openapi.json":
+	case "/id3.server.Scoring/Predict", "/id3.server.Scoring/PredictBatch", "/id3.server.Scoring/GetModelInfo":
+	default:
+		path = "other"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = make(map[[2]string]int)
+		m.latency = make(map[string]*histogram)
+	}
+	m.requests[[2]string{path, strconv.Itoa(status)}]++
+	h := m.latency[path]
+	if h == nil {
+		h = &histogram{counts: make([]int, len(latencyBuckets)+1)}
+		m.latency[path] = h
+	}
+	s := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, s)
+	h.counts[i]++
+	h.sum += s
+}
+
+// prediction records the outcome for a record.
+//
+func (m *metrics) prediction(p Prediction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.classes == nil {
+		m.classes = make(map[string]int)
+		m.unknown = make(map[string]int)
+	}
+	switch {
+	case p.unknown != "":
+		m.unknown[p.unknown]++
+	case p.Class != "":
+		m.classes[p.Class]++
+		if p.fallback {
+			m.fallbacks++
+		}
+	}
+}
+
+// write writes the metrics in the Prometheus text exposition format.
+//
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintln(w, "# HELP id3_requests_total Requests by path and status code.")
+	fmt.Fprintln(w, "# TYPE id3_requests_total counter")
+	keys := make([][2]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "id3_requests_total{path=%s,code=%s} %d\n", label(k[0]), label(k[1]), m.requests[k])
+	}
+	fmt.Fprintln(w, "# HELP id3_request_duration_seconds Request latency by path.")
+	fmt.Fprintln(w, "# TYPE id3_request_duration_seconds histogram")
+	paths := make([]string, 0, len(m.latency))
+	for path := range m.latency {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		h := m.latency[path]
+		n := 0
+		for i, le := range latencyBuckets {
+			n += h.counts[i]
+			fmt.Fprintf(w, "id3_request_duration_seconds_bucket{path=%s,le=\"%g\"} %d\n", label(path), le, n)
+		}
+		n += h.counts[len(latencyBuckets)]
+		fmt.Fprintf(w, "id3_request_duration_seconds_bucket{path=%s,le=\"+Inf\"} %d\n", label(path), n)
+		fmt.Fprintf(w, "id3_request_duration_seconds_sum{path=%s} %g\n", label(path), h.sum)
+		fmt.Fprintf(w, "id3_request_duration_seconds_count{path=%s} %d\n", label(path), n)
+	}
+	fmt.Fprintln(w, "# HELP id3_predictions_total Predictions by class.")
+	fmt.Fprintln(w, "# TYPE id3_predictions_total counter")
+	for _, c := range sortedKeys(m.classes) {
+		fmt.Fprintf(w, "id3_predictions_total{class=%s} %d\n", label(c), m.classes[c])
+	}
+	fmt.Fprintln(w, "# HELP id3_fallbacks_total Predictions of a default class, for values without a case.")
+	fmt.Fprintln(w, "# TYPE id3_fallbacks_total counter")
+	fmt.Fprintf(w, "id3_fallbacks_total %d\n", m.fallbacks)
+	fmt.Fprintln(w, "# HELP id3_unknown_values_total Records without a rule for the value of a column.")
+	fmt.Fprintln(w, "# TYPE id3_unknown_values_total counter")
+	for _, c := range sortedKeys(m.unknown) {
+		fmt.Fprintf(w, "id3_unknown_values_total{column=%s} %d\n", label(c), m.unknown[c])
+	}
+}
+
+// label quotes a label value.
+//
+func label(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// statusRecorder notes the status code written through it.
+//
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
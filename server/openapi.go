@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gbkr-com/id3"
+)
+
+// openAPI returns an OpenAPI 3 document describing the endpoints for the
+// decision. Each column it tests is a required property of a record, with the
+// values that have a case as an enum, unless some decision on the column has a
+// default or a test and so accepts any value.
+//
+func openAPI(d *id3.Decision, columns []string) ([]byte, error) {
+	values := make(map[string][]string)
+	open := make(map[string]bool)
+	var walk func(d *id3.Decision)
+	walk = func(d *id3.Decision) {
+		if d.Default != "" || d.Test != nil {
+			open[d.Column] = true
+		}
+		for _, c := range d.Cases {
+			if !contains(values[d.Column], c.Value) {
+				values[d.Column] = append(values[d.Column], c.Value)
+			}
+			if c.Decide != nil {
+				walk(c.Decide)
+			}
+		}
+	}
+	walk(d)
+	properties := make(map[string]interface{})
+	for _, c := range columns {
+		p := map[string]interface{}{"type": "string"}
+		if !open[c] {
+			sort.Strings(values[c])
+			p["enum"] = values[c]
+		}
+		properties[c] = p
+	}
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	content := func(schema interface{}) map[string]interface{} {
+		return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+	}
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "id3 prediction server",
+			"version": d.Hash()[:12],
+		},
+		"paths": map[string]interface{}{
+			"/predict": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary": "Decide the class of a record, or of a batch of records",
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"oneOf": []interface{}{
+										ref("Record"),
+										map[string]interface{}{"type": "array", "items": ref("Record")},
+									},
+								},
+							},
+							"text/csv": map[string]interface{}{
+								"schema": map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "A prediction for a record, or the predictions for a batch",
+							"content": content(map[string]interface{}{
+								"oneOf": []interface{}{ref("Prediction"), ref("Predictions")},
+							}),
+						},
+						"400": map[string]interface{}{
+							"description": "The request is malformed or a record lacks a column",
+							"content":     content(ref("Error")),
+						},
+						"422": map[string]interface{}{
+							"description": "There is no rule for a value of the record",
+							"content":     content(ref("Prediction")),
+						},
+					},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Record": map[string]interface{}{
+					"type":                 "object",
+					"required":             columns,
+					"properties":           properties,
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+				"Prediction": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"class": map[string]interface{}{"type": "string", "enum": d.Classes()},
+						"probabilities": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "number"},
+						},
+						"error": map[string]interface{}{"type": "string"},
+					},
+				},
+				"Predictions": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"predictions": map[string]interface{}{"type": "array", "items": ref("Prediction")},
+					},
+				},
+				"Error": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"error": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+	return json.MarshalIndent(doc, "", "    ")
+}
+
+func contains(slice []string, s string) bool {
+	for _, x := range slice {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/gbkr-com/id3"
+)
+
+// Registry holds versions of named models, and routes the traffic for each
+// name between a champion version and optionally a challenger, recording the
+// outcomes of each version so that they can be compared. It is safe for
+// concurrent use.
+//
+type Registry struct {
+	mu       sync.RWMutex
+	models   map[string]map[string]*id3.Decision // By name then version.
+	routes   map[string]route                    // By name.
+	outcomes map[[2]string]*Outcome              // By name and version.
+}
+
+type route struct {
+	champion   string
+	challenger string
+	fraction   float64 // Of traffic for the challenger.
+}
+
+// Outcome counts the predictions made by a model version, and how many were
+// correct of those whose actual class was later recorded.
+//
+type Outcome struct {
+	Served   int
+	Labelled int
+	Correct  int
+}
+
+// Accuracy returns Correct as a fraction of Labelled, or zero.
+//
+func (o Outcome) Accuracy() float64 {
+	if o.Labelled == 0 {
+		return 0
+	}
+	return float64(o.Correct) / float64(o.Labelled)
+}
+
+// NewRegistry returns an empty registry.
+//
+func NewRegistry() *Registry {
+	return &Registry{
+		models:   make(map[string]map[string]*id3.Decision),
+		routes:   make(map[string]route),
+		outcomes: make(map[[2]string]*Outcome),
+	}
+}
+
+// Register adds or replaces a version of the named model. The first version
+// registered for a name becomes its champion.
+//
+func (r *Registry) Register(name, version string, d *id3.Decision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.models[name] == nil {
+		r.models[name] = make(map[string]*id3.Decision)
+		r.routes[name] = route{champion: version}
+	}
+	r.models[name][version] = d
+}
+
+// Get returns a version of the named model.
+//
+func (r *Registry) Get(name, version string) (*id3.Decision, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.models[name][version]
+	return d, ok
+}
+
+// Versions returns the versions of the named model, in sorted order.
+//
+func (r *Registry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]string, 0, len(r.models[name]))
+	for v := range r.models[name] {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Route sets the champion version of the named model, and the challenger to
+// receive the given fraction of its traffic. An empty challenger, or a zero
+// fraction, sends all traffic to the champion. Promoting a challenger is a
+// matter of routing to it as the champion.
+//
+func (r *Registry) Route(name, champion, challenger string, fraction float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.models[name][champion]; !ok {
+		return fmt.Errorf("id3: no version '%s' of model '%s'", champion, name)
+	}
+	if _, ok := r.models[name][challenger]; challenger != "" && !ok {
+		return fmt.Errorf("id3: no version '%s' of model '%s'", challenger, name)
+	}
+	if fraction < 0 || fraction > 1 {
+		return fmt.Errorf("id3: challenger fraction %g is not between 0 and 1", fraction)
+	}
+	r.routes[name] = route{champion: champion, challenger: challenger, fraction: fraction}
+	return nil
+}
+
+// Pick chooses the version of the named model to serve a request, counting it
+// as served. If key is not empty, such as a user or session identifier, the
+// same key is always routed to the same version while the route is unchanged;
+// otherwise the choice is random.
+//
+func (r *Registry) Pick(name, key string) (string, *id3.Decision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rt, ok := r.routes[name]
+	if !ok {
+		return "", nil, fmt.Errorf("id3: no model '%s'", name)
+	}
+	version := rt.champion
+	if rt.challenger != "" && rt.fraction > 0 {
+		var x float64
+		if key == "" {
+			x = rand.Float64()
+		} else {
+			x = float64(mix(key)>>11) / (1 << 53)
+		}
+		if x < rt.fraction {
+			version = rt.challenger
+		}
+	}
+	r.outcome(name, version).Served++
+	return version, r.models[name][version], nil
+}
+
+// Record notes the actual class for a prediction made by a version of the
+// named model.
+//
+func (r *Registry) Record(name, version, predicted, actual string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o := r.outcome(name, version)
+	o.Labelled++
+	if predicted == actual {
+		o.Correct++
+	}
+}
+
+// Outcomes returns the outcomes of each version of the named model that has
+// served or been recorded.
+//
+func (r *Registry) Outcomes(name string) map[string]Outcome {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	outcomes := make(map[string]Outcome)
+	for k, o := range r.outcomes {
+		if k[0] == name {
+			outcomes[k[1]] = *o
+		}
+	}
+	return outcomes
+}
+
+// mix hashes the key to 64 bits that are evenly spread even for similar keys,
+// by applying the MurmurHash3 finalizer to its FNV-1a hash.
+//
+func mix(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func (r *Registry) outcome(name, version string) *Outcome {
+	k := [2]string{name, version}
+	o := r.outcomes[k]
+	if o == nil {
+		o = new(Outcome)
+		r.outcomes[k] = o
+	}
+	return o
+}
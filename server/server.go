@@ -0,0 +1,320 @@
+// Package server serves the predictions of an id3 decision tree over HTTP.
+//
+// A Handler answers POST /predict with either a JSON object, a JSON array of
+// objects or CSV data with a header row. Each record maps column names to
+// values, and must have every column the decision tests. A single object is
+// answered with a single prediction, and a batch with
+//
+//	{"predictions": [{"class": "no", "probabilities": {"no": 1, "yes": 0}}, ...]}
+//
+// where a record without a rule for one of its values has an "error" instead
+// of a class. GET /openapi.json returns an OpenAPI document for the endpoint,
+// with the columns and values of the decision served, from which clients can
+// be generated. GET /metrics returns request and prediction counts in the
+// Prometheus text format.
+//
+// The same Handler serves the gRPC Scoring service defined in scoring.proto,
+// for calls made over HTTP/2. That needs a server with TLS, or else support
+// for HTTP/2 without it, such as golang.org/x/net/http2/h2c.
+//
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gbkr-com/id3"
+)
+
+// DefaultMaxBodyBytes is the largest request body accepted by a Handler unless
+// its MaxBodyBytes is set.
+//
+const DefaultMaxBodyBytes = 1 << 20
+
+// Handler is an http.Handler serving predictions from a decision tree.
+//
+// With MaxConcurrent set, requests beyond those being worked on and MaxQueue
+// more waiting for a turn are answered with 429 Too Many Requests, so that a
+// spike in traffic cannot exhaust memory. With Timeout set, requests that take
+// longer to wait and work, including through a batch, are answered with 503
+// Service Unavailable. Set these fields before serving.
+//
+type Handler struct {
+	MaxBodyBytes  int64         // The largest request body accepted, if not zero.
+	MaxConcurrent int           // The most requests worked on at once, if not zero.
+	MaxQueue      int           // The most requests waiting for a turn.
+	Timeout       time.Duration // The longest a request may wait and work, if not zero.
+	Logger        *slog.Logger  // For fallbacks, unknown values and rejections, if set.
+	Options       []id3.Option  // For matching values, such as id3.WithFuzzyMatch.
+
+	model   atomic.Value // The current *model.
+	metrics metrics
+	once    sync.Once     // Makes workers.
+	workers chan struct{} // Holds a token for each busy worker.
+	queued  int64         // The number of requests waiting for a worker.
+}
+
+// model is a decision with what is derived from it for each request.
+//
+type model struct {
+	decision *id3.Decision
+	columns  []string
+	openAPI  []byte // The OpenAPI document, or nil if that failed.
+}
+
+// Prediction is the result for one record.
+//
+type Prediction struct {
+	Class         string             `json:"class,omitempty"`
+	Probabilities map[string]float64 `json:"probabilities,omitempty"`
+	Error         string             `json:"error,omitempty"`
+
+	fallback bool   // Whether the class is a default.
+	unknown  string // The column without a rule for its value.
+}
+
+// New returns a handler serving the decision.
+//
+func New(d *id3.Decision) *Handler {
+	h := new(Handler)
+	h.SetDecision(d)
+	return h
+}
+
+// Decision returns the decision currently served.
+//
+func (h *Handler) Decision() *id3.Decision {
+	return h.current().decision
+}
+
+// SetDecision replaces the decision served. Requests already in progress are
+// completed with the previous decision.
+//
+func (h *Handler) SetDecision(d *id3.Decision) {
+	m := &model{decision: d, columns: d.Columns()}
+	m.openAPI, _ = openAPI(d, m.columns)
+	h.model.Store(m)
+}
+
+func (h *Handler) current() *model {
+	return h.model.Load().(*model)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		h.metrics.request(r.URL.Path, rec.status, time.Since(start))
+	}()
+	w = rec
+	if isGRPC(r) {
+		h.serveGRPC(w, r)
+		return
+	}
+	switch r.URL.Path {
+	case "/predict":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+			return
+		}
+		h.predict(w, r)
+	case "/metrics":
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		h.metrics.write(w)
+	case "/openapi.json":
+		doc := h.current().openAPI
+		if doc == nil {
+			writeError(w, http.StatusInternalServerError, errors.New("no OpenAPI document"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(doc)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) predict(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.withTimeout(r.Context())
+	defer cancel()
+	release, err := h.admit(ctx)
+	if err == errSaturated {
+		h.log(slog.LevelWarn, "id3 server: request rejected", "reason", err)
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer release()
+	limit := h.MaxBodyBytes
+	if limit == 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	records, batch, err := parseRecords(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	m := h.current()
+	if err := m.validate(records); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	predictions := make([]Prediction, len(records))
+	for i, record := range records {
+		if err := ctx.Err(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		predictions[i] = m.predict(record, h.Options)
+		h.observe(predictions[i])
+	}
+	if !batch {
+		status := http.StatusOK
+		if predictions[0].Error != "" {
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, status, predictions[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Predictions []Prediction `json:"predictions"`
+	}{predictions})
+}
+
+// parseRecords returns the records in the body, and whether it was a batch.
+//
+func parseRecords(contentType string, body []byte) ([]map[string]string, bool, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "text/csv" {
+		rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(rows) == 0 {
+			return nil, false, errors.New("no header row")
+		}
+		records := make([]map[string]string, len(rows)-1)
+		for i, row := range rows[1:] {
+			records[i] = make(map[string]string, len(row))
+			for j, v := range row {
+				records[i][rows[0][j]] = v
+			}
+		}
+		return records, true, nil
+	}
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var records []map[string]string
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, false, err
+		}
+		return records, true, nil
+	}
+	var record map[string]string
+	if err := json.Unmarshal(trimmed, &record); err != nil {
+		return nil, false, err
+	}
+	return []map[string]string{record}, false, nil
+}
+
+// validate checks every record has each column the decision tests.
+//
+func (m *model) validate(records []map[string]string) error {
+	for i, record := range records {
+		var missing []string
+		for _, c := range m.columns {
+			if _, ok := record[c]; !ok {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("record %d has no %s", i+1, strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
+
+// predict follows the decision for the record, as Decision.DecideRow does with
+// the options. Unlike DecideRow it notes where a default class was used, or
+// which column had no rule, for the metrics.
+//
+func (m *model) predict(record map[string]string, opts []id3.Option) Prediction {
+	at, c, err := m.decision.Follow(record, opts...)
+	switch {
+	case errors.Is(err, id3.ErrNoMatchingCase):
+		return Prediction{Error: fmt.Sprintf("no rule for %s=%s", at.Column, record[at.Column]), unknown: at.Column}
+	case err != nil:
+		return Prediction{Error: err.Error()}
+	case c == nil:
+		return Prediction{Class: at.Default, fallback: true}
+	}
+	return Prediction{Class: c.Class, Probabilities: probabilities(c.Counts)}
+}
+
+func probabilities(counts map[string]int) map[string]float64 {
+	if len(counts) == 0 {
+		return nil
+	}
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	p := make(map[string]float64, len(counts))
+	for class, n := range counts {
+		p[class] = float64(n) / float64(total)
+	}
+	return p
+}
+
+// observe counts the prediction, and logs it if a default class was used or
+// there was no rule.
+//
+func (h *Handler) observe(p Prediction) {
+	h.metrics.prediction(p)
+	switch {
+	case p.unknown != "":
+		h.log(slog.LevelWarn, "id3 server: no rule for value", "column", p.unknown, "error", p.Error)
+	case p.fallback:
+		h.log(slog.LevelInfo, "id3 server: default class used", "class", p.Class)
+	}
+}
+
+func (h *Handler) log(level slog.Level, msg string, args ...any) {
+	if h.Logger != nil {
+		h.Logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
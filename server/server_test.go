@@ -0,0 +1,338 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gbkr-com/id3"
+	"github.com/gbkr-com/id3/internal/wire"
+)
+
+const example = `outlook,temperature,humidity,wind,play
+sunny,hot,high,weak,no
+sunny,hot,high,strong,no
+overcast,hot,high,weak,yes
+rain,mild,high,weak,yes
+rain,cool,normal,weak,yes
+rain,cool,normal,strong,no
+overcast,cool,normal,strong,yes
+sunny,mild,high,weak,no
+sunny,cool,normal,weak,yes
+rain,mild,normal,weak,yes
+sunny,mild,normal,strong,yes
+overcast,mild,high,strong,yes
+overcast,hot,normal,weak,yes
+rain,mild,high,strong,no
+`
+
+func handler() *Handler {
+	view, _ := id3.Read(strings.NewReader(example))
+	d, _ := id3.Learn(view, "play")
+	return New(d)
+}
+
+func post(h http.Handler, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/predict", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestPredict(t *testing.T) {
+	h := handler()
+	w := post(h, "application/json", `{"outlook": "sunny", "humidity": "high", "wind": "weak"}`)
+	var p Prediction
+	json.Unmarshal(w.Body.Bytes(), &p)
+	if w.Code != http.StatusOK || p.Class != "no" || p.Probabilities["no"] != 1 {
+		t.Error(w.Code, w.Body.String())
+	}
+	w = post(h, "text/csv", "outlook,humidity,wind\novercast,high,weak\nfoggy,high,weak\n")
+	var batch struct{ Predictions []Prediction }
+	json.Unmarshal(w.Body.Bytes(), &batch)
+	if w.Code != http.StatusOK || len(batch.Predictions) != 2 {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	if batch.Predictions[0].Class != "yes" || batch.Predictions[1].Error == "" {
+		t.Error(w.Body.String())
+	}
+	//
+	// Records must have every column tested, and values must be strings.
+	//
+	if w := post(h, "application/json", `[{"outlook": "sunny"}]`); w.Code != http.StatusBadRequest {
+		t.Error(w.Code)
+	}
+	if w := post(h, "application/json", `{"outlook": 1}`); w.Code != http.StatusBadRequest {
+		t.Error(w.Code)
+	}
+	if w := post(h, "application/json", `{"outlook": "foggy", "humidity": "", "wind": ""}`); w.Code != http.StatusUnprocessableEntity {
+		t.Error(w.Code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/predict", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Error(w.Code)
+	}
+}
+
+func TestPredictAsDecide(t *testing.T) {
+	h := handler()
+	//
+	// A missing value takes the case of the most rows, as Decide has it.
+	//
+	record := map[string]string{"outlook": "", "humidity": "normal", "wind": "weak"}
+	want, _ := h.Decision().DecideRow(record)
+	body, _ := json.Marshal(record)
+	var p Prediction
+	w := post(h, "application/json", string(body))
+	json.Unmarshal(w.Body.Bytes(), &p)
+	if w.Code != http.StatusOK || p.Class != want {
+		t.Error(w.Code, w.Body.String(), want)
+	}
+	//
+	// The options match values as they would for DecideContext.
+	//
+	fuzzy := `{"outlook": "Suny", "humidity": "high", "wind": "weak"}`
+	if w := post(h, "application/json", fuzzy); w.Code != http.StatusUnprocessableEntity {
+		t.Error(w.Code)
+	}
+	h.Options = []id3.Option{id3.WithFuzzyMatch(1)}
+	w = post(h, "application/json", fuzzy)
+	json.Unmarshal(w.Body.Bytes(), &p)
+	if w.Code != http.StatusOK || p.Class != "no" {
+		t.Error(w.Code, w.Body.String())
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	srv := httptest.NewUnstartedServer(handler())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	call := func(method string, msg []byte, timeout string) ([]byte, string) {
+		body := append([]byte{0, 0, 0, 0, byte(len(msg))}, msg...)
+		r, _ := http.NewRequest(http.MethodPost, srv.URL+"/id3.server.Scoring/"+method, bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/grpc")
+		if timeout != "" {
+			r.Header.Set("Grpc-Timeout", timeout)
+		}
+		resp, err := srv.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		if len(b) >= 5 {
+			b = b[5:]
+		}
+		return b, resp.Trailer.Get("Grpc-Status")
+	}
+	record := func(values ...string) *wire.Writer {
+		w := new(wire.Writer)
+		for i := 0; i < len(values); i += 2 {
+			entry := new(wire.Writer)
+			entry.String(1, values[i])
+			entry.String(2, values[i+1])
+			w.Message(1, entry)
+		}
+		return w
+	}
+	b, status := call("Predict", record("outlook", "overcast", "humidity", "high", "wind", "weak").Encoded(), "1S")
+	if status != "0" {
+		t.Fatal(status)
+	}
+	field, _, _, class, _ := wire.NewReader(b).Next()
+	if field != 1 || string(class) != "yes" {
+		t.Error(b)
+	}
+	batch := new(wire.Writer)
+	batch.Message(1, record("outlook", "overcast", "humidity", "high", "wind", "weak"))
+	batch.Message(1, record("outlook", "foggy", "humidity", "high", "wind", "weak"))
+	b, status = call("PredictBatch", batch.Encoded(), "")
+	n := 0
+	for r := wire.NewReader(b); r.More(); n++ {
+		r.Next()
+	}
+	if status != "0" || n != 2 {
+		t.Error(status, n)
+	}
+	if _, status = call("Predict", record("outlook", "sunny").Encoded(), ""); status != "3" {
+		t.Error(status)
+	}
+	if _, status = call("GetModelInfo", nil, ""); status != "0" {
+		t.Error(status)
+	}
+	if _, status = call("Train", nil, ""); status != "12" {
+		t.Error(status)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	w := httptest.NewRecorder()
+	handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		OpenAPI    string
+		Components struct {
+			Schemas struct {
+				Record struct {
+					Required   []string
+					Properties map[string]struct{ Enum []string }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	record := doc.Components.Schemas.Record
+	if doc.OpenAPI != "3.0.3" || strings.Join(record.Required, ",") != "humidity,outlook,wind" {
+		t.Error(w.Body.String())
+	}
+	if strings.Join(record.Properties["outlook"].Enum, ",") != "overcast,rain,sunny" {
+		t.Error(record.Properties)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	h := handler()
+	d := h.Decision()
+	for _, c := range d.Cases {
+		if c.Value == "sunny" {
+			c.Decide.Default = "no"
+		}
+	}
+	h.SetDecision(d)
+	post(h, "text/csv", "outlook,humidity,wind\novercast,high,weak\nfoggy,high,weak\nsunny,low,weak\n")
+	for _, path := range []string{"/id3.server.Scoring/Unknown", "/id3.server.Scoring/Other"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(w.Body.String(), "Unknown") {
+		t.Error(w.Body.String())
+	}
+	for _, want := range []string{
+		`id3_requests_total{path="/predict",code="200"} 1`,
+		`id3_request_duration_seconds_count{path="other"} 2`,
+		`id3_request_duration_seconds_count{path="/predict"} 1`,
+		`id3_predictions_total{class="no"} 1`,
+		`id3_predictions_total{class="yes"} 1`,
+		`id3_fallbacks_total 1`,
+		`id3_unknown_values_total{column="outlook"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Error(want, w.Body.String())
+		}
+	}
+}
+
+func TestModelWatcher(t *testing.T) {
+	h := handler()
+	path := filepath.Join(t.TempDir(), "model.json")
+	id3.SaveFile(path, h.Decision())
+	w := NewModelWatcher(path, time.Millisecond, h.SetDecision)
+	if changed, err := w.Check(); changed || err != nil {
+		t.Error(changed, err)
+	}
+	//
+	// A new model is swapped in, but a broken one is not.
+	//
+	d, _ := id3.FromJSON([]byte(`{"Column": "outlook", "Cases": [{"Value": "sunny", "Class": "yes"}]}`))
+	id3.SaveFile(path, d)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	if changed, err := w.Check(); !changed || err != nil {
+		t.Error(changed, err)
+	}
+	if len(h.Decision().Cases) != 1 {
+		t.Error()
+	}
+	os.WriteFile(path, []byte(`{"Column": "outlook"}`), 0644)
+	if changed, err := w.Check(); changed || err == nil {
+		t.Error(changed, err)
+	}
+	if len(h.Decision().Cases) != 1 {
+		t.Error()
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	d := handler().Decision()
+	r.Register("play", "v1", d)
+	r.Register("play", "v2", d)
+	if strings.Join(r.Versions("play"), ",") != "v1,v2" {
+		t.Error(r.Versions("play"))
+	}
+	if err := r.Route("play", "v1", "v3", 0.5); err == nil {
+		t.Error()
+	}
+	if err := r.Route("play", "v1", "v2", 0.25); err != nil {
+		t.Error(err)
+	}
+	for i := 0; i < 1000; i++ {
+		version, _, _ := r.Pick("play", fmt.Sprint(i))
+		r.Record("play", version, "yes", "yes")
+	}
+	outcomes := r.Outcomes("play")
+	if n := outcomes["v2"].Served; n < 200 || n > 300 {
+		t.Error(outcomes)
+	}
+	if outcomes["v1"].Served+outcomes["v2"].Served != 1000 || outcomes["v1"].Accuracy() != 1 {
+		t.Error(outcomes)
+	}
+	v, _, _ := r.Pick("play", "user")
+	if w, _, _ := r.Pick("play", "user"); v != w {
+		t.Error()
+	}
+	if _, _, err := r.Pick("golf", ""); err == nil {
+		t.Error()
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	h := handler()
+	h.MaxConcurrent = 1
+	h.Timeout = 50 * time.Millisecond
+	//
+	// Hold the only worker, so that a request with no queue is turned away,
+	// and a request with a queue times out waiting.
+	//
+	release, err := h.admit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"outlook": "overcast", "humidity": "high", "wind": "weak"}`
+	if w := post(h, "application/json", body); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Error(w.Code)
+	}
+	h.MaxQueue = 1
+	if w := post(h, "application/json", body); w.Code != http.StatusServiceUnavailable {
+		t.Error(w.Code)
+	}
+	release()
+	if w := post(h, "application/json", body); w.Code != http.StatusOK {
+		t.Error(w.Code)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var b bytes.Buffer
+	h := handler()
+	h.Logger = slog.New(slog.NewTextHandler(&b, nil))
+	post(h, "application/json", `{"outlook": "foggy", "humidity": "", "wind": ""}`)
+	if !strings.Contains(b.String(), "no rule for value") || !strings.Contains(b.String(), "column=outlook") {
+		t.Error(b.String())
+	}
+}
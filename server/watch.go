@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/gbkr-com/id3"
+)
+
+// ModelWatcher polls a model file and loads it again whenever its size or
+// modification time changes, so that a new model can be deployed by replacing
+// the file. Write the file elsewhere and rename it into place, as SaveFile
+// does, so that a partly written file is never loaded.
+//
+type ModelWatcher struct {
+	OnError func(err error) // Called when a changed file cannot be used, if set.
+
+	path     string
+	interval time.Duration
+	load     func(d *id3.Decision)
+	size     int64
+	modified time.Time
+}
+
+// NewModelWatcher returns a watcher for the model file at path, which calls
+// load with each new model that loads and validates. The current file is taken
+// as already loaded. For example, to keep a Handler up to date:
+//
+//	w := server.NewModelWatcher(path, 10*time.Second, handler.SetDecision)
+//	go w.Run(ctx)
+//
+func NewModelWatcher(path string, interval time.Duration, load func(d *id3.Decision)) *ModelWatcher {
+	w := &ModelWatcher{path: path, interval: interval, load: load}
+	if info, err := os.Stat(path); err == nil {
+		w.size, w.modified = info.Size(), info.ModTime()
+	}
+	return w
+}
+
+// Run checks the file at each interval until the context is done.
+//
+func (w *ModelWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := w.Check(); err != nil && w.OnError != nil {
+				w.OnError(err)
+			}
+		}
+	}
+}
+
+// Check loads the model if the file has changed since it was last loaded,
+// returning whether it did. A model that fails to load or validate is not
+// used, and is not tried again until the file changes once more.
+//
+func (w *ModelWatcher) Check() (bool, error) {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == w.size && info.ModTime().Equal(w.modified) {
+		return false, nil
+	}
+	w.size, w.modified = info.Size(), info.ModTime()
+	d, err := id3.LoadFile(w.path)
+	if err != nil {
+		return false, err
+	}
+	if err := d.Validate(nil); err != nil {
+		return false, err
+	}
+	w.load(d)
+	return true, nil
+}
@@ -0,0 +1,202 @@
+package id3
+
+import (
+	"sort"
+	"strings"
+)
+
+// WithSetValued makes Learn treat the named columns as holding sets of values
+// separated by sep, such as "urgent;billing". Rather than a case for each
+// distinct cell, Learn splits on whether the set contains the single value
+// with the most gain, and may split on the same column again below that. This
+// saves exploding a column of tags into a binary column for each tag.
+//
+func WithSetValued(sep string, columns ...string) Option {
+	return func(o *options) {
+		if o.sets == nil {
+			o.sets = make(map[string]string)
+		}
+		for _, c := range columns {
+			o.sets[c] = sep
+		}
+	}
+}
+
+// tokens returns the distinct values in the set, without surrounding spaces.
+//
+func tokens(set, sep string) []string {
+	var values []string
+	for _, v := range strings.Split(set, sep) {
+		if v = strings.TrimSpace(v); v != "" && !contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// containsToken reports whether the set holds the value.
+//
+func containsToken(set, sep, value string) bool {
+	for _, v := range strings.Split(set, sep) {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// bestTest returns the "contains" test on the set-valued column with the most
+// gain for the class, by the impurity measure, and that gain, or nil if no test
+// has any gain. Rows weigh as given in the hidden column at weightAt, if not
+// negative. Ties are broken on the operand, so the result is deterministic.
+//
+func bestTest(view View, column, sep, class string, weightAt int, m Impurity) (*Test, float64) {
+	gains := presenceGains(view, column, class, func(s string) []string { return tokens(s, sep) }, weightAt, m)
+	values := make([]string, 0, len(gains))
+	for v := range gains {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	var best *Test
+	maxGain := 0.0
+	for _, v := range values {
+		if gains[v] > maxGain+1e-12 {
+			maxGain = gains[v]
+			best = &Test{Op: opContains, Operand: v, Separator: sep}
+		}
+	}
+	return best, maxGain
+}
+
+// presenceGains returns, for each distinct value split from the column, the
+// information gain for the class from knowing whether a row has that value,
+// or the decrease in impurity by the measure if not nil. The information gain
+// is the mutual information between the presence of the value and the class.
+// Rows weigh as given in the hidden column at weightAt, if not negative. The
+// split function must not return duplicate values.
+//
+func presenceGains(view View, column, class string, split func(string) []string, weightAt int, m Impurity) map[string]float64 {
+	i := mustIndexOf(view, column)
+	classAt := mustIndexOf(view, class)
+	//
+	// Count the classes of all the rows, and of the rows having each value,
+	// in a single pass.
+	//
+	total := make(map[string]float64)
+	within := make(map[string]map[string]float64)
+	rows := 0.0
+	view.First()
+	for {
+		row := view.Next()
+		if row == nil {
+			break
+		}
+		w := weightOf(row, weightAt)
+		if w == 0 {
+			continue
+		}
+		rows += w
+		total[row[classAt]] += w
+		for _, v := range split(row[i]) {
+			if within[v] == nil {
+				within[v] = make(map[string]float64)
+			}
+			within[v][row[classAt]] += w
+		}
+	}
+	h := impurityOf(m, total)
+	gains := make(map[string]float64, len(within))
+	for v, counts := range within {
+		in, out := 0.0, 0.0
+		without := make(map[string]float64, len(total))
+		for c, n := range total {
+			without[c] = n - counts[c]
+			in += counts[c]
+			out += without[c]
+		}
+		if in == 0 || out == 0 {
+			gains[v] = 0
+			continue
+		}
+		gains[v] = h - (in*impurityOf(m, counts)+out*impurityOf(m, without))/rows
+	}
+	return gains
+}
+
+// SelectTest returns a view that shows only rows for which the outcome of the
+// test on the column is the given outcome, "true" or "false". Numbers are read
+// as written in the locale WithLocale gives.
+//
+func SelectTest(view View, column string, test *Test, outcome string, opts ...Option) View {
+	return &testView{
+		parent:  view,
+		col:     mustIndexOf(view, column),
+		test:    test,
+		outcome: outcome,
+		locale:  newOptions(opts).locale,
+	}
+}
+
+// outcomeCounts returns the class frequencies of the rows of the view by each
+// outcome of the test on the column, reading numbers as written in the locale.
+// Rows weigh as given in the hidden column at weightAt, if not negative.
+//
+func outcomeCounts(view View, column string, test *Test, class string, weightAt int, l Locale) map[string]map[string]float64 {
+	i := mustIndexOf(view, column)
+	classAt := mustIndexOf(view, class)
+	counts := make(map[string]map[string]float64, 2)
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		w := weightOf(row, weightAt)
+		if w == 0 {
+			continue
+		}
+		outcome := test.outcome(row[i], l)
+		if counts[outcome] == nil {
+			counts[outcome] = make(map[string]float64)
+		}
+		counts[outcome][row[classAt]] += w
+	}
+	return counts
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type testView struct {
+	parent  View   // Inherit from the parent view.
+	col     int    // Column index of the column tested.
+	test    *Test  // The test.
+	outcome string // The outcome of the rows shown.
+	locale  Locale // For reading numbers.
+}
+
+func (t *testView) Columns() []string { return t.parent.Columns() }
+
+func (t *testView) lookup(column string) (int, bool) { return lookupIn(t.parent, column) }
+
+func (t *testView) First() { t.parent.First() }
+
+func (t *testView) Next() []string {
+	for {
+		row := t.parent.Next()
+		if row == nil || t.test.outcome(row[t.col], t.locale) == t.outcome {
+			return row
+		}
+	}
+}
+
+func (t *testView) rows() func() []string {
+	return filter(rowsIn(t.parent), func(row []string) bool { return t.test.outcome(row[t.col], t.locale) == t.outcome })
+}
+
+func (t *testView) Select(column, value string) View {
+	return &selectView{
+		parent: t,
+		col:    mustIndexOf(t, column),
+		val:    value,
+	}
+}
+
+func (t *testView) Drop(column string) View {
+	return newDropView(t, mustIndexOf(t, column))
+}
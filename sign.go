@@ -0,0 +1,45 @@
+package id3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrSignature is returned when a decision does not match its signature.
+//
+var ErrSignature = errors.New("id3: decision does not match signature")
+
+// SignatureExt is appended to a model file name to give the name of its
+// detached signature file.
+//
+const SignatureExt = ".sig"
+
+// Sign returns the hex encoded HMAC-SHA256 of the canonical form of this
+// decision, using the key. With a nil key this is the plain SHA-256 checksum
+// given by Hash. As the canonical form is signed, the signature is independent
+// of the file format and the order of cases.
+//
+func (d *Decision) Sign(key []byte) string {
+	if key == nil {
+		return d.Hash()
+	}
+	b, _ := d.Canonical().ToJSON(false)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns ErrSignature unless the signature, from Sign with the same key,
+// matches this decision.
+//
+func (d *Decision) Verify(key []byte, signature string) error {
+	expected, _ := hex.DecodeString(d.Sign(key))
+	actual, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || !hmac.Equal(expected, actual) {
+		return ErrSignature
+	}
+	return nil
+}
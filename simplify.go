@@ -0,0 +1,80 @@
+package id3
+
+import (
+	"sort"
+)
+
+// Simplify returns a simplified copy of this decision. Where two or more leaf
+// cases of a decision decide the same class, they are merged into the default
+// class of that decision, and a decision that then decides the same class for
+// every value becomes a leaf. A decision with a single case and no default is
+// replaced by the outcome of that case. Values that previously had no rule may
+// now decide a default class, and the class frequencies of merged cases are
+// lost.
+//
+func (d *Decision) Simplify() *Decision {
+	s := d.clone()
+	s.simplify()
+	return s
+}
+
+// simplify works in place and returns the class, and true, if this decision
+// now decides the same class for every value.
+//
+func (d *Decision) simplify() (string, bool) {
+	for _, c := range d.Cases {
+		if c.Decide == nil {
+			continue
+		}
+		if class, ok := c.Decide.simplify(); ok {
+			c.Counts = c.totals()
+			if len(c.Counts) == 0 {
+				c.Counts = nil
+			}
+			c.Class, c.Decide = class, nil
+		}
+	}
+	//
+	// Choose the class to merge into the default: the existing default, or
+	// the class of the most leaf cases if that is more than one.
+	//
+	merge := d.Default
+	if merge == "" {
+		frequency := make(map[string]int)
+		for _, c := range d.Cases {
+			if c.Decide == nil {
+				frequency[c.Class]++
+			}
+		}
+		classes := make([]string, 0, len(frequency))
+		for class := range frequency {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			if frequency[class] > 1 && frequency[class] > frequency[merge] {
+				merge = class
+			}
+		}
+	}
+	if merge != "" {
+		var kept []*Case
+		for _, c := range d.Cases {
+			if c.Decide != nil || c.Class != merge {
+				kept = append(kept, c)
+			}
+		}
+		d.Cases, d.Default = kept, merge
+	}
+	switch {
+	case len(d.Cases) == 0:
+		return d.Default, true
+	case len(d.Cases) == 1 && d.Default == "":
+		c := d.Cases[0]
+		if c.Decide == nil {
+			return c.Class, true
+		}
+		*d = *c.Decide
+	}
+	return "", false
+}
@@ -0,0 +1,169 @@
+package id3
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SQLDialect determines how ToSQL quotes column names.
+//
+type SQLDialect int
+
+// The supported SQL dialects. ANSISQL also suits PostgreSQL, SQLite and most
+// data warehouses.
+//
+const (
+	ANSISQL   SQLDialect = iota // "column"
+	MySQL                       // `column`
+	SQLServer                   // [column]
+)
+
+// ToSQL returns this decision as a nested SQL CASE expression that yields the
+// decided class, or NULL where no case matches and there is no default. As
+// for Decide, a NULL is a missing value, which takes the case for the empty
+// value or, without one, the case the most training rows took, unless there
+// is a default. For example, to score a table:
+//
+//	SELECT t.*, <expression> AS play FROM t
+//
+// A threshold learned WithSchema compares the column as a number, which should
+// be of a numeric type. The "contains" test cannot be expressed.
+//
+func (d *Decision) ToSQL(dialect SQLDialect) (string, error) {
+	var b strings.Builder
+	if err := d.writeSQL(&b, dialect, 0); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (d *Decision) writeSQL(b *strings.Builder, dialect SQLDialect, depth int) error {
+	if d.Test != nil {
+		return d.writeThresholdSQL(b, dialect, depth)
+	}
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(b, "CASE %s\n", dialect.identifier(d.Column))
+	for _, c := range d.Cases {
+		fmt.Fprintf(b, "%s  WHEN %s THEN ", indent, sqlString(c.Value))
+		if err := c.writeSQL(b, d, dialect, depth); err != nil {
+			return err
+		}
+	}
+	missing := d.caseFor("")
+	if missing == nil {
+		missing = d.mostFrequent()
+	}
+	switch {
+	case d.Default != "":
+		fmt.Fprintf(b, "%s  ELSE %s\n", indent, sqlString(d.Default))
+	case missing != nil:
+		//
+		// A simple CASE cannot match NULL, which is missing like the empty
+		// value, so both take the case for missing values in a searched CASE.
+		//
+		column := dialect.identifier(d.Column)
+		fmt.Fprintf(b, "%s  ELSE CASE\n%s    WHEN %s IS NULL OR %s = '' THEN ", indent, indent, column, column)
+		if err := missing.writeSQL(b, d, dialect, depth+1); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s  END\n", indent)
+	}
+	fmt.Fprintf(b, "%sEND", indent)
+	return nil
+}
+
+// writeThresholdSQL writes a decision with a "<=" test as a searched CASE
+// expression. The case for "false" is the ELSE, so that a NULL, like a missing
+// value, takes it. Either case may be the default, which the least number and
+// a missing value match.
+//
+func (d *Decision) writeThresholdSQL(b *strings.Builder, dialect SQLDialect, depth int) error {
+	if d.Test.Op != opLessOrEqual || math.IsNaN(d.Test.threshold()) {
+		return fmt.Errorf("id3: SQL cannot express the test on '%s'", d.Column)
+	}
+	indent := strings.Repeat("  ", depth)
+	b.WriteString("CASE\n")
+	if c := d.match("-inf"); c != nil {
+		fmt.Fprintf(b, "%s  WHEN %s <= %s THEN ", indent, dialect.identifier(d.Column), strconv.FormatFloat(d.Test.threshold(), 'g', -1, 64))
+		if err := c.writeSQL(b, d, dialect, depth); err != nil {
+			return err
+		}
+	}
+	if c := d.match(""); c != nil {
+		fmt.Fprintf(b, "%s  ELSE ", indent)
+		if err := c.writeSQL(b, d, dialect, depth); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(b, "%sEND", indent)
+	return nil
+}
+
+// writeSQL writes what the case of the decision yields, and a new line.
+//
+func (c *Case) writeSQL(b *strings.Builder, d *Decision, dialect SQLDialect, depth int) error {
+	switch {
+	case c.Decide != nil:
+		if err := c.Decide.writeSQL(b, dialect, depth+2); err != nil {
+			return err
+		}
+	case c.Class != "":
+		b.WriteString(sqlString(c.Class))
+	default:
+		return fmt.Errorf("id3: case '%s' of '%s' has no class or decision", c.Value, d.Column)
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+func (dialect SQLDialect) identifier(s string) string {
+	switch dialect {
+	case MySQL:
+		return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+	case SQLServer:
+		return "[" + strings.ReplaceAll(s, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Value implements driver.Valuer, storing the decision in a database column as
+// compact JSON. A nil decision is stored as NULL.
+//
+func (d *Decision) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	return d.ToJSON(false)
+}
+
+// Scan implements sql.Scanner, replacing this decision with the JSON, or the
+// Envelope, read from a database column. NULL gives an empty decision.
+//
+func (d *Decision) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*d = Decision{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("id3: cannot scan %T into a decision", src)
+	}
+	decoded, err := FromJSON(b)
+	if err != nil {
+		return err
+	}
+	*d = *decoded
+	return nil
+}
@@ -0,0 +1,51 @@
+package id3
+
+import (
+	"context"
+	"database/sql"
+)
+
+// NewSQLView returns a view on the result of the query, with its arguments, on
+// the database, so that a decision can be learned from a table without first
+// exporting it as CSV. The rows are read once, as by ReadSQL.
+//
+func NewSQLView(ctx context.Context, db *sql.DB, query string, args ...any) (View, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ReadSQL(rows)
+}
+
+// ReadSQL reads the rows of a query result into a view and closes them, since
+// Learn reads the rows of a view more than once and a result can be read only
+// once. The columns are named as in the result. A NULL is a missing value,
+// and other values are written as database/sql converts them to strings.
+//
+func ReadSQL(rows *sql.Rows) (View, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	data := [][]string{columns}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(columns))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		data = append(data, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newBaseView(data), nil
+}
@@ -0,0 +1,99 @@
+package id3
+
+import (
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// Stats describes a run of Learn or LearnExternal, for diagnosing slow runs and
+// pathological data.
+//
+type Stats struct {
+	Nodes      int             // The number of decisions made.
+	Leaves     int             // The number of cases deciding a class.
+	Depth      int             // The greatest depth of a decision, from 0 at the root.
+	Rows       int64           // The number of rows counted into contingency tables, summed over every decision or pass.
+	Duration   time.Duration   // The wall time taken.
+	ByDepth    []time.Duration // The wall time spent on the decisions at each depth.
+	PeakMemory uint64          // The greatest heap in use, in bytes, sampled at each decision.
+	Stopped    bool            // Whether the tree was left unfinished by WithMaxDuration or WithContext.
+}
+
+// WithStats has Learn and LearnExternal fill in the statistics of the run when
+// they succeed. Sampling the memory in use briefly stops the program at each
+// decision, so the statistics are only gathered when asked for.
+//
+func WithStats(s *Stats) Option {
+	return func(o *options) { o.stats = s }
+}
+
+// node notes a decision made at the depth.
+//
+func (l *learner) node(depth int) {
+	l.stats.Nodes++
+	if depth > l.stats.Depth {
+		l.stats.Depth = depth
+	}
+	if l.options.stats != nil {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		if m.HeapAlloc > l.stats.PeakMemory {
+			l.stats.PeakMemory = m.HeapAlloc
+		}
+	}
+}
+
+// spent adds the time spent on a decision at the depth.
+//
+func (l *learner) spent(depth int, d time.Duration) {
+	for len(l.stats.ByDepth) <= depth {
+		l.stats.ByDepth = append(l.stats.ByDepth, 0)
+	}
+	l.stats.ByDepth[depth] += d
+}
+
+// finish completes the statistics of the run, and gives them to any WithStats
+// option.
+//
+func (l *learner) finish() {
+	l.stats.Duration = time.Since(l.began)
+	if l.options.stats != nil {
+		*l.options.stats = l.stats
+	}
+}
+
+// stop reports whether to stop expanding the tree, because the time allowed has
+// passed or the context is done, noting the first time it does.
+//
+func (l *learner) stop() bool {
+	if l.stats.Stopped {
+		return true
+	}
+	if l.budget > 0 && time.Since(l.began) > l.budget || l.ctx != nil && l.ctx.Err() != nil {
+		l.stats.Stopped = true
+		l.log(slog.LevelWarn, "id3: stopped expanding the tree", "after", time.Since(l.began))
+	}
+	return l.stats.Stopped
+}
+
+// join adds the statistics and unfinished cases of a learner forked from this
+// one.
+//
+func (l *learner) join(f *learner) {
+	l.stats.Nodes += f.stats.Nodes
+	l.stats.Leaves += f.stats.Leaves
+	l.stats.Rows += f.stats.Rows
+	l.stats.Depth = max(l.stats.Depth, f.stats.Depth)
+	l.stats.PeakMemory = max(l.stats.PeakMemory, f.stats.PeakMemory)
+	l.stats.Stopped = l.stats.Stopped || f.stats.Stopped
+	for len(l.stats.ByDepth) < len(f.stats.ByDepth) {
+		l.stats.ByDepth = append(l.stats.ByDepth, 0)
+	}
+	for depth, d := range f.stats.ByDepth {
+		l.stats.ByDepth[depth] += d
+	}
+	for c, rows := range f.frontier {
+		l.frontier[c] = rows
+	}
+}
@@ -0,0 +1,295 @@
+package id3
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ToJSONWriter writes this decision to the writer in the same JSON format as
+// ToJSON, but a case at a time rather than building the whole document in
+// memory.
+//
+func (d *Decision) ToJSONWriter(w io.Writer, indent bool) error {
+	s := &jsonStreamWriter{w: bufio.NewWriter(w), indent: indent}
+	if err := s.value(reflect.ValueOf(d), 0); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// FromJSONReader reads a decision, or an Envelope holding one, from the reader.
+// Unlike FromJSON the input is decoded a token at a time, so that only the
+// decision itself is held in memory.
+//
+func FromJSONReader(r io.Reader) (*Decision, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	if err := jsonExpect(dec, '{'); err != nil {
+		return nil, err
+	}
+	//
+	// The top level is either the decision itself or an envelope, whose keys
+	// do not overlap with those of a decision.
+	//
+	d := new(Decision)
+	var payload *Decision
+	var version *int
+	for dec.More() {
+		key, err := jsonKey(dec)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.EqualFold(key, "formatVersion"):
+			version = new(int)
+			err = dec.Decode(version)
+		case strings.EqualFold(key, "payload"):
+			payload = new(Decision)
+			err = jsonStreamDecision(dec, payload)
+		default:
+			err = jsonStreamField(dec, reflect.ValueOf(d).Elem(), key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := jsonExpect(dec, '}'); err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return d, nil
+	}
+	if *version > FormatVersion {
+		return nil, fmt.Errorf("id3: unsupported model format version %d", *version)
+	}
+	if payload == nil {
+		return nil, errors.New("id3: envelope has no payload")
+	}
+	return payload, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type jsonStreamWriter struct {
+	w      *bufio.Writer
+	indent bool
+	err    error
+}
+
+func (s *jsonStreamWriter) write(str string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(str)
+	}
+}
+
+func (s *jsonStreamWriter) newline(depth int) {
+	if s.indent {
+		s.write("\n" + strings.Repeat("    ", depth))
+	}
+}
+
+// value writes structs, pointers to structs and slices of those itself, and
+// everything else with encoding/json, honouring the json field tags.
+//
+func (s *jsonStreamWriter) value(v reflect.Value, depth int) error {
+	switch {
+	case v.Kind() == reflect.Ptr && v.IsNil():
+		s.write("null")
+	case v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct:
+		return s.value(v.Elem(), depth)
+	case v.Kind() == reflect.Struct:
+		s.write("{")
+		first := true
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, omitempty := jsonField(t.Field(i))
+			if name == "" || (omitempty && v.Field(i).IsZero()) {
+				continue
+			}
+			if !first {
+				s.write(",")
+			}
+			first = false
+			s.newline(depth + 1)
+			b, _ := json.Marshal(name)
+			s.write(string(b) + ":")
+			if s.indent {
+				s.write(" ")
+			}
+			if err := s.value(v.Field(i), depth+1); err != nil {
+				return err
+			}
+		}
+		if !first {
+			s.newline(depth)
+		}
+		s.write("}")
+	case v.Kind() == reflect.Slice && !v.IsNil() && v.Type().Elem().Kind() == reflect.Ptr:
+		s.write("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				s.write(",")
+			}
+			s.newline(depth + 1)
+			if err := s.value(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+		if v.Len() > 0 {
+			s.newline(depth)
+		}
+		s.write("]")
+	default:
+		var b []byte
+		var err error
+		if s.indent {
+			b, err = json.MarshalIndent(v.Interface(), strings.Repeat("    ", depth), "    ")
+		} else {
+			b, err = json.Marshal(v.Interface())
+		}
+		if err != nil {
+			return err
+		}
+		s.write(string(b))
+	}
+	return s.err
+}
+
+// jsonField returns the JSON name of the struct field, or "" if it is not
+// encoded, and whether it is omitted when empty.
+//
+func jsonField(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func jsonExpect(dec *json.Decoder, delim json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("id3: expected '%v' in JSON, found %v", delim, t)
+	}
+	return nil
+}
+
+func jsonKey(dec *json.Decoder) (string, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := t.(string)
+	if !ok {
+		return "", fmt.Errorf("id3: expected a key in JSON, found %v", t)
+	}
+	return key, nil
+}
+
+// jsonStreamDecision decodes the object at the decoder into the decision.
+//
+func jsonStreamDecision(dec *json.Decoder, d *Decision) error {
+	if err := jsonExpect(dec, '{'); err != nil {
+		return err
+	}
+	return jsonStreamObject(dec, reflect.ValueOf(d).Elem())
+}
+
+// jsonStreamObject decodes the keys and values of an object, whose opening
+// brace has been read, into the struct.
+//
+func jsonStreamObject(dec *json.Decoder, v reflect.Value) error {
+	for dec.More() {
+		key, err := jsonKey(dec)
+		if err != nil {
+			return err
+		}
+		if err := jsonStreamField(dec, v, key); err != nil {
+			return err
+		}
+	}
+	return jsonExpect(dec, '}')
+}
+
+// jsonStreamField decodes the value for the key into the matching field of the
+// struct. Pointers to structs and slices of those are streamed, everything else
+// is left to encoding/json. Unknown keys are skipped.
+//
+func jsonStreamField(dec *json.Decoder, v reflect.Value, key string) error {
+	var f reflect.Value
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if name, _ := jsonField(t.Field(i)); name != "" && strings.EqualFold(name, key) {
+			f = v.Field(i)
+			break
+		}
+	}
+	if !f.IsValid() {
+		var skip json.RawMessage
+		return dec.Decode(&skip)
+	}
+	streamed := f.Kind() == reflect.Ptr && f.Type().Elem().Kind() == reflect.Struct
+	list := f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Ptr && f.Type().Elem().Elem().Kind() == reflect.Struct
+	if !streamed && !list {
+		return dec.Decode(f.Addr().Interface())
+	}
+	//
+	// The decoder cannot peek, so read the opening token and allow for null.
+	//
+	token, err := dec.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if streamed {
+		if token != json.Delim('{') {
+			return fmt.Errorf("id3: expected an object in JSON for '%s'", key)
+		}
+		p := reflect.New(f.Type().Elem())
+		if err := jsonStreamObject(dec, p.Elem()); err != nil {
+			return err
+		}
+		f.Set(p)
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("id3: expected an array in JSON for '%s'", key)
+	}
+	s := reflect.MakeSlice(f.Type(), 0, 0)
+	for dec.More() {
+		if err := jsonExpect(dec, '{'); err != nil {
+			return err
+		}
+		p := reflect.New(f.Type().Elem().Elem())
+		if err := jsonStreamObject(dec, p.Elem()); err != nil {
+			return err
+		}
+		s = reflect.Append(s, p)
+	}
+	if err := jsonExpect(dec, ']'); err != nil {
+		return err
+	}
+	f.Set(s)
+	return nil
+}
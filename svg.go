@@ -0,0 +1,117 @@
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"unicode/utf8"
+)
+
+// Layout constants for SVG rendering, in pixels. Text widths are estimated
+// from the character count, as no font metrics are available.
+//
+const (
+	svgCharWidth = 7
+	svgPadding   = 16
+	svgGap       = 12
+	svgHeight    = 28
+	svgLevel     = 80
+	svgMargin    = 10
+)
+
+// svgNode is a positioned node in the SVG layout.
+//
+type svgNode struct {
+	label    string
+	leaf     bool
+	x, y     float64 // The centre of the node.
+	width    float64
+	slot     float64  // The horizontal space reserved for a leaf and its edge label.
+	edges    []string // The case values labelling the edges to each child.
+	children []*svgNode
+}
+
+// ToSVG returns this decision as a standalone SVG image, laid out as a top down
+// tree. Decisions are drawn as rectangles labelled with the column name, cases
+// as labelled lines and decided classes as ellipses.
+//
+func (d *Decision) ToSVG() ([]byte, error) {
+	root := svgDecision(d, 0)
+	right := 0.0
+	svgLayout(root, &right)
+	height := svgHeight + svgMargin*2.0
+	svgDepth(root, &height)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" font-family="sans-serif" font-size="12">`+"\n", right+svgMargin, height)
+	svgWrite(&buf, root)
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+func svgDecision(d *Decision, depth int) *svgNode {
+	n := &svgNode{label: d.Column, y: svgMargin + svgHeight/2.0 + float64(depth)*svgLevel}
+	for _, c := range d.rendered() {
+		var child *svgNode
+		if c.Decide != nil {
+			child = svgDecision(c.Decide, depth+1)
+		} else {
+			child = &svgNode{label: c.Class, leaf: true, y: n.y + svgLevel}
+			child.width = svgWidth(child.label)
+		}
+		child.slot = child.width
+		if w := svgWidth(d.label(c)); w > child.slot {
+			child.slot = w
+		}
+		n.edges = append(n.edges, d.label(c))
+		n.children = append(n.children, child)
+	}
+	n.width = svgWidth(n.label)
+	return n
+}
+
+func svgWidth(label string) float64 {
+	return float64(utf8.RuneCountInString(label)*svgCharWidth + svgPadding)
+}
+
+// svgLayout places leaves left to right, advancing the right hand edge, and
+// centres each decision above its children.
+//
+func svgLayout(n *svgNode, right *float64) {
+	if len(n.children) == 0 {
+		if *right == 0 {
+			*right = svgMargin
+		} else {
+			*right += svgGap
+		}
+		n.x = *right + n.slot/2
+		*right += n.slot
+		return
+	}
+	for _, c := range n.children {
+		svgLayout(c, right)
+	}
+	n.x = (n.children[0].x + n.children[len(n.children)-1].x) / 2
+}
+
+func svgDepth(n *svgNode, height *float64) {
+	if h := n.y + svgHeight/2.0 + svgMargin; h > *height {
+		*height = h
+	}
+	for _, c := range n.children {
+		svgDepth(c, height)
+	}
+}
+
+func svgWrite(buf *bytes.Buffer, n *svgNode) {
+	for i, c := range n.children {
+		fmt.Fprintf(buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", n.x, n.y+svgHeight/2, c.x, c.y-svgHeight/2)
+		fmt.Fprintf(buf, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#555">%s</text>`+"\n", (n.x+c.x)/2, (n.y+c.y)/2, html.EscapeString(n.edges[i]))
+		svgWrite(buf, c)
+	}
+	if n.leaf {
+		fmt.Fprintf(buf, `<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" fill="#eef" stroke="black"/>`+"\n", n.x, n.y, n.width/2, svgHeight/2.0)
+	} else {
+		fmt.Fprintf(buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%d" fill="white" stroke="black"/>`+"\n", n.x-n.width/2, n.y-svgHeight/2, n.width, svgHeight)
+	}
+	fmt.Fprintf(buf, `<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="middle">%s</text>`+"\n", n.x, n.y, html.EscapeString(n.label))
+}
@@ -0,0 +1,49 @@
+package id3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String returns this decision as an indented text tree in the style of C4.5,
+// for example:
+//
+//	outlook = sunny:
+//	|   humidity = high: no (3)
+//	|   humidity = normal: yes (2)
+//	outlook = overcast: yes (4)
+//
+// Where the tree carries class frequencies from learning, each leaf shows the
+// number of training rows as (n), or (n/e) when e of those rows are of another
+// class.
+//
+func (d *Decision) String() string {
+	var b strings.Builder
+	d.writeText(&b, 0)
+	return b.String()
+}
+
+func (d *Decision) writeText(b *strings.Builder, depth int) {
+	indent := strings.Repeat("|   ", depth)
+	for _, c := range d.rendered() {
+		fmt.Fprintf(b, "%s%s:", indent, d.condition(c))
+		if c.Decide != nil {
+			b.WriteString("\n")
+			c.Decide.writeText(b, depth+1)
+			continue
+		}
+		fmt.Fprintf(b, " %s", c.Class)
+		if len(c.Counts) > 0 {
+			n := 0
+			for _, v := range c.Counts {
+				n += v
+			}
+			if e := n - c.Counts[c.Class]; e > 0 {
+				fmt.Fprintf(b, " (%d/%d)", n, e)
+			} else {
+				fmt.Fprintf(b, " (%d)", n)
+			}
+		}
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,99 @@
+package id3
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// WithSchema gives Learn the kind of each column. A Float or Int column is
+// split, as by C4.5, on whether its value is at most the threshold with the
+// most gain, such as "temperature <= 20.5", rather than with a case for every
+// distinct number, and may be split on again below that. The schema may be the
+// one InferSchema finds. Numbers are read as written in the locale WithLocale
+// gives, and a value that is not a number is taken as above every threshold.
+// Thresholds are written as Go writes numbers, such as "1234.5", so a decision
+// reads numbers in another locale when DecideContext is given it too.
+//
+func WithSchema(s Schema) Option {
+	return func(o *options) { o.schema = s }
+}
+
+// numeric reports whether the schema gives the column as a number, to split on
+// a threshold.
+//
+func (o *options) numeric(column string) bool {
+	k, ok := o.schema[column]
+	return ok && (k == Float || k == Int)
+}
+
+// bestThreshold returns the "<=" test on the numeric column with the most gain
+// for the class, by the impurity measure, and that gain, or nil if no
+// threshold has any gain. Each threshold is a value in the column, read as
+// written in the locale, so ties are broken on the smallest. Rows weigh as
+// given in the hidden column at weightAt, if not negative.
+//
+func bestThreshold(view View, column, class string, weightAt int, m Impurity, l Locale) (*Test, float64) {
+	i := mustIndexOf(view, column)
+	classAt := mustIndexOf(view, class)
+	//
+	// Find the numbers in the column, with their classes, and count the
+	// classes of all the rows.
+	//
+	type number struct {
+		f     float64
+		class string
+		w     float64
+	}
+	var numbers []number
+	total := make(map[string]float64)
+	rows, counted := 0.0, 0
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		w := weightOf(row, weightAt)
+		if w == 0 {
+			continue
+		}
+		rows += w
+		counted++
+		total[row[classAt]] += w
+		if f, err := strconv.ParseFloat(l.number(row[i]), 64); err == nil && !math.IsNaN(f) {
+			numbers = append(numbers, number{f, row[classAt], w})
+		}
+	}
+	sort.Slice(numbers, func(a, b int) bool { return numbers[a].f < numbers[b].f })
+	//
+	// Move the rows below each threshold in turn, in increasing order, and
+	// find the gain from the classes on each side.
+	//
+	h := impurityOf(m, total)
+	below := make(map[string]float64)
+	in := 0.0
+	var best *Test
+	maxGain := 0.0
+	for n, x := range numbers {
+		below[x.class] += x.w
+		in += x.w
+		if n+1 < len(numbers) && numbers[n+1].f == x.f {
+			continue
+		}
+		if n+1 == counted {
+			break
+		}
+		if math.IsInf(x.f, 0) {
+			continue
+		}
+		above := make(map[string]float64, len(total))
+		for c, k := range total {
+			if k > below[c] {
+				above[c] = k - below[c]
+			}
+		}
+		g := h - (in*impurityOf(m, below)+(rows-in)*impurityOf(m, above))/rows
+		if g > maxGain+1e-12 {
+			maxGain = g
+			best = &Test{Op: opLessOrEqual, Operand: strconv.FormatFloat(x.f, 'g', -1, 64)}
+		}
+	}
+	return best, maxGain
+}
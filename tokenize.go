@@ -0,0 +1,129 @@
+package id3
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Tokenize returns a view in which the free text column is replaced by binary
+// presence columns for the k tokens most informative about the class, by their
+// mutual information with it. Tokens are the runs of letters and digits in the
+// text, in lower case. Each new column is named column:token, such as
+// "complaint:refund", and holds "true" or "false". The columns are added after
+// the others in decreasing order of information, with ties broken on the token.
+//
+func Tokenize(view View, column, class string, k int) View {
+	return tokenize(view, column, rankTokens(view, column, class, k))
+}
+
+// rankTokens returns the k tokens of the column most informative about the
+// class, in the order of the presence columns of Tokenize.
+//
+func rankTokens(view View, column, class string, k int) []string {
+	gains := presenceGains(view, column, class, words, -1, nil)
+	var ranked []string
+	for w := range gains {
+		ranked = append(ranked, w)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if gains[ranked[i]] == gains[ranked[j]] {
+			return ranked[i] < ranked[j]
+		}
+		return gains[ranked[i]] > gains[ranked[j]]
+	})
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return ranked
+}
+
+// tokenize returns a view in which the text column is replaced by presence
+// columns for the tokens.
+//
+func tokenize(view View, column string, tokens []string) View {
+	parent := view.Drop(column)
+	columns := parent.Columns()
+	columns = append(columns[:len(columns):len(columns)], make([]string, len(tokens))...)
+	for i, w := range tokens {
+		columns[len(columns)-len(tokens)+i] = column + ":" + w
+	}
+	return &tokenView{
+		parent:  parent,
+		col:     mustIndexOf(view, column),
+		tokens:  tokens,
+		columns: columns,
+		index:   newIndex(columns),
+	}
+}
+
+// words returns the distinct tokens of the text.
+//
+func words(text string) []string {
+	var distinct []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !contains(distinct, w) {
+			distinct = append(distinct, w)
+		}
+	}
+	return distinct
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type tokenView struct {
+	parent  View           // Inherit from the parent, which hides the text column.
+	col     int            // Column index of the text column.
+	tokens  []string       // The tokens with a presence column.
+	columns []string       // The parent columns followed by the presence columns.
+	index   map[string]int // The index of each column.
+}
+
+func (t *tokenView) Columns() []string { return t.columns }
+
+func (t *tokenView) lookup(column string) (int, bool) {
+	i, ok := t.index[column]
+	return i, ok
+}
+
+func (t *tokenView) First() { t.parent.First() }
+
+func (t *tokenView) Next() []string { return t.extend(t.parent.Next()) }
+
+func (t *tokenView) rows() func() []string {
+	next := rowsIn(t.parent)
+	return func() []string { return t.extend(next()) }
+}
+
+// extend returns the row of the parent followed by its presence columns.
+//
+func (t *tokenView) extend(row []string) []string {
+	if row == nil {
+		return nil
+	}
+	present := words(row[t.col])
+	out := make([]string, len(row), len(row)+len(t.tokens))
+	copy(out, row)
+	for _, w := range t.tokens {
+		if contains(present, w) {
+			out = append(out, "true")
+		} else {
+			out = append(out, "false")
+		}
+	}
+	return out
+}
+
+func (t *tokenView) Select(column, value string) View {
+	return &selectView{
+		parent: t,
+		col:    mustIndexOf(t, column),
+		val:    value,
+	}
+}
+
+func (t *tokenView) Drop(column string) View {
+	return newDropView(t, mustIndexOf(t, column))
+}
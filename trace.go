@@ -0,0 +1,91 @@
+package id3
+
+import (
+	"context"
+	"log/slog"
+)
+
+// A Tracer starts spans, so that the time taken to read, learn and decide can
+// be broken down by a tracing system. It is small enough to adapt to any such
+// system; with OpenTelemetry, for example, Start calls the Start method of an
+// otel Tracer and the Span methods call SetAttributes, AddEvent and End.
+//
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// A Span is a traced operation, which ends with a call to End.
+//
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	AddEvent(name string, attrs ...slog.Attr)
+	End()
+}
+
+// WithTracer traces Read, Learn and DecideContext with spans named "id3.Read",
+// "id3.Learn" and "id3.Decide". Learn adds a "split" event for each decision
+// it makes.
+//
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) { o.tracer = tracer }
+}
+
+// WithContext sets the context for Learn and Read, which is the parent of any
+// spans they start. Learn stops expanding the tree once it is done, as for
+// WithMaxDuration.
+//
+func WithContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// start starts a span if there is a tracer, or returns a span that does
+// nothing.
+//
+func (o *options) start(name string) Span {
+	if o.tracer == nil {
+		return noSpan{}
+	}
+	_, span := o.tracer.Start(o.context(), name)
+	return span
+}
+
+func (o *options) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+type noSpan struct{}
+
+func (noSpan) SetAttributes(...slog.Attr)    {}
+func (noSpan) AddEvent(string, ...slog.Attr) {}
+func (noSpan) End()                          {}
+
+// DecideContext is like Decide, but stops with the context's error if it is
+// done before all the rows are decided. The options may give a Tracer,
+// WithFuzzyMatch or WithMissingMarkers.
+//
+func (d *Decision) DecideContext(ctx context.Context, data [][]string, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+	o.ctx = ctx
+	span := o.start("id3.Decide")
+	span.SetAttributes(slog.Int("rows", len(data)-1))
+	defer span.End()
+	var result []string
+	var index map[string]int
+	if len(data) > 0 {
+		index = newIndex(data[0])
+	}
+	for i := 1; i < len(data); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		class, err := d.decide(data, i, index, o)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, class)
+	}
+	return result, nil
+}
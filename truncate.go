@@ -0,0 +1,45 @@
+package id3
+
+import (
+	"errors"
+)
+
+// Truncate returns a copy of this decision in which every decision at the given
+// depth, where the root is depth 0, is replaced by a leaf deciding the class
+// held by most of the training rows beneath it. This needs the class
+// frequencies from learning. A depth below 1 is an error, as the root cannot
+// become a leaf.
+//
+func (d *Decision) Truncate(depth int) (*Decision, error) {
+	if depth < 1 {
+		return nil, errors.New("id3: truncation depth must be at least 1")
+	}
+	t := d.clone()
+	if err := t.truncate(depth - 1); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// truncate makes leaves of the cases of this decision when depth reaches zero.
+//
+func (d *Decision) truncate(depth int) error {
+	for _, c := range d.Cases {
+		if c.Decide == nil {
+			continue
+		}
+		if depth > 0 {
+			if err := c.Decide.truncate(depth - 1); err != nil {
+				return err
+			}
+			continue
+		}
+		counts := c.totals()
+		class := majority(weighed(counts))
+		if class == "" {
+			return errors.New("id3: truncation needs class frequencies from learning")
+		}
+		c.Class, c.Decide, c.Counts = class, nil, counts
+	}
+	return nil
+}
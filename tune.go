@@ -0,0 +1,215 @@
+package id3
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Params gives the candidate options for Learn of each hyperparameter to tune,
+// by name, such as the column subsets to decide on:
+//
+//	params := Params{
+//		"columns": {WithExcluded("id"), WithExcluded("id", "postcode")},
+//		"missing": {WithMissing(MissingAsValue, "age"), WithMissing(MissingImpute, "age")},
+//	}
+//
+// Each trial of a search takes one of the options of every hyperparameter.
+//
+type Params map[string][]Option
+
+// A Trial is a combination of the options of a search and its accuracy.
+//
+type Trial struct {
+	Choices  map[string]int // The index of the option taken for each hyperparameter.
+	Accuracy float64        // The fraction of the held out rows decided correctly.
+	Rows     int            // The number of rows the accuracy was estimated from.
+}
+
+// Options returns the options of the trial.
+//
+func (p Params) Options(t Trial) []Option {
+	var opts []Option
+	for _, name := range p.names() {
+		opts = append(opts, p[name][t.Choices[name]])
+	}
+	return opts
+}
+
+// names returns the names of the hyperparameters in sorted order.
+//
+func (p Params) names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sample returns up to n distinct combinations of the options, in random
+// order. Where there are no more than n, it returns all of them.
+//
+func (p Params) sample(n int, r *rand.Rand) []map[string]int {
+	names := p.names()
+	total := 1
+	for _, name := range names {
+		if total *= len(p[name]); total > n {
+			break
+		}
+	}
+	var combinations []map[string]int
+	if total <= n {
+		for i := 0; i < total; i++ {
+			c, j := make(map[string]int, len(names)), i
+			for _, name := range names {
+				c[name] = j % len(p[name])
+				j /= len(p[name])
+			}
+			combinations = append(combinations, c)
+		}
+		r.Shuffle(len(combinations), func(i, j int) {
+			combinations[i], combinations[j] = combinations[j], combinations[i]
+		})
+		return combinations
+	}
+	seen := make(map[string]bool)
+	for len(combinations) < n {
+		c := make(map[string]int, len(names))
+		choices := make([]int, len(names))
+		for i, name := range names {
+			c[name] = r.Intn(len(p[name]))
+			choices[i] = c[name]
+		}
+		if key := fmt.Sprint(choices); !seen[key] {
+			seen[key] = true
+			combinations = append(combinations, c)
+		}
+	}
+	return combinations
+}
+
+// RandomSearch estimates the accuracy of Learn with budget combinations of the
+// options, chosen at random from the seed, by k-fold cross validation of the
+// view. Every k-th row, from a different starting row, is held out of each
+// fold for testing, and a held out row without a decision counts as wrong.
+// The trials are returned in decreasing accuracy, so the first has the options
+// to learn with. A hyperparameter without options is an error.
+//
+func RandomSearch(view View, class string, params Params, budget, k int, seed int64) ([]Trial, error) {
+	for _, options := range params {
+		if len(options) == 0 {
+			return nil, errors.New("id3: hyperparameter has no options")
+		}
+	}
+	data := rowsOf(view)
+	var trials []Trial
+	for _, c := range params.sample(budget, rand.New(rand.NewSource(seed))) {
+		t := Trial{Choices: c}
+		var err error
+		if t.Accuracy, err = crossValidate(data, class, k, params.Options(t)); err != nil {
+			return nil, err
+		}
+		t.Rows = len(data) - 1
+		trials = append(trials, t)
+	}
+	sort.SliceStable(trials, func(i, j int) bool { return trials[i].Accuracy > trials[j].Accuracy })
+	return trials, nil
+}
+
+// rowsOf returns the column names and rows of the view.
+//
+func rowsOf(view View) [][]string {
+	data := [][]string{view.Columns()}
+	view.First()
+	for row := view.Next(); row != nil; row = view.Next() {
+		data = append(data, row)
+	}
+	return data
+}
+
+// crossValidate returns the accuracy of learning from the data, with a header
+// row, by k-fold cross validation.
+//
+func crossValidate(data [][]string, class string, k int, opts []Option) (float64, error) {
+	folds, err := kfold(data, k, -1)
+	if err != nil {
+		return 0, err
+	}
+	classAt, err := find(data[0], class)
+	if err != nil {
+		return 0, err
+	}
+	correct := 0
+	for _, f := range folds {
+		n, _, err := f.score(class, classAt, opts)
+		if err != nil {
+			return 0, err
+		}
+		correct += n
+	}
+	return float64(correct) / float64(len(data)-1), nil
+}
+
+// SuccessiveHalving estimates the accuracy of Learn with n combinations of the
+// options, chosen at random from the seed, as RandomSearch does, but saves
+// time by first cross validating every combination on a small sample of the
+// rows of the view. Only the most accurate 1/eta of the combinations go on to
+// the next round, on a sample eta times larger, until the last round uses all
+// the rows. Each sample has at least k rows.
+//
+// All the trials of all the rounds are returned, those of later rounds first
+// and then in decreasing accuracy, so the first has the options to learn with.
+//
+func SuccessiveHalving(view View, class string, params Params, n, k, eta int, seed int64) ([]Trial, error) {
+	for _, options := range params {
+		if len(options) == 0 {
+			return nil, errors.New("id3: hyperparameter has no options")
+		}
+	}
+	if eta < 2 {
+		return nil, errors.New("id3: successive halving needs an eta of at least 2")
+	}
+	r := rand.New(rand.NewSource(seed))
+	data := rowsOf(view)
+	rows := data[1:]
+	r.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+	combinations := params.sample(n, r)
+	rounds := 1
+	for m := len(combinations); m >= eta; m /= eta {
+		rounds++
+	}
+	size := len(rows)
+	for i := 1; i < rounds; i++ {
+		size /= eta
+	}
+	var trials []Trial
+	for round := 0; round < rounds && len(combinations) > 0; round++ {
+		if size < k {
+			size = k
+		}
+		if round == rounds-1 || size > len(rows) {
+			size = len(rows)
+		}
+		sample := append([][]string{data[0]}, rows[:size]...)
+		var results []Trial
+		for _, c := range combinations {
+			t := Trial{Choices: c, Rows: size}
+			var err error
+			if t.Accuracy, err = crossValidate(sample, class, k, params.Options(t)); err != nil {
+				return nil, err
+			}
+			results = append(results, t)
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Accuracy > results[j].Accuracy })
+		trials = append(results, trials...)
+		keep := (len(combinations) + eta - 1) / eta
+		combinations = combinations[:0]
+		for _, t := range results[:keep] {
+			combinations = append(combinations, t.Choices)
+		}
+		size *= eta
+	}
+	return trials, nil
+}
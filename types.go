@@ -0,0 +1,371 @@
+package id3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of the values in a column. Views hold every value as a
+// string, which is parsed as the column's kind where order matters, such as
+// for threshold comparisons.
+//
+type Kind int
+
+// The kinds of column.
+//
+const (
+	String Kind = iota
+	Float
+	Int
+	Bool
+	Time
+)
+
+var kindNames = []string{"string", "float", "int", "bool", "time"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return kindNames[k]
+}
+
+// ParseKind returns the kind with the given name, as returned by Kind.String.
+//
+func ParseKind(name string) (Kind, error) {
+	for i, n := range kindNames {
+		if strings.EqualFold(n, name) {
+			return Kind(i), nil
+		}
+	}
+	return String, fmt.Errorf("id3: unknown kind '%s'", name)
+}
+
+// Schema gives the kind of some or all of the columns of a view. Columns not
+// in the schema are strings.
+//
+type Schema map[string]Kind
+
+// TimeLayouts are the layouts tried, in order, when parsing a Time value.
+//
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// Value is a typed cell value.
+//
+type Value struct {
+	kind Kind
+	s    string
+	f    float64
+	i    int64
+	b    bool
+	t    time.Time
+}
+
+// Locale describes how numbers and times are written. The zero Locale is that
+// of Go: "1234.5" and the TimeLayouts.
+//
+type Locale struct {
+	Decimal     string   // The decimal separator, or "" for ".".
+	Thousands   string   // The separator between groups of digits, or "" for none.
+	TimeLayouts []string // The layouts tried for times, or nil for TimeLayouts.
+}
+
+// EuropeanLocale writes numbers as "1.234,5" and dates day first, as
+// "31.12.2024" or "31/12/2024".
+//
+var EuropeanLocale = Locale{
+	Decimal:   ",",
+	Thousands: ".",
+	TimeLayouts: []string{
+		"02.01.2006 15:04:05",
+		"02.01.2006",
+		"02/01/2006 15:04:05",
+		"02/01/2006",
+		"2006-01-02",
+	},
+}
+
+// number returns the number in the form read by strconv, or "" if the digits
+// are not grouped in threes by the thousands separator, so that a date such as
+// "31.12.2024" is not taken for a number.
+//
+func (l Locale) number(s string) string {
+	s = strings.TrimSpace(s)
+	decimal := l.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	if l.Thousands != "" && strings.Contains(s, l.Thousands) {
+		whole := s
+		if i := strings.Index(s, decimal); i >= 0 {
+			whole = s[:i]
+		}
+		groups := strings.Split(strings.TrimLeft(whole, "+-"), l.Thousands)
+		for i, g := range groups {
+			if len(g) > 3 || len(g) == 0 || i > 0 && len(g) != 3 {
+				return ""
+			}
+		}
+		s = strings.ReplaceAll(s, l.Thousands, "")
+	}
+	return strings.Replace(s, decimal, ".", 1)
+}
+
+// ParseValue parses the string as a value of the given kind, as written in Go.
+//
+func ParseValue(s string, kind Kind) (Value, error) {
+	return Locale{}.ParseValue(s, kind)
+}
+
+// ParseValue parses the string as a value of the given kind, as written in
+// this locale.
+//
+func (l Locale) ParseValue(s string, kind Kind) (Value, error) {
+	v := Value{kind: kind, s: s}
+	var err error
+	switch kind {
+	case String:
+	case Float:
+		v.f, err = strconv.ParseFloat(l.number(s), 64)
+	case Int:
+		v.i, err = strconv.ParseInt(l.number(s), 10, 64)
+	case Bool:
+		v.b, err = strconv.ParseBool(strings.TrimSpace(s))
+	case Time:
+		layouts := l.TimeLayouts
+		if layouts == nil {
+			layouts = TimeLayouts
+		}
+		err = fmt.Errorf("no layout matches")
+		for _, layout := range layouts {
+			var t time.Time
+			if t, err = time.Parse(layout, strings.TrimSpace(s)); err == nil {
+				v.t = t
+				break
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown kind")
+	}
+	if err != nil {
+		return Value{}, fmt.Errorf("id3: cannot parse '%s' as %v: %v", s, kind, err)
+	}
+	return v, nil
+}
+
+// Kind returns the kind of the value.
+//
+func (v Value) Kind() Kind { return v.kind }
+
+// String returns the value as it was parsed.
+//
+func (v Value) String() string { return v.s }
+
+// Float returns a Float or Int value as a float64.
+//
+func (v Value) Float() float64 {
+	if v.kind == Int {
+		return float64(v.i)
+	}
+	return v.f
+}
+
+// Int returns the value of an Int.
+//
+func (v Value) Int() int64 { return v.i }
+
+// Bool returns the value of a Bool.
+//
+func (v Value) Bool() bool { return v.b }
+
+// Time returns the value of a Time.
+//
+func (v Value) Time() time.Time { return v.t }
+
+// Compare returns -1, 0 or +1 as this value is less than, equal to or greater
+// than the other. Float and Int values compare numerically with each other;
+// values of other differing kinds compare by kind.
+//
+func (v Value) Compare(w Value) int {
+	numeric := func(k Kind) bool { return k == Float || k == Int }
+	switch {
+	case v.kind == Int && w.kind == Int:
+		return compare(v.i < w.i, v.i > w.i)
+	case numeric(v.kind) && numeric(w.kind):
+		return compare(v.Float() < w.Float(), v.Float() > w.Float())
+	case v.kind != w.kind:
+		return compare(v.kind < w.kind, v.kind > w.kind)
+	case v.kind == Bool:
+		return compare(!v.b && w.b, v.b && !w.b)
+	case v.kind == Time:
+		return compare(v.t.Before(w.t), v.t.After(w.t))
+	}
+	return strings.Compare(v.s, w.s)
+}
+
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// InferSchema returns the narrowest kind that every non-empty value of each
+// column of the view parses as, trying Int, Float, Bool and Time in turn.
+// Hidden columns and those with no values are left out. Values are parsed in
+// the locale given by WithLocale, if any.
+//
+func InferSchema(view View, opts ...Option) Schema {
+	locale := newOptions(opts).locale
+	columns := view.Columns()
+	candidates := make([][]Kind, len(columns))
+	for i := range candidates {
+		candidates[i] = []Kind{Int, Float, Bool, Time}
+	}
+	seen := make([]bool, len(columns))
+	view.First()
+	for {
+		row := view.Next()
+		if row == nil {
+			break
+		}
+		for i, s := range row {
+			if s == "" || columns[i] == "" {
+				continue
+			}
+			seen[i] = true
+			kept := candidates[i][:0]
+			for _, k := range candidates[i] {
+				if _, err := locale.ParseValue(s, k); err == nil {
+					kept = append(kept, k)
+				}
+			}
+			candidates[i] = kept
+		}
+	}
+	schema := make(Schema)
+	for i, c := range columns {
+		if c == "" || !seen[i] {
+			continue
+		}
+		schema[c] = String
+		if len(candidates[i]) > 0 {
+			schema[c] = candidates[i][0]
+		}
+	}
+	return schema
+}
+
+// Op is a comparison operator for SelectCompare.
+//
+type Op int
+
+// The comparison operators.
+//
+const (
+	Less Op = iota
+	LessOrEqual
+	Greater
+	GreaterOrEqual
+)
+
+var opSymbols = []string{"<", "<=", ">", ">="}
+
+func (op Op) String() string {
+	if op < 0 || int(op) >= len(opSymbols) {
+		return "Op(" + strconv.Itoa(int(op)) + ")"
+	}
+	return opSymbols[op]
+}
+
+func (op Op) holds(c int) bool {
+	switch op {
+	case Less:
+		return c < 0
+	case LessOrEqual:
+		return c <= 0
+	case Greater:
+		return c > 0
+	case GreaterOrEqual:
+		return c >= 0
+	}
+	return false
+}
+
+// SelectCompare returns a view that shows only rows whose value in the column,
+// parsed as the kind of the given value, compares with it by the operator. For
+// example, the rows with a temperature of at most 20.5:
+//
+//	v, _ := ParseValue("20.5", Float)
+//	cool := SelectCompare(view, "temperature", LessOrEqual, v)
+//
+// Rows whose value does not parse, in the locale given by WithLocale if any,
+// are not shown.
+//
+func SelectCompare(view View, column string, op Op, value Value, opts ...Option) View {
+	return &compareView{
+		parent: view,
+		col:    mustIndexOf(view, column),
+		op:     op,
+		val:    value,
+		locale: newOptions(opts).locale,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type compareView struct {
+	parent View   // Inherit from the parent view.
+	col    int    // Column index of the column to compare.
+	op     Op     // The comparison.
+	val    Value  // The value to compare with.
+	locale Locale // For parsing the values in the column.
+}
+
+func (c *compareView) Columns() []string { return c.parent.Columns() }
+
+func (c *compareView) lookup(column string) (int, bool) { return lookupIn(c.parent, column) }
+
+func (c *compareView) First() { c.parent.First() }
+
+func (c *compareView) Next() []string {
+	for {
+		row := c.parent.Next()
+		if row == nil {
+			return nil
+		}
+		v, err := c.locale.ParseValue(row[c.col], c.val.kind)
+		if err == nil && c.op.holds(v.Compare(c.val)) {
+			return row
+		}
+	}
+}
+
+func (c *compareView) rows() func() []string {
+	return filter(rowsIn(c.parent), func(row []string) bool {
+		v, err := c.locale.ParseValue(row[c.col], c.val.kind)
+		return err == nil && c.op.holds(v.Compare(c.val))
+	})
+}
+
+func (c *compareView) Select(column, value string) View {
+	return &selectView{
+		parent: c,
+		col:    mustIndexOf(c, column),
+		val:    value,
+	}
+}
+
+func (c *compareView) Drop(column string) View {
+	return newDropView(c, mustIndexOf(c, column))
+}
@@ -0,0 +1,92 @@
+package id3
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ValidationError lists all the problems found by Validate.
+//
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "id3: invalid decision: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the structure of this decision, as may be needed after hand
+// editing, and returns a *ValidationError listing every problem found, or nil.
+// It finds decisions without a column or any cases, cases that decide neither
+// or both a class and a decision, duplicate case values, and decisions reached
+// more than once through shared pointers, which includes cycles, and tests that
+// are unknown or whose cases are not "true" or "false". If columns is
+// not nil, every column tested must also be one of those.
+//
+func (d *Decision) Validate(columns []string) error {
+	v := &validator{columns: columns, seen: make(map[*Decision]bool)}
+	v.decision(d, nil)
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	columns  []string
+	seen     map[*Decision]bool
+	problems []string
+}
+
+func (v *validator) add(path []Condition, format string, args ...interface{}) {
+	v.problems = append(v.problems, formatPath(path)+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) decision(d *Decision, path []Condition) {
+	if v.seen[d] {
+		v.add(path, "decision is shared or cyclic")
+		return
+	}
+	v.seen[d] = true
+	switch {
+	case d.Column == "":
+		v.add(path, "decision has no column")
+	case v.columns != nil && !contains(v.columns, d.Column):
+		v.add(path, "unknown column '%s'", d.Column)
+	}
+	switch {
+	case d.Test == nil || d.Test.Op == opContains:
+	case d.Test.Op != opLessOrEqual:
+		v.add(path, "unknown test '%s'", d.Test.Op)
+	case math.IsNaN(d.Test.threshold()):
+		v.add(path, "threshold '%s' is not a number", d.Test.Operand)
+	}
+	if len(d.Cases) == 0 && d.Default == "" {
+		v.add(path, "decision has no cases")
+	}
+	values := make(map[string]bool)
+	for i, c := range d.Cases {
+		if c == nil {
+			v.add(path, "case %d is nil", i)
+			continue
+		}
+		at := append(path[:len(path):len(path)], Condition{Column: d.Column, Value: c.Value, Test: d.Test})
+		if values[c.Value] {
+			v.add(at, "duplicate case value")
+		}
+		if d.Test != nil && c.Value != "true" && c.Value != "false" {
+			v.add(at, "case value of a test is not 'true' or 'false'")
+		}
+		values[c.Value] = true
+		switch {
+		case c.Class == "" && c.Decide == nil:
+			v.add(at, "case has no class or decision")
+		case c.Class != "" && c.Decide != nil:
+			v.add(at, "case has both a class and a decision")
+		}
+		if c.Decide != nil {
+			v.decision(c.Decide, at)
+		}
+	}
+}
@@ -2,12 +2,18 @@ package id3
 
 import (
 	"encoding/csv"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"sort"
 )
 
 // View is the interface for ID3 to inspect CSV conformant data. It provides
 // a cursor like mechanism for reading the data, through the First() and Next()
-// functions.
+// functions. The cursor is shared by everything reading the view, so readers
+// that may overlap, such as goroutines, should each use an Iterator - see the
+// Rows function.
 //
 type View interface {
 
@@ -26,47 +32,285 @@ type View interface {
 	Next() []string
 
 	// Select returns a view that shows only rows having the given value in the
-	// column.
+	// column. It panics if there is no such column - see the Select function.
 	//
 	Select(column, value string) View
 
-	// Drop returns a view which 'hides' the named column.
+	// Drop returns a view which 'hides' the named column. It panics if there
+	// is no such column - see the Drop function.
 	//
 	Drop(column string) View
 }
 
+// Select is like the Select method of the view, but fails with
+// ErrColumnNotFound rather than panicking if there is no such column, for
+// column names given by users.
+//
+func Select(view View, column, value string) (View, error) {
+	if err := CheckColumns(view, column); err != nil {
+		return nil, err
+	}
+	return view.Select(column, value), nil
+}
+
+// Drop is like the Drop method of the view, but fails with ErrColumnNotFound
+// rather than panicking if there is no such column.
+//
+func Drop(view View, column string) (View, error) {
+	if err := CheckColumns(view, column); err != nil {
+		return nil, err
+	}
+	return view.Drop(column), nil
+}
+
+// CheckColumns fails with ErrColumnNotFound for the first of the columns the
+// view does not show, or returns nil. Functions such as Likelihood, Frequency,
+// TotalEntropy and AverageEntropy panic given such a column, so column names
+// given by users can be checked with this first. Learn and Decide return the
+// error themselves.
+//
+func CheckColumns(view View, columns ...string) error {
+	for _, c := range columns {
+		if c == "" {
+			return fmt.Errorf("%w: no name given", ErrColumnNotFound)
+		}
+		if _, err := indexOf(view, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Read CSV conformant data from the given reader and return a View on that.
+// With WithMissingMarkers, the markers are read as missing values, and with
+// WithDialect or WithColumnNames the data may be written in another dialect of
+// CSV or without a header.
 //
-func Read(reader io.Reader) (View, error) {
-	r := csv.NewReader(reader)
+func Read(reader io.Reader, opts ...Option) (View, error) {
+	o := newOptions(opts)
+	span := o.start("id3.Read")
+	defer span.End()
+	r := o.csvReader(reader)
 	data, err := r.ReadAll()
+	if errors.Is(err, csv.ErrFieldCount) {
+		return nil, fmt.Errorf("%w: %v", ErrSchemaMismatch, err)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &baseView{
-		data: data,
-		next: 1,
-	}, nil
+	if o.headerless() {
+		if o.names != nil {
+			if err := checkHeader(o.names); err != nil {
+				return nil, err
+			}
+		}
+		var first []string
+		if len(data) > 0 {
+			first = data[0]
+		}
+		data = append([][]string{o.columnNames(first)}, data...)
+	}
+	if len(data) > 0 {
+		span.SetAttributes(slog.Int("rows", len(data)-1), slog.Int("columns", len(data[0])))
+	}
+	o.unmark(data)
+	view := newBaseView(data)
+	if o.logger != nil {
+		warn(view, o)
+	}
+	return view, nil
 }
 
-func find(slice []string, x string) int {
+// NewView returns a view on CSV conformant data already in memory, whose first
+// row is the column headings, without copying it, so the data must not be
+// changed while the view is used. It fails with ErrSchemaMismatch if there is
+// no header, a heading is empty or repeated, or a row has a different number
+// of values.
+//
+func NewView(data [][]string) (View, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: no header row", ErrSchemaMismatch)
+	}
+	if err := checkHeader(data[0]); err != nil {
+		return nil, err
+	}
+	for i, row := range data[1:] {
+		if len(row) != len(data[0]) {
+			return nil, fmt.Errorf("%w: row %d has %d values for %d columns", ErrSchemaMismatch, i+1, len(row), len(data[0]))
+		}
+	}
+	return newBaseView(data), nil
+}
+
+// NewViewFromMaps returns a view on records in memory, each giving the value
+// of a column by name, with the given columns in order. A record without a
+// column has a missing value there. If columns is nil they are all the names
+// in the records, in sorted order. It fails with ErrSchemaMismatch if a column
+// is empty or repeated, or a record has a column not given.
+//
+func NewViewFromMaps(records []map[string]string, columns []string) (View, error) {
+	if columns == nil {
+		for _, r := range records {
+			for c := range r {
+				if !contains(columns, c) {
+					columns = append(columns, c)
+				}
+			}
+		}
+		sort.Strings(columns)
+	}
+	if err := checkHeader(columns); err != nil {
+		return nil, err
+	}
+	index := newIndex(columns)
+	data := make([][]string, 1, len(records)+1)
+	data[0] = columns
+	for i, r := range records {
+		row := make([]string, len(columns))
+		for c, v := range r {
+			j, ok := index[c]
+			if !ok {
+				return nil, fmt.Errorf("%w: record %d has column '%s'", ErrSchemaMismatch, i+1, c)
+			}
+			row[j] = v
+		}
+		data = append(data, row)
+	}
+	return &baseView{data: data, next: 1, index: index}, nil
+}
+
+// checkHeader fails with ErrSchemaMismatch if a column is empty or repeated.
+//
+func checkHeader(columns []string) error {
+	for i, c := range columns {
+		if c == "" {
+			return fmt.Errorf("%w: column %d has no name", ErrSchemaMismatch, i+1)
+		}
+		if j, _ := find(columns, c); j != i {
+			return fmt.Errorf("%w: duplicate column '%s'", ErrSchemaMismatch, c)
+		}
+	}
+	return nil
+}
+
+// warn logs problems with the data that will not stop learning, but may spoil
+// the result.
+//
+func warn(view View, o *options) {
+	columns := view.Columns()
+	for i, c := range columns {
+		if j, _ := find(columns, c); j != i {
+			o.log(slog.LevelWarn, "id3: duplicate column name, only the first is used", "column", c, "index", i)
+		}
+	}
+	for _, s := range Describe(view) {
+		if s.LikelyID {
+			o.log(slog.LevelWarn, "id3: column looks like a row identifier", "column", s.Column, "distinct", s.Distinct)
+		}
+	}
+}
+
+// find returns the index of the named column, or ErrColumnNotFound.
+//
+func find(slice []string, x string) (int, error) {
 	for i, str := range slice {
 		if str == x {
-			return i
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: '%s'", ErrColumnNotFound, x)
+}
+
+// mustFind is like find but panics with the error, for the functions that do
+// not return one.
+//
+func mustFind(slice []string, x string) int {
+	i, err := find(slice, x)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// indexed is implemented by the views of this package, which look up their
+// visible columns by name in an index built once and shared through the chain
+// of views, rather than scanning the names. With thousands of columns the scan
+// is a measurable part of learning.
+//
+type indexed interface {
+	lookup(column string) (int, bool)
+}
+
+// indexOf returns the index of the named column of the view, or
+// ErrColumnNotFound.
+//
+func indexOf(view View, column string) (int, error) {
+	if v, ok := view.(indexed); ok && column != "" {
+		if i, ok := v.lookup(column); ok {
+			return i, nil
+		}
+		return -1, fmt.Errorf("%w: '%s'", ErrColumnNotFound, column)
+	}
+	return find(view.Columns(), column)
+}
+
+// mustIndexOf is like indexOf but panics with the error, for the functions
+// that do not return one.
+//
+func mustIndexOf(view View, column string) int {
+	i, err := indexOf(view, column)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// lookupIn looks up the column of a parent view.
+//
+func lookupIn(parent View, column string) (int, bool) {
+	i, err := indexOf(parent, column)
+	return i, err == nil
+}
+
+// newIndex returns the index of each column name, the first where names are
+// duplicated, as find would.
+//
+func newIndex(columns []string) map[string]int {
+	index := make(map[string]int, len(columns))
+	for i, c := range columns {
+		if _, ok := index[c]; !ok {
+			index[c] = i
 		}
 	}
-	panic("id3: '" + x + "'not in slice")
+	return index
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// newBaseView returns a view on the data, with its header row indexed now so
+// that looking up columns changes nothing, and the view can be shared.
+//
+func newBaseView(data [][]string) *baseView {
+	b := &baseView{data: data, next: 1}
+	if len(data) > 0 {
+		b.index = newIndex(data[0])
+	}
+	return b
+}
+
 type baseView struct {
-	data [][]string // The original CSV conformant data.
-	next int        // The index of the next row to return.
+	data  [][]string     // The original CSV conformant data.
+	next  int            // The index of the next row to return.
+	index map[string]int // The index of each column.
 }
 
 func (b *baseView) Columns() []string { return b.data[0] }
 
+func (b *baseView) lookup(column string) (int, bool) {
+	i, ok := b.index[column]
+	return i, ok
+}
+
 func (b *baseView) First() { b.next = 1 }
 
 func (b *baseView) Next() []string {
@@ -84,19 +328,27 @@ func (b *baseView) Next() []string {
 	return row
 }
 
+func (b *baseView) rows() func() []string {
+	next := 1
+	return func() []string {
+		if next >= len(b.data) {
+			return nil
+		}
+		next++
+		return b.data[next-1]
+	}
+}
+
 func (b *baseView) Select(column, value string) View {
 	return &selectView{
 		parent: b,
-		col:    find(b.Columns(), column),
+		col:    mustIndexOf(b, column),
 		val:    value,
 	}
 }
 
 func (b *baseView) Drop(column string) View {
-	return &dropView{
-		parent: b,
-		drop:   find(b.Columns(), column),
-	}
+	return newDropView(b, mustIndexOf(b, column))
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -109,6 +361,8 @@ type selectView struct {
 
 func (s *selectView) Columns() []string { return s.parent.Columns() }
 
+func (s *selectView) lookup(column string) (int, bool) { return lookupIn(s.parent, column) }
+
 func (s *selectView) First() { s.parent.First() }
 
 func (s *selectView) Next() []string {
@@ -123,38 +377,50 @@ func (s *selectView) Next() []string {
 	}
 }
 
+func (s *selectView) rows() func() []string {
+	return filter(rowsIn(s.parent), func(row []string) bool { return row[s.col] == s.val })
+}
+
 func (s *selectView) Select(column, value string) View {
 	return &selectView{
 		parent: s,
-		col:    find(s.Columns(), column),
+		col:    mustIndexOf(s, column),
 		val:    value,
 	}
 }
 
 func (s *selectView) Drop(column string) View {
-	return &dropView{
-		parent: s,
-		drop:   find(s.Columns(), column),
-	}
+	return newDropView(s, mustIndexOf(s, column))
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
-type dropView struct {
-	parent View // Inherit from the parent.
-	drop   int  // Column index of the column to drop from the view.
-}
-
-func (d *dropView) Columns() []string {
+// newDropView returns a view of the parent that drops the column at the index,
+// with its column names made now so that the view can be shared.
+//
+func newDropView(parent View, drop int) *dropView {
 	//
 	// Approach is to replace the column name to be dropped with "", so that
 	// subsequent use of 'locate' could panic. This avoids larger scale data
 	// copyng.
 	//
-	c := make([]string, len(d.parent.Columns()))
-	copy(c, d.parent.Columns())
-	c[d.drop] = ""
-	return c
+	columns := make([]string, len(parent.Columns()))
+	copy(columns, parent.Columns())
+	columns[drop] = ""
+	return &dropView{parent: parent, drop: drop, columns: columns}
+}
+
+type dropView struct {
+	parent  View     // Inherit from the parent.
+	drop    int      // Column index of the column to drop from the view.
+	columns []string // The column names.
+}
+
+func (d *dropView) Columns() []string { return d.columns }
+
+func (d *dropView) lookup(column string) (int, bool) {
+	i, ok := lookupIn(d.parent, column)
+	return i, ok && i != d.drop
 }
 
 func (d *dropView) First() { d.parent.First() }
@@ -167,17 +433,16 @@ func (d *dropView) Next() []string {
 	return row
 }
 
+func (d *dropView) rows() func() []string { return rowsIn(d.parent) }
+
 func (d *dropView) Select(column, value string) View {
 	return &selectView{
 		parent: d,
-		col:    find(d.Columns(), column),
+		col:    mustIndexOf(d, column),
 		val:    value,
 	}
 }
 
 func (d *dropView) Drop(column string) View {
-	return &dropView{
-		parent: d,
-		drop:   find(d.Columns(), column),
-	}
+	return newDropView(d, mustIndexOf(d, column))
 }
@@ -0,0 +1,126 @@
+package id3
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// WithWeights has Learn count each row by the weight in the named column, a
+// number of at least zero, rather than as one row. A row of weight 2 then
+// counts as two rows that agree on every column, and a row of weight 0 is not
+// counted at all. The class frequencies, the gain of each split and the most
+// frequent class of each case are weighted sums, and the Counts of each case
+// are those sums rounded to whole numbers, leaving out any that round to zero.
+// The column is not decided on. Numbers are read as written in the locale
+// WithLocale gives. Learn fails with ErrColumnNotFound if the view lacks the
+// column, or if a weight is not such a number.
+//
+func WithWeights(column string) Option {
+	return func(o *options) { o.weights = column }
+}
+
+// withRowWeights has Learn weigh each row by the weight in the same place, as
+// for boosting, which is otherwise as WithWeights.
+//
+func withRowWeights(weights []float64) Option {
+	return func(o *options) { o.boosted = weights }
+}
+
+// weigh returns a view of the rows of the view, each followed by its weight in
+// a hidden column, and notes where that is, if learning WithWeights or with
+// weights for the rows, or distributing missing values, which then give part
+// of a row to each case. The column WithWeights names is hidden. Otherwise it
+// returns the view, and each row weighs one.
+//
+func (l *learner) weigh(view View) (View, error) {
+	l.weightAt = -1
+	at := -1
+	if l.weights != "" {
+		var err error
+		if at, err = indexOf(view, l.weights); err != nil {
+			return nil, err
+		}
+	} else if l.boosted == nil && !l.distributes() {
+		return view, nil
+	}
+	data := rowsOf(view)
+	if l.boosted != nil && len(l.boosted) != len(data)-1 {
+		return nil, fmt.Errorf("id3: %d weights for %d rows", len(l.boosted), len(data)-1)
+	}
+	for r := 1; r < len(data); r++ {
+		w := 1.0
+		if l.boosted != nil {
+			w = l.boosted[r-1]
+		} else if at >= 0 {
+			var err error
+			w, err = strconv.ParseFloat(l.locale.number(data[r][at]), 64)
+			if err != nil || !(w >= 0) || math.IsInf(w, 1) {
+				return nil, fmt.Errorf("id3: weight '%s' of row %d is not a number of at least zero", data[r][at], r)
+			}
+		}
+		data[r] = append(data[r][:len(data[r]):len(data[r])], strconv.FormatFloat(w, 'g', -1, 64))
+	}
+	l.weightAt = len(data[0])
+	data[0] = append(data[0][:len(data[0]):len(data[0])], "")
+	view = newBaseView(data)
+	if at >= 0 {
+		view = view.Drop(l.weights)
+	}
+	return view, nil
+}
+
+// distributes reports whether the missing values of any column are
+// distributed between the cases of a decision on it.
+//
+func (o *options) distributes() bool {
+	for _, m := range o.missing {
+		if m == MissingDistribute {
+			return true
+		}
+	}
+	return false
+}
+
+// weightOf returns the weight of the row in the hidden column at the index,
+// or one if it is negative.
+//
+func weightOf(row []string, at int) float64 {
+	if at < 0 {
+		return 1
+	}
+	w, _ := strconv.ParseFloat(row[at], 64)
+	return w
+}
+
+// reweigh returns a copy of the row with its weight, in the hidden column at
+// the index, scaled by the fraction.
+//
+func reweigh(row []string, at int, fraction float64) []string {
+	out := append([]string(nil), row...)
+	out[at] = strconv.FormatFloat(weightOf(row, at)*fraction, 'g', -1, 64)
+	return out
+}
+
+// weighed returns the whole counts as class frequencies.
+//
+func weighed(counts map[string]int) map[string]float64 {
+	w := make(map[string]float64, len(counts))
+	for k, n := range counts {
+		w[k] = float64(n)
+	}
+	return w
+}
+
+// tally returns the class frequencies rounded to whole counts, as for the
+// Counts of a case, leaving out those that round to zero.
+//
+func tally(classes map[string]float64) map[string]int {
+	counts := make(map[string]int, len(classes))
+	for k, w := range classes {
+		if n := int(math.Round(w)); n > 0 {
+			counts[k] = n
+		}
+	}
+	return counts
+}
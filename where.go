@@ -0,0 +1,77 @@
+package id3
+
+// Where returns a view that shows only rows for which the function returns
+// true, for conditions that Select and SelectCompare cannot express, such as a
+// prefix or a comparison of two columns:
+//
+//	v := Where(view, func(row map[string]string) bool {
+//		return strings.HasPrefix(row["postcode"], "SW")
+//	})
+//
+// The function is given the value of each column the view shows, by name, in
+// a map reused for every row, so it must not keep the map.
+//
+func Where(view View, keep func(row map[string]string) bool) View {
+	return &whereView{
+		parent: view,
+		keep:   keep,
+		values: make(map[string]string),
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type whereView struct {
+	parent View                             // Inherit from the parent view.
+	keep   func(row map[string]string) bool // Whether to show a row.
+	values map[string]string                // The values of the row being tested.
+}
+
+func (w *whereView) Columns() []string { return w.parent.Columns() }
+
+func (w *whereView) lookup(column string) (int, bool) { return lookupIn(w.parent, column) }
+
+func (w *whereView) First() { w.parent.First() }
+
+func (w *whereView) Next() []string {
+	for {
+		row := w.parent.Next()
+		if row == nil || w.kept(row, w.parent.Columns(), w.values) {
+			return row
+		}
+	}
+}
+
+func (w *whereView) rows() func() []string {
+	columns, values := w.parent.Columns(), make(map[string]string)
+	return filter(rowsIn(w.parent), func(row []string) bool { return w.kept(row, columns, values) })
+}
+
+// kept reports whether the function keeps the row, given the values map to
+// fill in.
+//
+func (w *whereView) kept(row, columns []string, values map[string]string) bool {
+	//
+	// Hidden columns are left out, and where names are duplicated the first
+	// is given, as for indexOf.
+	//
+	clear(values)
+	for i, c := range columns {
+		if _, ok := values[c]; c != "" && !ok {
+			values[c] = row[i]
+		}
+	}
+	return w.keep(values)
+}
+
+func (w *whereView) Select(column, value string) View {
+	return &selectView{
+		parent: w,
+		col:    mustIndexOf(w, column),
+		val:    value,
+	}
+}
+
+func (w *whereView) Drop(column string) View {
+	return newDropView(w, mustIndexOf(w, column))
+}
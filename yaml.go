@@ -0,0 +1,473 @@
+package id3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ToYAML returns this decision as a YAML formatted bytes slice. The keys are the
+// same as those of ToJSON, and empty values are omitted.
+//
+func (d *Decision) ToYAML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := yamlEncode(&buf, reflect.ValueOf(d), 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FromYAML translates the given YAML formatted byte slice into a decision. Only
+// the block style subset of YAML written by ToYAML is understood, although
+// comments, any consistent indentation and quoted or plain scalars may be used
+// when editing by hand. Keys are matched without regard to case.
+//
+func FromYAML(b []byte) (*Decision, error) {
+	p := &yamlParser{}
+	if err := p.scan(string(b)); err != nil {
+		return nil, err
+	}
+	if len(p.lines) == 0 {
+		return nil, errors.New("id3: empty YAML document")
+	}
+	node, err := p.parse(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.at < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	d := new(Decision)
+	if err := yamlDecode(node, reflect.ValueOf(d).Elem()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func yamlEncode(buf *bytes.Buffer, v reflect.Value, indent int) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		return yamlEncode(buf, v.Elem(), indent)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" || v.Field(i).IsZero() {
+				continue
+			}
+			if err := yamlEncodeEntry(buf, t.Field(i).Name, v.Field(i), indent); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := yamlEncodeEntry(buf, k, v.MapIndex(reflect.ValueOf(k)), indent); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			//
+			// Encode each item one level deeper, then replace the indentation of
+			// its first line with the sequence marker.
+			//
+			var item bytes.Buffer
+			if err := yamlEncode(&item, v.Index(i), indent+2); err != nil {
+				return err
+			}
+			buf.WriteString(strings.Repeat(" ", indent) + "- ")
+			buf.Write(item.Bytes()[indent+2:])
+		}
+	default:
+		return fmt.Errorf("id3: cannot encode %s as YAML", v.Type())
+	}
+	return nil
+}
+
+func yamlEncodeEntry(buf *bytes.Buffer, key string, v reflect.Value, indent int) error {
+	buf.WriteString(strings.Repeat(" ", indent) + yamlScalar(key) + ":")
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		buf.WriteString(" " + yamlScalar(v.String()) + "\n")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(" " + strconv.FormatInt(v.Int(), 10) + "\n")
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(" " + strconv.FormatFloat(v.Float(), 'g', -1, 64) + "\n")
+	case reflect.Bool:
+		buf.WriteString(" " + strconv.FormatBool(v.Bool()) + "\n")
+	default:
+		buf.WriteString("\n")
+		return yamlEncode(buf, v, indent+2)
+	}
+	return nil
+}
+
+// yamlScalar returns the string as a plain scalar where that is unambiguous,
+// otherwise double quoted.
+//
+func yamlScalar(s string) string {
+	quote := s == "" || strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.ContainsAny(s, "\n\r\t\\")
+	if !quote {
+		switch strings.ToLower(s) {
+		case "~", "null", "true", "false", "yes", "no", "y", "n", "on", "off":
+			quote = true
+		}
+	}
+	if !quote {
+		_, err := strconv.ParseFloat(s, 64)
+		quote = err == nil
+	}
+	if !quote {
+		return s
+	}
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// yamlNode is a parsed YAML scalar, mapping or sequence.
+//
+type yamlNode struct {
+	scalar *string
+	keys   []string
+	values []*yamlNode
+	items  []*yamlNode
+	line   int
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	at    int
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.at < len(p.lines) {
+		line = p.lines[p.at].number
+	}
+	return fmt.Errorf("id3: YAML line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// scan splits the document into lines, dropping comments, blank lines and
+// document markers.
+//
+func (p *yamlParser) scan(doc string) error {
+	for i, text := range strings.Split(doc, "\n") {
+		text = strings.TrimRight(yamlStripComment(text), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return fmt.Errorf("id3: YAML line %d: tabs cannot be used for indentation", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	return nil
+}
+
+func yamlStripComment(s string) string {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// parse returns the block node starting at the current line, which must have
+// the given indentation.
+//
+func (p *yamlParser) parse(indent int) (*yamlNode, error) {
+	first := p.lines[p.at]
+	if first.text == "-" || strings.HasPrefix(first.text, "- ") {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{line: p.lines[p.at].number}
+	for p.at < len(p.lines) && p.lines[p.at].indent == indent {
+		l := p.lines[p.at]
+		if l.text != "-" && !strings.HasPrefix(l.text, "- ") {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		var item *yamlNode
+		var err error
+		switch {
+		case rest == "":
+			//
+			// The item is the block on the following lines.
+			//
+			p.at++
+			if p.at < len(p.lines) && p.lines[p.at].indent > indent {
+				item, err = p.parse(p.lines[p.at].indent)
+			} else {
+				item = &yamlNode{scalar: new(string), line: l.number}
+			}
+		case yamlIsEntry(rest) || strings.HasPrefix(rest, "- "):
+			//
+			// The item is a block starting on this line, so re-read the line as
+			// if the marker were spaces.
+			//
+			p.lines[p.at] = yamlLine{number: l.number, indent: indent + len(l.text) - len(rest), text: rest}
+			item, err = p.parse(p.lines[p.at].indent)
+		default:
+			item, err = p.parseScalar(rest, l.number)
+			p.at++
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.items = append(node.items, item)
+	}
+	return node, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{line: p.lines[p.at].number}
+	for p.at < len(p.lines) && p.lines[p.at].indent == indent {
+		l := p.lines[p.at]
+		if !yamlIsEntry(l.text) {
+			return nil, p.errorf("expected 'key: value'")
+		}
+		key, rest, err := yamlSplitEntry(l.text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		var value *yamlNode
+		p.at++
+		switch {
+		case rest != "":
+			value, err = p.parseScalar(rest, l.number)
+		case p.at < len(p.lines) && p.lines[p.at].indent > indent:
+			value, err = p.parse(p.lines[p.at].indent)
+		case p.at < len(p.lines) && p.lines[p.at].indent == indent && strings.HasPrefix(p.lines[p.at].text, "- "):
+			//
+			// A sequence may be at the same indentation as its key.
+			//
+			value, err = p.parseSequence(indent)
+		default:
+			value = &yamlNode{line: l.number}
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, value)
+	}
+	return node, nil
+}
+
+// parseScalar parses an inline value, which may also be an empty flow mapping or
+// sequence.
+//
+func (p *yamlParser) parseScalar(s string, line int) (*yamlNode, error) {
+	node := &yamlNode{line: line}
+	switch {
+	case s == "{}" || s == "[]":
+		return node, nil
+	case strings.HasPrefix(s, "{") || strings.HasPrefix(s, "["):
+		return nil, fmt.Errorf("id3: YAML line %d: flow collections are not supported", line)
+	}
+	v, err := yamlUnquote(s)
+	if err != nil {
+		return nil, fmt.Errorf("id3: YAML line %d: %v", line, err)
+	}
+	node.scalar = &v
+	return node, nil
+}
+
+// yamlIsEntry reports whether the text starts with a mapping key.
+//
+func yamlIsEntry(s string) bool {
+	_, _, err := yamlSplitEntry(s)
+	return err == nil
+}
+
+func yamlSplitEntry(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		end := yamlQuoteEnd(s)
+		if end < 0 || !strings.HasPrefix(s[end:], ":") {
+			return "", "", errors.New("malformed key")
+		}
+		key, err := yamlUnquote(s[:end])
+		return key, strings.TrimSpace(s[end+1:]), err
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", errors.New("missing ':'")
+		}
+		i = len(s) - 1
+	}
+	return s[:i], strings.TrimSpace(s[i+1:]), nil
+}
+
+// yamlQuoteEnd returns the index after the closing quote of the quoted string
+// at the start of s, or -1.
+//
+func yamlQuoteEnd(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case q == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func yamlUnquote(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if yamlQuoteEnd(s) != len(s) {
+			return "", errors.New("malformed double quoted string")
+		}
+		var v string
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return "", err
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if yamlQuoteEnd(s) != len(s) {
+			return "", errors.New("malformed single quoted string")
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return s, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// yamlDecode stores the node in v, matching mapping keys to struct field names
+// without regard to case.
+//
+func yamlDecode(node *yamlNode, v reflect.Value) error {
+	if node.scalar != nil && v.Kind() != reflect.String {
+		switch *node.scalar {
+		case "", "~", "null":
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if node.scalar == nil && node.keys == nil && node.items == nil {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return yamlDecode(node, v.Elem())
+	case reflect.Struct:
+		if node.scalar != nil || node.items != nil {
+			return fmt.Errorf("id3: YAML line %d: expected a mapping", node.line)
+		}
+		for i, key := range node.keys {
+			f := v.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, key) })
+			if !f.IsValid() || !f.CanSet() {
+				return fmt.Errorf("id3: YAML line %d: unknown key '%s'", node.values[i].line, key)
+			}
+			if err := yamlDecode(node.values[i], f); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.scalar != nil || node.items != nil {
+			return fmt.Errorf("id3: YAML line %d: expected a mapping", node.line)
+		}
+		m := reflect.MakeMap(v.Type())
+		for i, key := range node.keys {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := yamlDecode(node.values[i], e); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key), e)
+		}
+		v.Set(m)
+	case reflect.Slice:
+		if node.scalar != nil || node.keys != nil {
+			return fmt.Errorf("id3: YAML line %d: expected a sequence", node.line)
+		}
+		s := reflect.MakeSlice(v.Type(), len(node.items), len(node.items))
+		for i, item := range node.items {
+			if err := yamlDecode(item, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	default:
+		if node.scalar == nil {
+			return fmt.Errorf("id3: YAML line %d: expected a scalar", node.line)
+		}
+		return yamlDecodeScalar(*node.scalar, node.line, v)
+	}
+	return nil
+}
+
+func yamlDecodeScalar(s string, line int, v reflect.Value) error {
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 64)
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, 64)
+		v.SetFloat(f)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("id3: cannot decode YAML into %s", v.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("id3: YAML line %d: %v", line, err)
+	}
+	return nil
+}